@@ -0,0 +1,107 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/image"
+)
+
+// RenderChannel renders a list of bundle images from a single package into a
+// complete declarative config. The bundles are ordered by version and placed
+// in a single channel, which is also the package's default channel, with
+// each bundle replacing the previous one.
+type RenderChannel struct {
+	BundleImages []string
+	Channel      string
+	Registry     image.Registry
+}
+
+func (r RenderChannel) Run(ctx context.Context) (*declcfg.DeclarativeConfig, error) {
+	if r.Channel == "" {
+		return nil, fmt.Errorf("channel name must be set")
+	}
+	if len(r.BundleImages) == 0 {
+		return nil, fmt.Errorf("at least one bundle image must be provided")
+	}
+
+	render := Render{
+		Refs:           r.BundleImages,
+		Registry:       r.Registry,
+		AllowedRefMask: RefBundleImage,
+	}
+	rendered, err := render.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := sets.New[string]()
+	for _, b := range rendered.Bundles {
+		pkgs.Insert(b.Package)
+	}
+	if pkgs.Len() != 1 {
+		return nil, fmt.Errorf("bundle images must all belong to one package, found packages %v", sets.List(pkgs))
+	}
+	pkgName := sets.List(pkgs)[0]
+
+	type versionedBundle struct {
+		bundle  declcfg.Bundle
+		version semver.Version
+	}
+	bundles := make([]versionedBundle, 0, len(rendered.Bundles))
+	for _, b := range rendered.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("parse properties for bundle %q: %v", b.Name, err)
+		}
+		if len(props.Packages) != 1 {
+			return nil, fmt.Errorf("bundle %q from image %q must have exactly 1 %q property, found %d", b.Name, b.Image, property.TypePackage, len(props.Packages))
+		}
+		v, err := semver.Parse(props.Packages[0].Version)
+		if err != nil {
+			return nil, fmt.Errorf("bundle %q from image %q has invalid version %q: %v", b.Name, b.Image, props.Packages[0].Version, err)
+		}
+		bundles = append(bundles, versionedBundle{b, v})
+	}
+	sort.SliceStable(bundles, func(i, j int) bool {
+		return bundles[i].version.LT(bundles[j].version)
+	})
+
+	cfg := &declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{
+			Schema:         declcfg.SchemaPackage,
+			Name:           pkgName,
+			DefaultChannel: r.Channel,
+		}},
+	}
+	ch := declcfg.Channel{
+		Schema:  declcfg.SchemaChannel,
+		Name:    r.Channel,
+		Package: pkgName,
+	}
+	for i, vb := range bundles {
+		entry := declcfg.ChannelEntry{Name: vb.bundle.Name}
+		if i > 0 {
+			prev := bundles[i-1]
+			if prev.version.EQ(vb.version) {
+				return nil, fmt.Errorf("cannot order bundles %q (image %q) and %q (image %q): both have version %q",
+					prev.bundle.Name, prev.bundle.Image, vb.bundle.Name, vb.bundle.Image, vb.version)
+			}
+			entry.Replaces = prev.bundle.Name
+		}
+		ch.Entries = append(ch.Entries, entry)
+		cfg.Bundles = append(cfg.Bundles, vb.bundle)
+	}
+	cfg.Channels = []declcfg.Channel{ch}
+
+	if _, err := declcfg.ConvertToModel(*cfg); err != nil {
+		return nil, fmt.Errorf("rendered config is invalid: %v", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,97 @@
+package action_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+func TestRenderChannel(t *testing.T) {
+	reg, err := newRegistry(t)
+	require.NoError(t, err)
+
+	type spec struct {
+		name          string
+		render        action.RenderChannel
+		expectPackage declcfg.Package
+		expectChannel declcfg.Channel
+		expectBundles []string
+		assertion     require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/OrderedByVersion",
+			render: action.RenderChannel{
+				BundleImages: []string{
+					"test.registry/foo-operator/foo-bundle:v0.2.0",
+					"test.registry/foo-operator/foo-bundle:v0.1.0",
+				},
+				Channel:  "stable",
+				Registry: reg,
+			},
+			expectPackage: declcfg.Package{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"},
+			expectChannel: declcfg.Channel{
+				Schema:  declcfg.SchemaChannel,
+				Name:    "stable",
+				Package: "foo",
+				Entries: []declcfg.ChannelEntry{
+					{Name: "foo.v0.1.0"},
+					{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				},
+			},
+			expectBundles: []string{"foo.v0.1.0", "foo.v0.2.0"},
+			assertion:     require.NoError,
+		},
+		{
+			name: "Error/NoChannel",
+			render: action.RenderChannel{
+				BundleImages: []string{"test.registry/foo-operator/foo-bundle:v0.1.0"},
+				Registry:     reg,
+			},
+			assertion: require.Error,
+		},
+		{
+			name: "Error/EqualVersions",
+			render: action.RenderChannel{
+				BundleImages: []string{
+					"test.registry/foo-operator/foo-bundle:v0.1.0",
+					"test.registry/foo-operator/foo-bundle:v0.1.0",
+				},
+				Channel:  "stable",
+				Registry: reg,
+			},
+			assertion: func(t require.TestingT, err error, _ ...interface{}) {
+				require.EqualError(t, err, `cannot order bundles "foo.v0.1.0" (image "test.registry/foo-operator/foo-bundle:v0.1.0") and "foo.v0.1.0" (image "test.registry/foo-operator/foo-bundle:v0.1.0"): both have version "0.1.0"`)
+			},
+		},
+		{
+			name: "Error/NotABundleImage",
+			render: action.RenderChannel{
+				BundleImages: []string{"test.registry/foo-operator/foo-index-declcfg:v0.2.0"},
+				Channel:      "stable",
+				Registry:     reg,
+			},
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := s.render.Run(context.Background())
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			require.Equal(t, []declcfg.Package{s.expectPackage}, actual.Packages)
+			require.Equal(t, []declcfg.Channel{s.expectChannel}, actual.Channels)
+			var names []string
+			for _, b := range actual.Bundles {
+				names = append(names, b.Name)
+			}
+			require.Equal(t, s.expectBundles, names)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// Platform identifies the platform an image was built for.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// String returns the platform in os-architecture[-variant] form, such as
+// "linux-arm64" or "linux-arm-v7".
+func (p Platform) String() string {
+	parts := []string{p.OS, p.Architecture}
+	if p.Variant != "" {
+		parts = append(parts, p.Variant)
+	}
+	return strings.Join(parts, "-")
+}
+
+// PlatformManifest is an entry of a manifest list: the digest of the image
+// manifest for one platform.
+type PlatformManifest struct {
+	Digest   string
+	Platform Platform
+}
+
+// ImageResolver reads manifest lists from image registries.
+type ImageResolver interface {
+	// ResolveManifestList returns the per-platform manifests of ref if ref
+	// is a manifest list or OCI image index, and nil if ref is a
+	// single-platform image.
+	ResolveManifestList(ctx context.Context, ref string) ([]PlatformManifest, error)
+}
+
+// ExpandMultiArchRelatedImages adds a related image for each platform of
+// every related image in cfg that is a manifest list, so that mirroring the
+// related images captures every architecture. Each added related image is
+// pinned to the platform's manifest digest and named after the original
+// related image with the platform appended, as in "operator-linux-arm64".
+// The manifest list itself is kept, since it is what clusters pull.
+// Single-platform images are left unchanged.
+func ExpandMultiArchRelatedImages(ctx context.Context, cfg *declcfg.DeclarativeConfig, resolver ImageResolver) error {
+	resolved := map[string][]PlatformManifest{}
+	for i, b := range cfg.Bundles {
+		existing := sets.New[declcfg.RelatedImage](b.RelatedImages...)
+		var expanded []declcfg.RelatedImage
+		for _, ri := range b.RelatedImages {
+			manifests, ok := resolved[ri.Image]
+			if !ok {
+				var err error
+				manifests, err = resolver.ResolveManifestList(ctx, ri.Image)
+				if err != nil {
+					return fmt.Errorf("package %q, bundle %q: resolve related image %q: %v", b.Package, b.Name, ri.Image, err)
+				}
+				resolved[ri.Image] = manifests
+			}
+			if len(manifests) == 0 {
+				continue
+			}
+
+			named, err := reference.ParseNormalizedNamed(ri.Image)
+			if err != nil {
+				return fmt.Errorf("package %q, bundle %q: parse related image %q: %v", b.Package, b.Name, ri.Image, err)
+			}
+			for _, m := range manifests {
+				pinned, err := pinDigest(named, m.Digest)
+				if err != nil {
+					return fmt.Errorf("package %q, bundle %q: related image %q, platform %q: %v", b.Package, b.Name, ri.Image, m.Platform, err)
+				}
+				name := m.Platform.String()
+				if ri.Name != "" {
+					name = ri.Name + "-" + name
+				}
+				platformImage := declcfg.RelatedImage{Name: name, Image: pinned}
+				if existing.Has(platformImage) {
+					continue
+				}
+				existing.Insert(platformImage)
+				expanded = append(expanded, platformImage)
+			}
+		}
+		cfg.Bundles[i].RelatedImages = append(cfg.Bundles[i].RelatedImages, expanded...)
+	}
+	return nil
+}
+
+// pinDigest returns the reference to the repository of named with the given
+// digest, dropping any tag or digest named already has.
+func pinDigest(named reference.Named, digest string) (string, error) {
+	ref, err := reference.ParseNormalizedNamed(reference.TrimNamed(named).Name() + "@" + digest)
+	if err != nil {
+		return "", err
+	}
+	return ref.String(), nil
+}
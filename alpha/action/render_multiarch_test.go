@@ -0,0 +1,69 @@
+package action_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+type fakeImageResolver map[string][]action.PlatformManifest
+
+func (r fakeImageResolver) ResolveManifestList(_ context.Context, ref string) ([]action.PlatformManifest, error) {
+	if ref == "quay.io/example/broken:v1" {
+		return nil, errors.New("manifest unknown")
+	}
+	return r[ref], nil
+}
+
+func TestExpandMultiArchRelatedImages(t *testing.T) {
+	const (
+		amd64Digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		armDigest   = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	resolver := fakeImageResolver{
+		"quay.io/example/operator:v1": {
+			{Digest: amd64Digest, Platform: action.Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: armDigest, Platform: action.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		},
+	}
+
+	cfg := &declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{{
+			Schema:  declcfg.SchemaBundle,
+			Package: "foo",
+			Name:    "foo.v1.0.0",
+			Image:   "quay.io/example/foo-bundle:v1",
+			RelatedImages: []declcfg.RelatedImage{
+				{Name: "", Image: "quay.io/example/foo-bundle:v1"},
+				{Name: "operator", Image: "quay.io/example/operator:v1"},
+			},
+		}},
+	}
+	require.NoError(t, action.ExpandMultiArchRelatedImages(context.Background(), cfg, resolver))
+	require.Equal(t, []declcfg.RelatedImage{
+		{Name: "", Image: "quay.io/example/foo-bundle:v1"},
+		{Name: "operator", Image: "quay.io/example/operator:v1"},
+		{Name: "operator-linux-amd64", Image: "quay.io/example/operator@" + amd64Digest},
+		{Name: "operator-linux-arm-v7", Image: "quay.io/example/operator@" + armDigest},
+	}, cfg.Bundles[0].RelatedImages)
+
+	// Expanding again does not add duplicates.
+	require.NoError(t, action.ExpandMultiArchRelatedImages(context.Background(), cfg, resolver))
+	require.Len(t, cfg.Bundles[0].RelatedImages, 4)
+
+	broken := &declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{{
+			Schema:        declcfg.SchemaBundle,
+			Package:       "foo",
+			Name:          "foo.v1.0.0",
+			RelatedImages: []declcfg.RelatedImage{{Name: "operator", Image: "quay.io/example/broken:v1"}},
+		}},
+	}
+	err := action.ExpandMultiArchRelatedImages(context.Background(), broken, resolver)
+	require.EqualError(t, err, `package "foo", bundle "foo.v1.0.0": resolve related image "quay.io/example/broken:v1": manifest unknown`)
+}
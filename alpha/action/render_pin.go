@@ -0,0 +1,75 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// DigestResolver resolves image references to digests.
+type DigestResolver interface {
+	// ResolveDigest returns the digest of the manifest that ref currently
+	// refers to.
+	ResolveDigest(ctx context.Context, ref string) (string, error)
+}
+
+// PinImageDigests rewrites every bundle image and related image in cfg that
+// refers to a tag so that it refers to the digest the tag currently
+// resolves to, which makes the catalog reproducible. Images that already
+// include a digest are left unchanged, and each tag is resolved only once.
+//
+// Images that cannot be parsed or resolved are left unchanged, and one
+// error per such image is returned as an aggregate error after all other
+// images have been pinned.
+func PinImageDigests(ctx context.Context, cfg *declcfg.DeclarativeConfig, resolver DigestResolver) error {
+	var (
+		pinned = map[string]string{}
+		failed = map[string]bool{}
+		errs   []error
+	)
+	pin := func(image string) string {
+		if image == "" || failed[image] {
+			return image
+		}
+		if p, ok := pinned[image]; ok {
+			return p
+		}
+
+		p, err := pinImage(ctx, image, resolver)
+		if err != nil {
+			failed[image] = true
+			errs = append(errs, fmt.Errorf("pin image %q: %v", image, err))
+			return image
+		}
+		pinned[image] = p
+		return p
+	}
+
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		b.Image = pin(b.Image)
+		for j := range b.RelatedImages {
+			b.RelatedImages[j].Image = pin(b.RelatedImages[j].Image)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func pinImage(ctx context.Context, image string, resolver DigestResolver) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return image, nil
+	}
+	digest, err := resolver.ResolveDigest(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	return pinDigest(named, digest)
+}
@@ -0,0 +1,90 @@
+package action_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/action"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+type fakeDigestResolver struct {
+	digests  map[string]string
+	resolved map[string]int
+}
+
+func (r fakeDigestResolver) ResolveDigest(_ context.Context, ref string) (string, error) {
+	r.resolved[ref]++
+	d, ok := r.digests[ref]
+	if !ok {
+		return "", errors.New("manifest unknown")
+	}
+	return d, nil
+}
+
+func TestPinImageDigests(t *testing.T) {
+	const (
+		bundleDigest   = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		operatorDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+		pinnedDigest   = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+	)
+	resolver := fakeDigestResolver{
+		digests: map[string]string{
+			"quay.io/example/foo-bundle:v1": bundleDigest,
+			"quay.io/example/operator:v1":   operatorDigest,
+		},
+		resolved: map[string]int{},
+	}
+
+	cfg := &declcfg.DeclarativeConfig{
+		Bundles: []declcfg.Bundle{
+			{
+				Schema:  declcfg.SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v1.0.0",
+				Image:   "quay.io/example/foo-bundle:v1",
+				RelatedImages: []declcfg.RelatedImage{
+					{Name: "operator", Image: "quay.io/example/operator:v1"},
+					{Name: "pinned", Image: "quay.io/example/pinned@" + pinnedDigest},
+					{Name: "missing", Image: "quay.io/example/missing:v1"},
+				},
+			},
+			{
+				Schema:  declcfg.SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v1.0.1",
+				RelatedImages: []declcfg.RelatedImage{
+					{Name: "operator", Image: "quay.io/example/operator:v1"},
+					{Name: "missing", Image: "quay.io/example/missing:v1"},
+					{Name: "invalid", Image: "quay.io/Example/INVALID:v1"},
+				},
+			},
+		},
+	}
+
+	err := action.PinImageDigests(context.Background(), cfg, resolver)
+	require.EqualError(t, err, `[pin image "quay.io/example/missing:v1": manifest unknown, pin image "quay.io/Example/INVALID:v1": invalid reference format: repository name must be lowercase]`)
+
+	require.Equal(t, "quay.io/example/foo-bundle@"+bundleDigest, cfg.Bundles[0].Image)
+	require.Equal(t, []declcfg.RelatedImage{
+		{Name: "operator", Image: "quay.io/example/operator@" + operatorDigest},
+		{Name: "pinned", Image: "quay.io/example/pinned@" + pinnedDigest},
+		{Name: "missing", Image: "quay.io/example/missing:v1"},
+	}, cfg.Bundles[0].RelatedImages)
+	require.Equal(t, "", cfg.Bundles[1].Image)
+	require.Equal(t, []declcfg.RelatedImage{
+		{Name: "operator", Image: "quay.io/example/operator@" + operatorDigest},
+		{Name: "missing", Image: "quay.io/example/missing:v1"},
+		{Name: "invalid", Image: "quay.io/Example/INVALID:v1"},
+	}, cfg.Bundles[1].RelatedImages)
+
+	// Each tag is resolved once.
+	require.Equal(t, map[string]int{
+		"quay.io/example/foo-bundle:v1": 1,
+		"quay.io/example/operator:v1":   1,
+		"quay.io/example/missing:v1":    1,
+	}, resolver.resolved)
+}
@@ -0,0 +1,61 @@
+package declcfg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	require.NotEmpty(t, cfg.Bundles[0].CsvJSON)
+	require.NotEmpty(t, cfg.Bundles[0].Objects)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBinary(cfg, &buf))
+
+	loaded, err := LoadBinary(&buf)
+	require.NoError(t, err)
+	require.Equal(t, cfg, *loaded)
+}
+
+func TestLoadBinaryErrors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteBinary(buildValidDeclarativeConfig(false), &buf))
+	valid := buf.Bytes()
+
+	type spec struct {
+		name      string
+		data      []byte
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Error/Empty",
+			data:      nil,
+			assertion: hasError("read binary header: EOF"),
+		},
+		{
+			name:      "Error/NotBinary",
+			data:      []byte(`{"schema":"olm.package","name":"foo"}`),
+			assertion: hasError("data is not in the binary declarative config format"),
+		},
+		{
+			name:      "Error/UnsupportedVersion",
+			data:      append(append([]byte{}, binaryMagic...), BinaryFormatVersion+1),
+			assertion: hasError("unsupported binary format version 2, expected 1"),
+		},
+		{
+			name:      "Error/Truncated",
+			data:      valid[:len(valid)/2],
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := LoadBinary(bytes.NewReader(s.data))
+			s.assertion(t, err)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package declcfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type equalOptions struct {
+	ignoreImage          bool
+	ignoreRelatedImages  bool
+	ignoredPropertyTypes sets.Set[string]
+}
+
+// EqualOption excludes part of a bundle from the comparison made by
+// BundlesEqual.
+type EqualOption func(*equalOptions)
+
+// IgnoreImage excludes the bundles' Image fields from the comparison.
+func IgnoreImage() EqualOption {
+	return func(opts *equalOptions) {
+		opts.ignoreImage = true
+	}
+}
+
+// IgnoreRelatedImages excludes the bundles' RelatedImages from the
+// comparison.
+func IgnoreRelatedImages() EqualOption {
+	return func(opts *equalOptions) {
+		opts.ignoreRelatedImages = true
+	}
+}
+
+// IgnorePropertyTypes excludes properties of the given types from the
+// comparison.
+func IgnorePropertyTypes(types ...string) EqualOption {
+	return func(opts *equalOptions) {
+		opts.ignoredPropertyTypes.Insert(types...)
+	}
+}
+
+// BundlesEqual reports whether a and b are equal. By default, every field
+// is compared: fields tagged with `hash:"set"` are compared as sets,
+// ignoring order and repeated elements, property values are compared after
+// canonicalizing their JSON, and fields that are not serialized, such as
+// CsvJSON and Objects, are compared too. Bundles loaded with
+// WithLazyRuntimeFields are compared as if those fields were populated,
+// without modifying the bundles, so lazily and eagerly loaded copies of a
+// bundle are equal. Options exclude the image, related images, or
+// properties of specific types from the comparison.
+//
+// Each call hashes every set field of both bundles. When the same bundles
+// are compared many times, use a BundleHashCache instead.
+func BundlesEqual(a, b Bundle, opts ...EqualOption) bool {
+	options := equalOptions{ignoredPropertyTypes: sets.New[string]()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !bundleScalarsEqual(&a, &b, options) {
+		return false
+	}
+	return bundleSetHashes(&a, options) == bundleSetHashes(&b, options)
+}
+
+// BundleHashCache caches the hashes of bundles' set fields so that
+// repeated comparisons of bundles with the same content only canonicalize
+// and sort their properties and related images once. A BundleHashCache is
+// safe for concurrent use.
+//
+// Entries are keyed by a digest of the raw bytes of a bundle's Properties
+// and RelatedImages, so any change to a bundle, including modifying
+// elements of those slices in place, is seen by the next comparison. The
+// cache does not reference the bundles themselves; its size grows with the
+// number of distinct bundle contents compared.
+type BundleHashCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]setHashes
+}
+
+// NewBundleHashCache returns an empty BundleHashCache.
+func NewBundleHashCache() *BundleHashCache {
+	return &BundleHashCache{entries: map[[sha256.Size]byte]setHashes{}}
+}
+
+// Equal reports whether a and b are equal, as defined by BundlesEqual with
+// no options.
+func (c *BundleHashCache) Equal(a, b *Bundle) bool {
+	if !bundleScalarsEqual(a, b, equalOptions{}) {
+		return false
+	}
+	return c.hashes(a) == c.hashes(b)
+}
+
+func (c *BundleHashCache) hashes(b *Bundle) setHashes {
+	key := bundleContentKey(b)
+
+	c.mu.Lock()
+	hashes, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return hashes
+	}
+
+	hashes = bundleSetHashes(b, equalOptions{})
+	c.mu.Lock()
+	c.entries[key] = hashes
+	c.mu.Unlock()
+	return hashes
+}
+
+// bundleContentKey digests the raw set fields of b in order. Unlike
+// bundleSetHashes, it neither parses property values nor sorts elements.
+func bundleContentKey(b *Bundle) [sha256.Size]byte {
+	h := sha256.New()
+	writeField := func(f string) {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	writeField(strconv.Itoa(len(b.Properties)))
+	for _, p := range b.Properties {
+		writeField(p.Type)
+		writeField(string(p.Value))
+	}
+	for _, ri := range b.RelatedImages {
+		writeField(ri.Name)
+		writeField(ri.Image)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+func bundleScalarsEqual(a, b *Bundle, opts equalOptions) bool {
+	if a.Schema != b.Schema || a.Name != b.Name || a.Package != b.Package {
+		return false
+	}
+	if !opts.ignoreImage && a.Image != b.Image {
+		return false
+	}
+	aCSV, aObjects, aOK := runtimeFields(a)
+	bCSV, bObjects, bOK := runtimeFields(b)
+	if !aOK || !bOK || aCSV != bCSV || len(aObjects) != len(bObjects) {
+		return false
+	}
+	for i := range aObjects {
+		if aObjects[i] != bObjects[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// runtimeFields returns b's CsvJSON and Objects, populating them in a copy
+// of b if b was loaded with WithLazyRuntimeFields. ok is false if they
+// cannot be populated.
+func runtimeFields(b *Bundle) (csvJSON string, objects []string, ok bool) {
+	if b.pendingObjects == nil {
+		return b.CsvJSON, b.Objects, true
+	}
+	populated := *b
+	if err := populated.PopulateRuntimeFields(); err != nil {
+		return "", nil, false
+	}
+	return populated.CsvJSON, populated.Objects, true
+}
+
+type setHashes struct {
+	properties    [sha256.Size]byte
+	relatedImages [sha256.Size]byte
+}
+
+func bundleSetHashes(b *Bundle, opts equalOptions) setHashes {
+	props := make([][sha256.Size]byte, 0, len(b.Properties))
+	for _, p := range b.Properties {
+		if opts.ignoredPropertyTypes.Has(p.Type) {
+			continue
+		}
+		value := []byte(p.Value)
+		if canonical, err := canonicalizeJSON(value); err == nil {
+			value = canonical
+		}
+		props = append(props, hashFields([]byte(p.Type), value))
+	}
+	hashes := setHashes{properties: hashSet(props)}
+	if !opts.ignoreRelatedImages {
+		related := make([][sha256.Size]byte, 0, len(b.RelatedImages))
+		for _, ri := range b.RelatedImages {
+			related = append(related, hashFields([]byte(ri.Name), []byte(ri.Image)))
+		}
+		hashes.relatedImages = hashSet(related)
+	}
+	return hashes
+}
+
+// hashFields hashes a sequence of fields, separating them so that
+// different splits of the same bytes hash differently.
+func hashFields(fields ...[]byte) [sha256.Size]byte {
+	h := sha256.New()
+	for _, f := range fields {
+		h.Write(f)
+		h.Write([]byte{0})
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// hashSet hashes a set of element hashes independently of their order.
+// Repeated elements are hashed once.
+func hashSet(elems [][sha256.Size]byte) [sha256.Size]byte {
+	sort.Slice(elems, func(i, j int) bool {
+		return bytes.Compare(elems[i][:], elems[j][:]) < 0
+	})
+	h := sha256.New()
+	for i, e := range elems {
+		if i > 0 && e == elems[i-1] {
+			continue
+		}
+		h.Write(e[:])
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
@@ -0,0 +1,153 @@
+package declcfg
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestBundlesEqual(t *testing.T) {
+	type spec struct {
+		name     string
+		mutate   func(*Bundle)
+		expected bool
+	}
+	specs := []spec{
+		{
+			name:     "Equal/Identical",
+			mutate:   func(*Bundle) {},
+			expected: true,
+		},
+		{
+			name: "Equal/PropertiesReordered",
+			mutate: func(b *Bundle) {
+				b.Properties[0], b.Properties[len(b.Properties)-1] = b.Properties[len(b.Properties)-1], b.Properties[0]
+			},
+			expected: true,
+		},
+		{
+			name: "Equal/PropertyValueFormatting",
+			mutate: func(b *Bundle) {
+				b.Properties[packagePropertyIndex(t, b)].Value = json.RawMessage(`{ "packageName" : "foo", "version" : "0.1.0" }`)
+			},
+			expected: true,
+		},
+		{
+			name: "NotEqual/PropertyValue",
+			mutate: func(b *Bundle) {
+				b.Properties[packagePropertyIndex(t, b)] = property.MustBuildPackage("foo", "0.2.0")
+			},
+			expected: false,
+		},
+		{
+			name: "NotEqual/RelatedImages",
+			mutate: func(b *Bundle) {
+				b.RelatedImages = append(b.RelatedImages, RelatedImage{Name: "extra", Image: "quay.io/example/extra:v1"})
+			},
+			expected: false,
+		},
+		{
+			name: "NotEqual/CsvJSON",
+			mutate: func(b *Bundle) {
+				b.CsvJSON = "{}"
+			},
+			expected: false,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			a := newTestBundle("foo", "0.1.0")
+			b := newTestBundle("foo", "0.1.0")
+			s.mutate(&b)
+			require.Equal(t, s.expected, BundlesEqual(a, b))
+			require.Equal(t, s.expected, NewBundleHashCache().Equal(&a, &b))
+		})
+	}
+}
+
+func TestBundlesEqualOptions(t *testing.T) {
+	a := newTestBundle("foo", "0.1.0")
+	b := newTestBundle("foo", "0.1.0")
+	b.Image = "quay.io/example/mirror:v0.1.0"
+	b.RelatedImages = []RelatedImage{{Name: "bundle", Image: b.Image}}
+	b.Properties = append(b.Properties, property.Property{Type: "example.com/note", Value: json.RawMessage(`"mirrored"`)})
+
+	require.False(t, BundlesEqual(a, b))
+	require.False(t, BundlesEqual(a, b, IgnoreImage(), IgnoreRelatedImages()))
+	require.False(t, BundlesEqual(a, b, IgnoreImage(), IgnorePropertyTypes("example.com/note")))
+	require.True(t, BundlesEqual(a, b, IgnoreImage(), IgnoreRelatedImages(), IgnorePropertyTypes("example.com/note")))
+}
+
+func TestBundleHashCacheMutation(t *testing.T) {
+	a := newTestBundle("foo", "0.1.0")
+	b := newTestBundle("foo", "0.1.0")
+	c := NewBundleHashCache()
+	require.True(t, c.Equal(&a, &b))
+
+	// Replacing the slice is detected.
+	b.Properties = append([]property.Property{}, b.Properties[1:]...)
+	require.False(t, c.Equal(&a, &b))
+
+	// So is modifying an element in place.
+	b = newTestBundle("foo", "0.1.0")
+	require.True(t, c.Equal(&a, &b))
+	b.Properties[packagePropertyIndex(t, &b)] = property.MustBuildPackage("foo", "0.2.0")
+	require.False(t, c.Equal(&a, &b))
+
+	// And undoing the change.
+	b.Properties[packagePropertyIndex(t, &b)] = property.MustBuildPackage("foo", "0.1.0")
+	require.True(t, c.Equal(&a, &b))
+
+	b.RelatedImages[0].Image = "quay.io/example/mirror:v0.1.0"
+	require.False(t, c.Equal(&a, &b))
+}
+
+func TestBundlesEqualLazyRuntimeFields(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+- type: olm.bundle.object
+  value:
+    ref: objects/csv.yaml
+`)},
+		"objects/csv.yaml": &fstest.MapFile{Data: []byte(`kind: ClusterServiceVersion
+apiVersion: operators.coreos.com/v1alpha1
+metadata:
+  name: foo.v0.1.0
+`)},
+		".indexignore": &fstest.MapFile{Data: []byte("objects\n")},
+	}
+	eager, err := LoadFS(context.Background(), fsys)
+	require.NoError(t, err)
+	lazy, err := LoadFS(context.Background(), fsys, WithLazyRuntimeFields(true))
+	require.NoError(t, err)
+
+	require.True(t, BundlesEqual(eager.Bundles[0], lazy.Bundles[0]))
+	require.True(t, NewBundleHashCache().Equal(&eager.Bundles[0], &lazy.Bundles[0]))
+	require.Empty(t, lazy.Bundles[0].Objects)
+
+	eager.Bundles[0].Objects = []string{"{}"}
+	require.False(t, BundlesEqual(eager.Bundles[0], lazy.Bundles[0]))
+}
+
+func packagePropertyIndex(t *testing.T, b *Bundle) int {
+	for i, p := range b.Properties {
+		if p.Type == property.TypePackage {
+			return i
+		}
+	}
+	t.Fatalf("bundle %q has no %q property", b.Name, property.TypePackage)
+	return -1
+}
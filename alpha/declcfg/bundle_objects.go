@@ -0,0 +1,129 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// FlattenBundleObjects splits each inline olm.bundle.object property of b
+// whose data is a JSON array into one olm.bundle.object property per array
+// element, preserving the order of properties and objects. Properties that
+// reference a file are left as-is. If b's Objects are populated, they are
+// split the same way and CsvJSON is recomputed.
+func FlattenBundleObjects(b *Bundle) error {
+	var props []property.Property
+	for i, p := range b.Properties {
+		if p.Type != property.TypeBundleObject {
+			props = append(props, p)
+			continue
+		}
+		var obj property.BundleObject
+		if err := json.Unmarshal(p.Value, &obj); err != nil {
+			return fmt.Errorf("parse property[%d] of type %q: %v", i, p.Type, err)
+		}
+		if obj.IsRef() {
+			props = append(props, p)
+			continue
+		}
+		data, err := obj.GetData(nil, "")
+		if err != nil {
+			return fmt.Errorf("get data for property[%d] of type %q: %v", i, p.Type, err)
+		}
+		elems, ok := splitJSONArray(data)
+		if !ok {
+			props = append(props, p)
+			continue
+		}
+		for _, elem := range elems {
+			props = append(props, property.MustBuildBundleObjectData(elem))
+		}
+	}
+	b.Properties = props
+
+	if len(b.Objects) > 0 {
+		var objs []string
+		for _, o := range b.Objects {
+			elems, ok := splitJSONArray([]byte(o))
+			if !ok {
+				objs = append(objs, o)
+				continue
+			}
+			for _, elem := range elems {
+				objs = append(objs, string(elem))
+			}
+		}
+		b.Objects = objs
+		b.CsvJSON = extractCSV(b.Objects)
+	}
+	return nil
+}
+
+// PackBundleObjects is the reverse of FlattenBundleObjects. It combines all
+// inline olm.bundle.object properties of b into a single property whose data
+// is a JSON array of the objects, in their original order, at the position
+// of the first such property. Properties that reference a file are left
+// as-is. b's Objects and CsvJSON are not modified, since consumers of those
+// fields expect one object per element.
+func PackBundleObjects(b *Bundle) error {
+	var (
+		props    []property.Property
+		objs     []json.RawMessage
+		packedAt = -1
+	)
+	for i, p := range b.Properties {
+		if p.Type != property.TypeBundleObject {
+			props = append(props, p)
+			continue
+		}
+		var obj property.BundleObject
+		if err := json.Unmarshal(p.Value, &obj); err != nil {
+			return fmt.Errorf("parse property[%d] of type %q: %v", i, p.Type, err)
+		}
+		if obj.IsRef() {
+			props = append(props, p)
+			continue
+		}
+		data, err := obj.GetData(nil, "")
+		if err != nil {
+			return fmt.Errorf("get data for property[%d] of type %q: %v", i, p.Type, err)
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("property[%d] of type %q: data is not valid JSON", i, p.Type)
+		}
+		if packedAt < 0 {
+			packedAt = len(props)
+			props = append(props, property.Property{})
+		}
+		objs = append(objs, data)
+	}
+	if packedAt < 0 {
+		return nil
+	}
+	data, err := json.Marshal(objs)
+	if err != nil {
+		return err
+	}
+	props[packedAt] = property.MustBuildBundleObjectData(data)
+	b.Properties = props
+	return nil
+}
+
+// splitJSONArray returns the elements of data if it is a JSON array.
+func splitJSONArray(data []byte) ([][]byte, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, false
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(trimmed, &elems); err != nil {
+		return nil, false
+	}
+	out := make([][]byte, 0, len(elems))
+	for _, e := range elems {
+		out = append(out, e)
+	}
+	return out, true
+}
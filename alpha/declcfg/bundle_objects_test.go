@@ -0,0 +1,53 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestFlattenAndPackBundleObjects(t *testing.T) {
+	csv := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"foo.v0.1.0"}}`
+	crd := `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition"}`
+	packed := []property.Property{
+		property.MustBuildPackage("foo", "0.1.0"),
+		property.MustBuildBundleObjectData([]byte("[" + csv + "," + crd + "]")),
+		property.MustBuildBundleObjectRef("objects/foo.yaml"),
+	}
+	flattened := []property.Property{
+		property.MustBuildPackage("foo", "0.1.0"),
+		property.MustBuildBundleObjectData([]byte(csv)),
+		property.MustBuildBundleObjectData([]byte(crd)),
+		property.MustBuildBundleObjectRef("objects/foo.yaml"),
+	}
+
+	b := Bundle{
+		Schema:     SchemaBundle,
+		Package:    "foo",
+		Name:       "foo.v0.1.0",
+		Properties: append([]property.Property(nil), packed...),
+		Objects:    []string{"[" + csv + "," + crd + "]"},
+	}
+	require.NoError(t, FlattenBundleObjects(&b))
+	require.Equal(t, flattened, b.Properties)
+	require.Equal(t, []string{csv, crd}, b.Objects)
+	require.Equal(t, csv, b.CsvJSON)
+
+	// The flattened properties produce the same objects when read back.
+	reread := []Bundle{{Properties: flattened[:3]}}
+	require.NoError(t, readBundleObjects(reread, nil, ""))
+	require.Equal(t, []string{csv, crd}, reread[0].Objects)
+	require.Equal(t, csv, reread[0].CsvJSON)
+
+	require.NoError(t, PackBundleObjects(&b))
+	require.Equal(t, packed, b.Properties)
+	require.Equal(t, []string{csv, crd}, b.Objects)
+
+	// Flattening is a no-op for bundles that are already flat.
+	b = Bundle{Properties: append([]property.Property(nil), flattened...)}
+	require.NoError(t, FlattenBundleObjects(&b))
+	require.Equal(t, flattened, b.Properties)
+	require.Nil(t, b.Objects)
+}
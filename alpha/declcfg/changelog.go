@@ -0,0 +1,160 @@
+package declcfg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteChangelog writes Markdown release notes describing how newCfg
+// differs from oldCfg. Changes are grouped by package, in sorted order,
+// and list the versions of added and removed bundles, newest first, as
+// well as added, removed, and changed channels and default channel
+// changes. Packages without such changes are omitted.
+//
+// The changes are computed with DiffSummary and ChannelDiff, so like them,
+// WriteChangelog compares the declarative configs directly and does not
+// validate them. An error is returned if an added or removed bundle has no
+// valid version, or a channel has more than one entry with the same name.
+func WriteChangelog(oldCfg, newCfg DeclarativeConfig, w io.Writer) error {
+	stats, err := DiffSummary(oldCfg, newCfg)
+	if err != nil {
+		return err
+	}
+
+	type packageChanges struct {
+		added, removed []string
+		channels       []string
+	}
+	changes := map[string]*packageChanges{}
+	changesFor := func(pkg string) *packageChanges {
+		if _, ok := changes[pkg]; !ok {
+			changes[pkg] = &packageChanges{}
+		}
+		return changes[pkg]
+	}
+	for pkg, vs := range stats.NewVersions {
+		changesFor(pkg).added = vs
+	}
+	for pkg, vs := range stats.RemovedVersions {
+		changesFor(pkg).removed = vs
+	}
+
+	oldDefaults := map[string]string{}
+	for _, p := range oldCfg.Packages {
+		oldDefaults[p.Name] = p.DefaultChannel
+	}
+	for _, p := range newCfg.Packages {
+		if o, ok := oldDefaults[p.Name]; ok && o != p.DefaultChannel {
+			changesFor(p.Name).channels = append(changesFor(p.Name).channels,
+				fmt.Sprintf("Default channel changed from `%s` to `%s`", o, p.DefaultChannel))
+		}
+	}
+
+	type key struct {
+		pkg  string
+		name string
+	}
+	oldChannels := map[key]Channel{}
+	for _, c := range oldCfg.Channels {
+		oldChannels[key{c.Package, c.Name}] = c
+	}
+	newChannels := map[key]Channel{}
+	for _, c := range newCfg.Channels {
+		newChannels[key{c.Package, c.Name}] = c
+	}
+	keys := unionKeys(oldChannels, newChannels).UnsortedList()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pkg != keys[j].pkg {
+			return keys[i].pkg < keys[j].pkg
+		}
+		return keys[i].name < keys[j].name
+	})
+	for _, k := range keys {
+		o, inOld := oldChannels[k]
+		n, inNew := newChannels[k]
+		var line string
+		switch {
+		case !inOld:
+			line = fmt.Sprintf("Added channel `%s`", k.name)
+		case !inNew:
+			line = fmt.Sprintf("Removed channel `%s`", k.name)
+		default:
+			cs, err := ChannelDiff(o, n)
+			if err != nil {
+				return err
+			}
+			line = channelChangelogLine(*cs)
+		}
+		if line != "" {
+			changesFor(k.pkg).channels = append(changesFor(k.pkg).channels, line)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Changelog\n")
+	if len(changes) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+	pkgs := make([]string, 0, len(changes))
+	for pkg := range changes {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	writeVersions := func(title string, ascending []string) {
+		if len(ascending) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for i := len(ascending) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "- %s\n", ascending[i])
+		}
+	}
+	for _, pkg := range pkgs {
+		c := changes[pkg]
+		fmt.Fprintf(&b, "\n## %s\n", pkg)
+		writeVersions("New versions", c.added)
+		writeVersions("Removed versions", c.removed)
+		if len(c.channels) > 0 {
+			b.WriteString("\n### Channel changes\n\n")
+			for _, line := range c.channels {
+				fmt.Fprintf(&b, "- %s\n", line)
+			}
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// channelChangelogLine describes the entries added to and removed from a
+// channel, and the entries whose upgrade edges changed, or returns an
+// empty string if there are none.
+func channelChangelogLine(cs ChannelChangeSet) string {
+	var parts []string
+	if len(cs.Added) > 0 {
+		parts = append(parts, "added "+codeList(cs.Added))
+	}
+	if len(cs.Removed) > 0 {
+		parts = append(parts, "removed "+codeList(cs.Removed))
+	}
+	if len(cs.Changed) > 0 {
+		changed := make([]string, 0, len(cs.Changed))
+		for _, c := range cs.Changed {
+			changed = append(changed, c.Name)
+		}
+		parts = append(parts, "changed upgrade edges of "+codeList(changed))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Channel `%s`: %s", cs.Channel, strings.Join(parts, "; "))
+}
+
+func codeList(items []string) string {
+	quoted := make([]string, 0, len(items))
+	for _, i := range items {
+		quoted = append(quoted, "`"+i+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
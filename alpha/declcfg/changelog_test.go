@@ -0,0 +1,67 @@
+package declcfg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteChangelog(t *testing.T) {
+	oldCfg := DeclarativeConfig{
+		Packages: []Package{
+			newTestPackage("foo", "beta", svgSmallCircle),
+			newTestPackage("bar", "stable", svgSmallCircle),
+		},
+		Channels: []Channel{
+			newTestChannel("foo", "beta", ChannelEntry{Name: testBundleName("foo", "0.1.0")}),
+			newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")}),
+			newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "1.0.0")}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("bar", "1.0.0"),
+		},
+	}
+	newCfg := DeclarativeConfig{
+		Packages: []Package{
+			newTestPackage("foo", "stable", svgSmallCircle),
+			newTestPackage("bar", "stable", svgSmallCircle),
+		},
+		Channels: []Channel{
+			newTestChannel("foo", "beta",
+				ChannelEntry{Name: testBundleName("foo", "0.2.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.10.0"), Replaces: testBundleName("foo", "0.2.0")},
+			),
+			newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.10.0")}),
+			newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "1.0.0"), SkipRange: "<1.0.0"}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.10.0"),
+			newTestBundle("bar", "1.0.0"),
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteChangelog(oldCfg, newCfg, buf))
+	require.Equal(t, "# Changelog\n"+
+		"\n## bar\n"+
+		"\n### Channel changes\n\n"+
+		"- Channel `stable`: changed upgrade edges of `bar.v1.0.0`\n"+
+		"\n## foo\n"+
+		"\n### New versions\n\n"+
+		"- 0.10.0\n"+
+		"- 0.2.0\n"+
+		"\n### Removed versions\n\n"+
+		"- 0.1.0\n"+
+		"\n### Channel changes\n\n"+
+		"- Default channel changed from `beta` to `stable`\n"+
+		"- Removed channel `alpha`\n"+
+		"- Channel `beta`: added `foo.v0.10.0`, `foo.v0.2.0`; removed `foo.v0.1.0`\n"+
+		"- Added channel `stable`\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteChangelog(oldCfg, oldCfg, buf))
+	require.Equal(t, "# Changelog\n\nNo changes.\n", buf.String())
+}
@@ -0,0 +1,231 @@
+package declcfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// MergeDuplicateChannels combines channels in cfg that share a package and
+// name into a single channel, kept at the position of the first of them.
+// The merged channel has the union of the duplicates' entries and
+// properties.
+//
+// Duplicates conflict if they define an entry with the same name but
+// different replaces, skips, or skipRange, or if they define properties of
+// the same type with different values. All conflicts are returned as an
+// aggregate error, and cfg is left unmodified.
+func MergeDuplicateChannels(cfg *DeclarativeConfig) error {
+	type key struct {
+		pkg  string
+		name string
+	}
+	indices := map[key][]int{}
+	var order []key
+	for i, ch := range cfg.Channels {
+		k := key{ch.Package, ch.Name}
+		if _, ok := indices[k]; !ok {
+			order = append(order, k)
+		}
+		indices[k] = append(indices[k], i)
+	}
+
+	var (
+		merged = make([]Channel, 0, len(order))
+		errs   []error
+	)
+	for _, k := range order {
+		dups := make([]Channel, 0, len(indices[k]))
+		for _, i := range indices[k] {
+			dups = append(dups, cfg.Channels[i])
+		}
+		ch, mergeErrs := mergeChannels(dups)
+		errs = append(errs, mergeErrs...)
+		merged = append(merged, ch)
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	cfg.Channels = merged
+	return nil
+}
+
+// mergeChannels merges channels that all have the same package and name.
+func mergeChannels(chs []Channel) (Channel, []error) {
+	out := chs[0]
+	if len(chs) == 1 {
+		return out, nil
+	}
+	out.Entries = nil
+	out.Properties = nil
+
+	var errs []error
+	entries := map[string]ChannelEntry{}
+	for _, ch := range chs {
+		for _, e := range ch.Entries {
+			existing, ok := entries[e.Name]
+			if !ok {
+				entries[e.Name] = e
+				out.Entries = append(out.Entries, e)
+				continue
+			}
+			if !reflect.DeepEqual(existing, e) {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q is defined differently by duplicate channels", out.Package, out.Name, e.Name))
+			}
+		}
+	}
+
+	// Properties of a type defined by more than one duplicate must have the
+	// same set of values in each of them.
+	values := map[string]sets.Set[string]{}
+	conflicts := sets.New[string]()
+	for _, ch := range chs {
+		chValues := map[string]sets.Set[string]{}
+		for _, p := range ch.Properties {
+			if _, ok := chValues[p.Type]; !ok {
+				chValues[p.Type] = sets.New[string]()
+			}
+			chValues[p.Type].Insert(string(p.Value))
+		}
+		for typ, vals := range chValues {
+			existing, ok := values[typ]
+			if !ok {
+				values[typ] = vals
+				continue
+			}
+			if !existing.Equal(vals) {
+				conflicts.Insert(typ)
+			}
+		}
+		out.Properties = append(out.Properties, ch.Properties...)
+	}
+	for _, typ := range sets.List(conflicts) {
+		errs = append(errs, fmt.Errorf("package %q, channel %q: duplicate channels define conflicting %q properties", out.Package, out.Name, typ))
+	}
+	out.Properties = property.Deduplicate(out.Properties)
+	return out, errs
+}
+
+// MergeChannels combines channels a and b of the same package into a single
+// channel, for example to fold a redundant channel into another. The
+// merged channel has a's name and properties and an entry for every bundle
+// in either channel, ordered by version.
+//
+// The entries form a single replaces chain in version order: each entry
+// replaces the next lower version, and the lowest entry keeps the replaces
+// of its original entry. No upgrade edge is lost: skips are unioned, and an
+// original replaces target that differs from the new one is added to
+// skips, and an entry never skips the entry it replaces. Versions come from
+// the olm.package properties of bundles.
+//
+// An error is returned if the channels are in different packages, a
+// bundle's version is missing or shared by two entries, the entries for a
+// bundle have different skipRanges, or the merged channel would contain a
+// cycle or not have exactly one head.
+func MergeChannels(a, b Channel, bundles []Bundle) (Channel, error) {
+	if a.Package != b.Package {
+		return Channel{}, fmt.Errorf("cannot merge channel %q of package %q with channel %q of package %q", a.Name, a.Package, b.Name, b.Package)
+	}
+	errPrefix := fmt.Sprintf("package %q, merge channel %q into %q", a.Package, b.Name, a.Name)
+
+	versions := map[string]semver.Version{}
+	for i := range bundles {
+		if bundles[i].Package != a.Package {
+			continue
+		}
+		if v, err := parseVersionProperty(&bundles[i]); err == nil {
+			versions[bundles[i].Name] = *v
+		}
+	}
+
+	type merged struct {
+		entry    ChannelEntry
+		replaces []string
+	}
+	entries := map[string]*merged{}
+	var names []string
+	for _, ch := range []Channel{a, b} {
+		for _, e := range ch.Entries {
+			m, ok := entries[e.Name]
+			if !ok {
+				m = &merged{entry: ChannelEntry{Name: e.Name, SkipRange: e.SkipRange}}
+				entries[e.Name] = m
+				names = append(names, e.Name)
+			} else if m.entry.SkipRange != e.SkipRange {
+				return Channel{}, fmt.Errorf("%s: entry %q has conflicting skipRanges %q and %q", errPrefix, e.Name, m.entry.SkipRange, e.SkipRange)
+			}
+			m.entry.Skips = append(m.entry.Skips, e.Skips...)
+			if e.Replaces != "" {
+				m.replaces = append(m.replaces, e.Replaces)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := versions[name]; !ok {
+			return Channel{}, fmt.Errorf("%s: bundle %q not found or has no valid version", errPrefix, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return versions[names[i]].LT(versions[names[j]])
+	})
+	for i := 1; i < len(names); i++ {
+		if versions[names[i]].EQ(versions[names[i-1]]) {
+			return Channel{}, fmt.Errorf("%s: bundles %q and %q have the same version %q", errPrefix, names[i-1], names[i], versions[names[i]])
+		}
+	}
+
+	out := Channel{
+		Schema:     a.Schema,
+		Name:       a.Name,
+		Package:    a.Package,
+		Properties: a.Properties,
+		Entries:    make([]ChannelEntry, 0, len(names)),
+	}
+	for i, name := range names {
+		m := entries[name]
+		e := m.entry
+		switch {
+		case i > 0:
+			e.Replaces = names[i-1]
+		case len(m.replaces) > 0:
+			e.Replaces = m.replaces[0]
+		}
+		skips := sets.New[string](e.Skips...)
+		for _, r := range m.replaces {
+			if r != e.Replaces {
+				skips.Insert(r)
+			}
+		}
+		skips.Delete(e.Replaces)
+		e.Skips = nil
+		if skips.Len() > 0 {
+			e.Skips = sets.List(skips)
+		}
+		out.Entries = append(out.Entries, e)
+	}
+
+	edges := map[string][]string{}
+	for _, e := range out.Entries {
+		if e.Replaces != "" {
+			edges[e.Name] = append(edges[e.Name], e.Replaces)
+		}
+		edges[e.Name] = append(edges[e.Name], e.Skips...)
+	}
+	if cs := cycles(names, edges); len(cs) > 0 {
+		return Channel{}, fmt.Errorf("%s: merged channel would contain a cycle between %s", errPrefix, quotedList(cs[0]))
+	}
+	if heads := channelHeads(out); len(heads) != 1 {
+		sort.Strings(heads)
+		return Channel{}, fmt.Errorf("%s: merged channel would have %d heads [%s]", errPrefix, len(heads), strings.Join(heads, ", "))
+	}
+	return out, nil
+}
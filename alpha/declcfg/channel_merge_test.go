@@ -0,0 +1,165 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestMergeDuplicateChannels(t *testing.T) {
+	type spec struct {
+		name      string
+		channels  []Channel
+		expected  []Channel
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/NoDuplicates",
+			channels: []Channel{
+				newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}),
+				newTestChannel("foo", "fast", ChannelEntry{Name: "foo.v0.1.0"}),
+			},
+			expected: []Channel{
+				newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}),
+				newTestChannel("foo", "fast", ChannelEntry{Name: "foo.v0.1.0"}),
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/UnionEntriesAndProperties",
+			channels: []Channel{
+				addChannelProperties(newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}),
+					[]property.Property{property.MustBuildChannelPriority("stable", 1)}),
+				newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.1.0"}),
+				addChannelProperties(newTestChannel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				), []property.Property{property.MustBuildChannelPriority("stable", 1)}),
+			},
+			expected: []Channel{
+				addChannelProperties(newTestChannel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				), []property.Property{property.MustBuildChannelPriority("stable", 1)}),
+				newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.1.0"}),
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/Conflicts",
+			channels: []Channel{
+				addChannelProperties(newTestChannel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				), []property.Property{property.MustBuildChannelPriority("stable", 1)}),
+				addChannelProperties(newTestChannel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.2.0", Skips: []string{"foo.v0.1.0"}},
+				), []property.Property{property.MustBuildChannelPriority("stable", 2)}),
+			},
+			assertion: hasError(`[package "foo", channel "stable": entry "foo.v0.2.0" is defined differently by duplicate channels, package "foo", channel "stable": duplicate channels define conflicting "olm.channel" properties]`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := &DeclarativeConfig{Channels: append([]Channel{}, s.channels...)}
+			err := MergeDuplicateChannels(cfg)
+			s.assertion(t, err)
+			if err != nil {
+				require.Equal(t, s.channels, cfg.Channels)
+				return
+			}
+			require.Equal(t, s.expected, cfg.Channels)
+			require.NoError(t, Validate(*cfg, AllowEmptyChannels()))
+		})
+	}
+}
+
+func TestMergeChannels(t *testing.T) {
+	bundles := []Bundle{
+		newTestBundle("foo", "0.1.0"),
+		newTestBundle("foo", "0.2.0"),
+		newTestBundle("foo", "0.3.0"),
+		newTestBundle("foo", "0.4.0"),
+		newTestBundle("bar", "0.1.0"),
+	}
+	type spec struct {
+		name      string
+		a, b      Channel
+		expected  Channel
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/InterleavedChains",
+			a: addChannelProperties(newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.0.1"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0"},
+			), []property.Property{property.MustBuildChannelPriority("candidate", 1)}),
+			b: newTestChannel("foo", "alpha",
+				ChannelEntry{Name: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.3.0"}, SkipRange: "<0.4.0"},
+			),
+			expected: addChannelProperties(newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.0.1"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.0"}},
+				ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.3.0", Skips: []string{"foo.v0.2.0"}, SkipRange: "<0.4.0"},
+			), []property.Property{property.MustBuildChannelPriority("candidate", 1)}),
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/SharedEntriesUnionSkips",
+			a: newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1"}},
+			),
+			b: newTestChannel("foo", "alpha",
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.2", "foo.v0.1.1"}},
+			),
+			expected: newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1", "foo.v0.1.2"}},
+			),
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/DifferentPackages",
+			a:         newTestChannel("foo", "candidate", ChannelEntry{Name: "foo.v0.1.0"}),
+			b:         newTestChannel("bar", "alpha", ChannelEntry{Name: "bar.v0.1.0"}),
+			assertion: hasError(`cannot merge channel "candidate" of package "foo" with channel "alpha" of package "bar"`),
+		},
+		{
+			name:      "Error/MissingVersion",
+			a:         newTestChannel("foo", "candidate", ChannelEntry{Name: "foo.v0.1.0"}),
+			b:         newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.9.0"}),
+			assertion: hasError(`package "foo", merge channel "alpha" into "candidate": bundle "foo.v0.9.0" not found or has no valid version`),
+		},
+		{
+			name:      "Error/ConflictingSkipRange",
+			a:         newTestChannel("foo", "candidate", ChannelEntry{Name: "foo.v0.2.0", SkipRange: "<0.2.0"}),
+			b:         newTestChannel("foo", "alpha", ChannelEntry{Name: "foo.v0.2.0", SkipRange: "<0.1.0"}),
+			assertion: hasError(`package "foo", merge channel "alpha" into "candidate": entry "foo.v0.2.0" has conflicting skipRanges "<0.2.0" and "<0.1.0"`),
+		},
+		{
+			name: "Error/Cycle",
+			a: newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0", Skips: []string{"foo.v0.2.0"}},
+			),
+			b: newTestChannel("foo", "alpha",
+				ChannelEntry{Name: "foo.v0.2.0"},
+			),
+			assertion: hasError(`package "foo", merge channel "alpha" into "candidate": merged channel would contain a cycle between "foo.v0.1.0", "foo.v0.2.0"`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := MergeChannels(s.a, s.b, bundles)
+			s.assertion(t, err)
+			if err == nil {
+				require.Equal(t, s.expected, actual)
+			}
+		})
+	}
+}
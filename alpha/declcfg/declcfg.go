@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/text/cases"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -49,6 +50,34 @@ type Channel struct {
 	Properties []property.Property `json:"properties,omitempty" hash:"set"`
 }
 
+// Recommended returns the name of the entry recommended by the channel's
+// olm.channel.recommended property. It returns false if the channel has no
+// such property or its properties cannot be parsed.
+func (c Channel) Recommended() (string, bool) {
+	props, err := property.Parse(c.Properties)
+	if err != nil || len(props.Recommended) == 0 {
+		return "", false
+	}
+	return props.Recommended[0].Name, true
+}
+
+// Priority returns the priority of the channel from its olm.channel property.
+// Properties with a channelName other than the channel's own name, including
+// an empty one, are ignored. It returns false if the channel has no such
+// property or its properties cannot be parsed.
+func (c Channel) Priority() (int, bool) {
+	props, err := property.Parse(c.Properties)
+	if err != nil {
+		return 0, false
+	}
+	for _, p := range props.Channels {
+		if p.ChannelName == c.Name {
+			return p.Priority, true
+		}
+	}
+	return 0, false
+}
+
 type ChannelEntry struct {
 	Name      string   `json:"name"`
 	Replaces  string   `json:"replaces,omitempty"`
@@ -82,6 +111,11 @@ type Bundle struct {
 	// first class fields.
 	CsvJSON string   `json:"-"`
 	Objects []string `json:"-"`
+
+	// pendingObjects is set when the bundle was loaded with
+	// WithLazyRuntimeFields and CsvJSON and Objects have not been
+	// populated yet. See PopulateRuntimeFields.
+	pendingObjects *pendingBundleObjects
 }
 
 type RelatedImage struct {
@@ -177,21 +211,56 @@ func extractUniqueMetaKeys(blobMap map[string]any, m *Meta) error {
 	return nil
 }
 
-func resolveUnmarshalErr(data []byte, err error) string {
+// UnmarshalErrorOption configures how FormatUnmarshalError presents the
+// document that failed to decode.
+type UnmarshalErrorOption func(*unmarshalErrorOptions)
+
+type unmarshalErrorOptions struct {
+	contextWindow int
+}
+
+// WithContextWindow limits the document shown in an unmarshal error to n bytes
+// on either side of the error offset, which keeps errors for large blobs
+// readable. The window is widened as needed so that multi-byte characters are
+// never split. A value of zero or less shows the entire document.
+func WithContextWindow(n int) UnmarshalErrorOption {
+	return func(opts *unmarshalErrorOptions) {
+		opts.contextWindow = n
+	}
+}
+
+// FormatUnmarshalError returns a human-readable description of err, which
+// resulted from decoding data as JSON. For syntax and type errors, the
+// document is included with the error location indicated by a <== marker.
+func FormatUnmarshalError(data []byte, err error, opts ...UnmarshalErrorOption) string {
 	var te *json.UnmarshalTypeError
 	if errors.As(err, &te) {
-		return formatUnmarshallErrorString(data, te.Error(), te.Offset)
+		return formatUnmarshallErrorString(data, te.Error(), te.Offset, opts...)
 	}
 	var se *json.SyntaxError
 	if errors.As(err, &se) {
-		return formatUnmarshallErrorString(data, se.Error(), se.Offset)
+		return formatUnmarshallErrorString(data, se.Error(), se.Offset, opts...)
 	}
 	return err.Error()
 }
 
-func formatUnmarshallErrorString(data []byte, errmsg string, offset int64) string {
+func formatUnmarshallErrorString(data []byte, errmsg string, offset int64, opts ...UnmarshalErrorOption) string {
+	options := unmarshalErrorOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
 	sb := new(strings.Builder)
-	_, _ = sb.WriteString(fmt.Sprintf("%s at offset %d (indicated by <==)\n ", errmsg, offset))
+	line, col := lineAndColumn(data, offset)
+	_, _ = sb.WriteString(fmt.Sprintf("%s at offset %d, line %d, column %d (indicated by <==)\n ", errmsg, offset, line, col))
+	if options.contextWindow > 0 {
+		writeContextWindow(sb, data, int(offset), options.contextWindow)
+		return sb.String()
+	}
+
 	// attempt to present the erroneous JSON in indented, human-readable format
 	// errors result in presenting the original, unformatted output
 	var pretty bytes.Buffer
@@ -220,3 +289,59 @@ func formatUnmarshallErrorString(data []byte, errmsg string, offset int64) strin
 
 	return sb.String()
 }
+
+// writeContextWindow writes the n bytes of data on either side of offset,
+// with the <== marker at offset. Truncated ends are marked with "...".
+func writeContextWindow(sb *strings.Builder, data []byte, offset, n int) {
+	start := offset - n
+	if start <= 0 {
+		start = 0
+	} else {
+		for start > 0 && !utf8.RuneStart(data[start]) {
+			start--
+		}
+	}
+	end := offset + n
+	if end >= len(data) {
+		end = len(data)
+	} else {
+		for end < len(data) && !utf8.RuneStart(data[end]) {
+			end++
+		}
+	}
+
+	if start > 0 {
+		_, _ = sb.WriteString("...")
+	}
+	_, _ = sb.Write(data[start:offset])
+	_, _ = sb.WriteString(" <== ")
+	_, _ = sb.Write(data[offset:end])
+	if end < len(data) {
+		_, _ = sb.WriteString("...")
+	}
+}
+
+// lineAndColumn returns the 1-based line and column of the byte at offset.
+// Columns count characters rather than bytes, and "\r\n" and a lone "\r"
+// are each treated as a single line break.
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	line, col := 1, 1
+	prefix := data[:offset]
+	for len(prefix) > 0 {
+		r, size := utf8.DecodeRune(prefix)
+		prefix = prefix[size:]
+		switch r {
+		case '\r':
+			if len(prefix) > 0 && prefix[0] == '\n' {
+				prefix = prefix[1:]
+			}
+			fallthrough
+		case '\n':
+			line++
+			col = 1
+		default:
+			col++
+		}
+	}
+	return line, col
+}
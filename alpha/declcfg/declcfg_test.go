@@ -0,0 +1,144 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestFormatUnmarshalError(t *testing.T) {
+	unmarshalErr := func(data string) error {
+		var v map[string]interface{}
+		return json.Unmarshal([]byte(data), &v)
+	}
+
+	type spec struct {
+		name     string
+		data     string
+		err      error
+		opts     []UnmarshalErrorOption
+		expected string
+	}
+	specs := []spec{
+		{
+			name:     "NotUnmarshalError",
+			data:     `{}`,
+			err:      errors.New("some error"),
+			expected: "some error",
+		},
+		{
+			name:     "FullDocument",
+			data:     `{"a": 1,}`,
+			expected: "invalid character '}' looking for beginning of object key string at offset 9, line 1, column 10 (indicated by <==)\n {\"a\": 1,} <== ",
+		},
+		{
+			name:     "ContextWindow",
+			data:     `{"aaaaaaaaaa": 1, "b": 2 "c": 3, "dddddddddd": 4}`,
+			opts:     []UnmarshalErrorOption{WithContextWindow(5)},
+			expected: "invalid character '\"' after object key:value pair at offset 26, line 1, column 27 (indicated by <==)\n ...: 2 \" <== c\": 3...",
+		},
+		{
+			name:     "ContextWindow/Multiline",
+			data:     "{\n  \"a\": 1,\n  \"b\": }",
+			opts:     []UnmarshalErrorOption{WithContextWindow(4)},
+			expected: "invalid character '}' looking for beginning of value at offset 20, line 3, column 9 (indicated by <==)\n ...\": } <== ",
+		},
+		{
+			name:     "ContextWindow/RuneBoundaries",
+			data:     `{"k": "ééé", 1}`,
+			opts:     []UnmarshalErrorOption{WithContextWindow(5)},
+			expected: "invalid character '1' looking for beginning of object key string at offset 17, line 1, column 15 (indicated by <==)\n ...é\", 1 <== }",
+		},
+		{
+			name:     "ContextWindow/LargerThanDocument",
+			data:     `{"a" 1}`,
+			opts:     []UnmarshalErrorOption{WithContextWindow(100)},
+			expected: "invalid character '1' after object key at offset 6, line 1, column 7 (indicated by <==)\n {\"a\" 1 <== }",
+		},
+		{
+			name:     "LineColumn/CRLF",
+			data:     "{\r\n  \"a\": 1,\r\n  \"b\": }",
+			opts:     []UnmarshalErrorOption{WithContextWindow(3)},
+			expected: "invalid character '}' looking for beginning of value at offset 22, line 3, column 9 (indicated by <==)\n ...: } <== ",
+		},
+		{
+			name:     "LineColumn/Multibyte",
+			data:     "{\n\"ключ\": \"значение\" 1}",
+			opts:     []UnmarshalErrorOption{WithContextWindow(2)},
+			expected: "invalid character '1' after object key:value pair at offset 34, line 2, column 21 (indicated by <==)\n ... 1 <== }",
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			err := s.err
+			if err == nil {
+				err = unmarshalErr(s.data)
+			}
+			require.Equal(t, s.expected, FormatUnmarshalError([]byte(s.data), err, s.opts...))
+		})
+	}
+}
+
+func TestChannelRecommended(t *testing.T) {
+	ch := newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.1.0")})
+	_, ok := ch.Recommended()
+	require.False(t, ok)
+
+	ch = addChannelProperties(ch, []property.Property{property.MustBuildRecommended(testBundleName("foo", "0.1.0"))})
+	name, ok := ch.Recommended()
+	require.True(t, ok)
+	require.Equal(t, testBundleName("foo", "0.1.0"), name)
+
+	// The property must survive a round trip through the model.
+	cfg := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+		Channels: []Channel{ch},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+	}
+	m, err := ConvertToModel(cfg)
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+	roundTripped := ConvertFromModel(m)
+	require.Len(t, roundTripped.Channels, 1)
+	name, ok = roundTripped.Channels[0].Recommended()
+	require.True(t, ok)
+	require.Equal(t, testBundleName("foo", "0.1.0"), name)
+}
+
+func TestChannelPriority(t *testing.T) {
+	ch := newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.1.0")})
+	_, ok := ch.Priority()
+	require.False(t, ok)
+
+	other := addChannelProperties(ch, []property.Property{property.MustBuildChannelPriority("fast", 3)})
+	_, ok = other.Priority()
+	require.False(t, ok)
+
+	unnamed := addChannelProperties(ch, []property.Property{property.MustBuildChannelPriority("", 3)})
+	_, ok = unnamed.Priority()
+	require.False(t, ok)
+
+	ch = addChannelProperties(ch, []property.Property{property.MustBuildChannelPriority("stable", 2)})
+	priority, ok := ch.Priority()
+	require.True(t, ok)
+	require.Equal(t, 2, priority)
+
+	// The property must survive a round trip through the model.
+	cfg := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+		Channels: []Channel{ch},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+	}
+	m, err := ConvertToModel(cfg)
+	require.NoError(t, err)
+	require.NoError(t, m.Validate())
+	roundTripped := ConvertFromModel(m)
+	require.Len(t, roundTripped.Channels, 1)
+	priority, ok = roundTripped.Channels[0].Priority()
+	require.True(t, ok)
+	require.Equal(t, 2, priority)
+}
@@ -11,9 +11,56 @@ import (
 )
 
 func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
+	bundles := make([]modelBundleSource, 0, len(cfg.Bundles))
+	for _, b := range cfg.Bundles {
+		bundles = append(bundles, newModelBundleSource(b))
+	}
+	return convertToModel(cfg.Packages, cfg.Channels, bundles)
+}
+
+// modelBundleSource holds the fields of a model bundle that come from an
+// olm.bundle object rather than from channel entries, so that the bundle
+// can be converted as soon as it is loaded. If the conversion failed, err
+// is set instead.
+type modelBundleSource struct {
+	pkg, name     string
+	image         string
+	properties    []property.Property
+	relatedImages []model.RelatedImage
+	csvJSON       string
+	objects       []string
+	propertiesP   *property.Properties
+	version       semver.Version
+	err           error
+}
+
+func newModelBundleSource(b Bundle) modelBundleSource {
+	src := modelBundleSource{pkg: b.Package, name: b.Name}
+	props, ver, err := parseModelBundleProperties(b)
+	if err != nil {
+		src.err = err
+		return src
+	}
+	if b.pendingObjects != nil {
+		if err := b.PopulateRuntimeFields(); err != nil {
+			src.err = fmt.Errorf("read bundle objects: %v", err)
+			return src
+		}
+	}
+	src.image = b.Image
+	src.properties = b.Properties
+	src.relatedImages = relatedImagesToModelRelatedImages(b.RelatedImages)
+	src.csvJSON = b.CsvJSON
+	src.objects = b.Objects
+	src.propertiesP = props
+	src.version = ver
+	return src
+}
+
+func convertToModel(packages []Package, channels []Channel, bundles []modelBundleSource) (model.Model, error) {
 	mpkgs := model.Model{}
 	defaultChannels := map[string]string{}
-	for _, p := range cfg.Packages {
+	for _, p := range packages {
 		if p.Name == "" {
 			return nil, fmt.Errorf("config contains package with no name")
 		}
@@ -38,7 +85,7 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 	}
 
 	channelDefinedEntries := map[string]sets.String{}
-	for _, c := range cfg.Channels {
+	for _, c := range channels {
 		mpkg, ok := mpkgs[c.Package]
 		if !ok {
 			return nil, fmt.Errorf("unknown package %q for channel %q", c.Package, c.Name)
@@ -91,61 +138,45 @@ func ConvertToModel(cfg DeclarativeConfig) (model.Model, error) {
 	// and is used to detect duplicate bundles.
 	packageBundles := map[string]sets.String{}
 
-	for _, b := range cfg.Bundles {
-		if b.Package == "" {
-			return nil, fmt.Errorf("package name must be set for bundle %q", b.Name)
+	for _, b := range bundles {
+		if b.pkg == "" {
+			return nil, fmt.Errorf("package name must be set for bundle %q", b.name)
 		}
-		mpkg, ok := mpkgs[b.Package]
+		mpkg, ok := mpkgs[b.pkg]
 		if !ok {
-			return nil, fmt.Errorf("unknown package %q for bundle %q", b.Package, b.Name)
+			return nil, fmt.Errorf("unknown package %q for bundle %q", b.pkg, b.name)
 		}
 
-		bundles, ok := packageBundles[b.Package]
+		names, ok := packageBundles[b.pkg]
 		if !ok {
-			bundles = sets.NewString()
+			names = sets.NewString()
 		}
-		if bundles.Has(b.Name) {
-			return nil, fmt.Errorf("package %q has duplicate bundle %q", b.Package, b.Name)
+		if names.Has(b.name) {
+			return nil, fmt.Errorf("package %q has duplicate bundle %q", b.pkg, b.name)
 		}
-		bundles.Insert(b.Name)
-		packageBundles[b.Package] = bundles
+		names.Insert(b.name)
+		packageBundles[b.pkg] = names
 
-		props, err := property.Parse(b.Properties)
-		if err != nil {
-			return nil, fmt.Errorf("parse properties for bundle %q: %v", b.Name, err)
+		if b.err != nil {
+			return nil, b.err
 		}
 
-		if len(props.Packages) != 1 {
-			return nil, fmt.Errorf("package %q bundle %q must have exactly 1 %q property, found %d", b.Package, b.Name, property.TypePackage, len(props.Packages))
-		}
-
-		if b.Package != props.Packages[0].PackageName {
-			return nil, fmt.Errorf("package %q does not match %q property %q", b.Package, property.TypePackage, props.Packages[0].PackageName)
-		}
-
-		// Parse version from the package property.
-		rawVersion := props.Packages[0].Version
-		ver, err := semver.Parse(rawVersion)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing bundle %q version %q: %v", b.Name, rawVersion, err)
-		}
-
-		channelDefinedEntries[b.Package] = channelDefinedEntries[b.Package].Delete(b.Name)
+		channelDefinedEntries[b.pkg] = channelDefinedEntries[b.pkg].Delete(b.name)
 		found := false
 		for _, mch := range mpkg.Channels {
-			if mb, ok := mch.Bundles[b.Name]; ok {
+			if mb, ok := mch.Bundles[b.name]; ok {
 				found = true
-				mb.Image = b.Image
-				mb.Properties = b.Properties
-				mb.RelatedImages = relatedImagesToModelRelatedImages(b.RelatedImages)
-				mb.CsvJSON = b.CsvJSON
-				mb.Objects = b.Objects
-				mb.PropertiesP = props
-				mb.Version = ver
+				mb.Image = b.image
+				mb.Properties = b.properties
+				mb.RelatedImages = append([]model.RelatedImage(nil), b.relatedImages...)
+				mb.CsvJSON = b.csvJSON
+				mb.Objects = b.objects
+				mb.PropertiesP = b.propertiesP
+				mb.Version = b.version
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("package %q, bundle %q not found in any channel entries", b.Package, b.Name)
+			return nil, fmt.Errorf("package %q, bundle %q not found in any channel entries", b.pkg, b.name)
 		}
 	}
 
@@ -185,3 +216,26 @@ func relatedImagesToModelRelatedImages(in []RelatedImage) []model.RelatedImage {
 	}
 	return out
 }
+
+// parseModelBundleProperties parses the properties of b and the version of
+// its olm.package property, which must name b's package.
+func parseModelBundleProperties(b Bundle) (*property.Properties, semver.Version, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return nil, semver.Version{}, fmt.Errorf("parse properties for bundle %q: %v", b.Name, err)
+	}
+	if len(props.Packages) != 1 {
+		return nil, semver.Version{}, fmt.Errorf("package %q bundle %q must have exactly 1 %q property, found %d", b.Package, b.Name, property.TypePackage, len(props.Packages))
+	}
+	if b.Package != props.Packages[0].PackageName {
+		return nil, semver.Version{}, fmt.Errorf("package %q does not match %q property %q", b.Package, property.TypePackage, props.Packages[0].PackageName)
+	}
+
+	// Parse version from the package property.
+	rawVersion := props.Packages[0].Version
+	ver, err := semver.Parse(rawVersion)
+	if err != nil {
+		return nil, semver.Version{}, fmt.Errorf("error parsing bundle %q version %q: %v", b.Name, rawVersion, err)
+	}
+	return props, ver, nil
+}
@@ -0,0 +1,469 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DiffStats is a compact summary of the differences between two declarative
+// configs, suitable for posting as a review comment.
+type DiffStats struct {
+	BundlesAdded   int `json:"bundlesAdded"`
+	BundlesRemoved int `json:"bundlesRemoved"`
+	BundlesChanged int `json:"bundlesChanged"`
+
+	// ChannelsAffected is the number of channels that were added, removed,
+	// or changed.
+	ChannelsAffected int `json:"channelsAffected"`
+
+	// PackagesTouched lists, in sorted order, the packages that have any
+	// added, removed, or changed package, channel, or bundle.
+	PackagesTouched []string `json:"packagesTouched,omitempty"`
+
+	// NewVersions maps each package to the versions of its added bundles,
+	// in ascending order.
+	NewVersions map[string][]string `json:"newVersions,omitempty"`
+
+	// RemovedVersions maps each package to the versions of its removed
+	// bundles, in ascending order.
+	RemovedVersions map[string][]string `json:"removedVersions,omitempty"`
+
+	// BundlesRenamed is the number of bundles that were renamed without
+	// otherwise changing. Renamed bundles are only detected with the
+	// DetectRenames option, and are not counted as added or removed.
+	BundlesRenamed int `json:"bundlesRenamed,omitempty"`
+
+	// Renamed lists the renamed bundles, sorted by package and then by old
+	// name.
+	Renamed []BundleRename `json:"renamed,omitempty"`
+}
+
+// BundleRename records a bundle that has a different name in the new config
+// than in the old one, but the same content.
+type BundleRename struct {
+	Package string `json:"package"`
+	OldName string `json:"oldName"`
+	NewName string `json:"newName"`
+}
+
+type diffOptions struct {
+	detectRenames bool
+}
+
+// DiffOption configures DiffSummary.
+type DiffOption func(*diffOptions)
+
+// DetectRenames makes DiffSummary match removed bundles with added bundles
+// of the same package whose BundleIdentity, ignoring their names, is the
+// same, and report them as renamed instead of removed and added. Images are
+// not compared, as for BundleIdentity. If several removed and added
+// bundles have the same content, they are paired in order of their names.
+func DetectRenames() DiffOption {
+	return func(opts *diffOptions) {
+		opts.detectRenames = true
+	}
+}
+
+// DiffSummary compares oldCfg and newCfg and summarizes their differences.
+// Objects are matched by package and name. Bundles are considered changed
+// when their identity (see BundleIdentity) or any of their image references
+// differ. Objects in Others are not compared.
+//
+// DiffSummary works directly on the declarative configs, without converting
+// them to a model, so it does not validate either config. An error is
+// returned if an added or removed bundle has no valid version.
+func DiffSummary(oldCfg, newCfg DeclarativeConfig, opts ...DiffOption) (DiffStats, error) {
+	options := diffOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	stats := DiffStats{}
+	touched := sets.New[string]()
+
+	oldPackages := map[string]Package{}
+	for _, p := range oldCfg.Packages {
+		oldPackages[p.Name] = p
+	}
+	newPackages := map[string]Package{}
+	for _, p := range newCfg.Packages {
+		newPackages[p.Name] = p
+	}
+	for name := range unionKeys(oldPackages, newPackages) {
+		o, inOld := oldPackages[name]
+		n, inNew := newPackages[name]
+		if inOld != inNew || !jsonEqual(o, n) {
+			touched.Insert(name)
+		}
+	}
+
+	type key struct {
+		pkg  string
+		name string
+	}
+
+	oldChannels := map[key]Channel{}
+	for _, c := range oldCfg.Channels {
+		oldChannels[key{c.Package, c.Name}] = c
+	}
+	newChannels := map[key]Channel{}
+	for _, c := range newCfg.Channels {
+		newChannels[key{c.Package, c.Name}] = c
+	}
+	for k := range unionKeys(oldChannels, newChannels) {
+		o, inOld := oldChannels[k]
+		n, inNew := newChannels[k]
+		if inOld != inNew || !jsonEqual(o, n) {
+			stats.ChannelsAffected++
+			touched.Insert(k.pkg)
+		}
+	}
+
+	oldBundles := map[key]Bundle{}
+	for _, b := range oldCfg.Bundles {
+		oldBundles[key{b.Package, b.Name}] = b
+	}
+	var added []*Bundle
+	for i, n := range newCfg.Bundles {
+		k := key{n.Package, n.Name}
+		o, inOld := oldBundles[k]
+		delete(oldBundles, k)
+		switch {
+		case !inOld:
+			added = append(added, &newCfg.Bundles[i])
+		case !bundleContentEqual(o, n):
+			stats.BundlesChanged++
+			touched.Insert(n.Package)
+		}
+	}
+	if options.detectRenames {
+		var removed []Bundle
+		for _, o := range oldBundles {
+			removed = append(removed, o)
+		}
+		var renamed []BundleRename
+		renamed, added = pairRenamedBundles(removed, added)
+		for _, r := range renamed {
+			delete(oldBundles, key{r.Package, r.OldName})
+			touched.Insert(r.Package)
+		}
+		stats.BundlesRenamed = len(renamed)
+		stats.Renamed = renamed
+	}
+
+	newVersions := map[string][]semver.Version{}
+	for _, n := range added {
+		v, err := parseVersionProperty(n)
+		if err != nil {
+			return DiffStats{}, fmt.Errorf("package %q: %v", n.Package, err)
+		}
+		newVersions[n.Package] = append(newVersions[n.Package], *v)
+		stats.BundlesAdded++
+		touched.Insert(n.Package)
+	}
+	removedVersions := map[string][]semver.Version{}
+	for k, o := range oldBundles {
+		v, err := parseVersionProperty(&o)
+		if err != nil {
+			return DiffStats{}, fmt.Errorf("package %q: %v", k.pkg, err)
+		}
+		removedVersions[k.pkg] = append(removedVersions[k.pkg], *v)
+		stats.BundlesRemoved++
+		touched.Insert(k.pkg)
+	}
+
+	if touched.Len() > 0 {
+		stats.PackagesTouched = sets.List(touched)
+	}
+	stats.NewVersions = versionStringsByPackage(newVersions)
+	stats.RemovedVersions = versionStringsByPackage(removedVersions)
+	return stats, nil
+}
+
+// versionStringsByPackage sorts the versions of each package in ascending
+// order and formats them. It returns nil if versions is empty.
+func versionStringsByPackage(versions map[string][]semver.Version) map[string][]string {
+	if len(versions) == 0 {
+		return nil
+	}
+	out := map[string][]string{}
+	for pkg, vs := range versions {
+		sort.Slice(vs, func(i, j int) bool {
+			return vs[i].LT(vs[j])
+		})
+		for _, v := range vs {
+			out[pkg] = append(out[pkg], v.String())
+		}
+	}
+	return out
+}
+
+// pairRenamedBundles pairs the removed and added bundles of each package
+// that have the same identity apart from their names. It returns the pairs,
+// sorted by package and old name, and the added bundles that were not
+// paired, in their original order.
+func pairRenamedBundles(removed []Bundle, added []*Bundle) ([]BundleRename, []*Bundle) {
+	anonymousIdentity := func(b Bundle) string {
+		b.Name = ""
+		return BundleIdentity(b)
+	}
+
+	// Both the package and the name are part of the identity, so clearing
+	// the name is enough to only pair bundles of the same package.
+	removedNames := map[string][]string{}
+	for _, o := range removed {
+		id := anonymousIdentity(o)
+		removedNames[id] = append(removedNames[id], o.Name)
+	}
+	for _, names := range removedNames {
+		sort.Strings(names)
+	}
+
+	sortedAdded := append([]*Bundle{}, added...)
+	sort.SliceStable(sortedAdded, func(i, j int) bool {
+		return sortedAdded[i].Name < sortedAdded[j].Name
+	})
+	var renamed []BundleRename
+	paired := sets.New[*Bundle]()
+	for _, n := range sortedAdded {
+		id := anonymousIdentity(*n)
+		names := removedNames[id]
+		if len(names) == 0 {
+			continue
+		}
+		renamed = append(renamed, BundleRename{Package: n.Package, OldName: names[0], NewName: n.Name})
+		removedNames[id] = names[1:]
+		paired.Insert(n)
+	}
+	sort.Slice(renamed, func(i, j int) bool {
+		if renamed[i].Package != renamed[j].Package {
+			return renamed[i].Package < renamed[j].Package
+		}
+		return renamed[i].OldName < renamed[j].OldName
+	})
+
+	var unpaired []*Bundle
+	for _, n := range added {
+		if !paired.Has(n) {
+			unpaired = append(unpaired, n)
+		}
+	}
+	return renamed, unpaired
+}
+
+// bundleContentEqual reports whether a and b have the same identity and
+// reference the same images.
+func bundleContentEqual(a, b Bundle) bool {
+	if a.Image != b.Image || BundleIdentity(a) != BundleIdentity(b) {
+		return false
+	}
+	relatedImages := func(b Bundle) sets.Set[RelatedImage] {
+		return sets.New[RelatedImage](b.RelatedImages...)
+	}
+	return relatedImages(a).Equal(relatedImages(b))
+}
+
+func unionKeys[K comparable, V any](a, b map[K]V) sets.Set[K] {
+	keys := sets.KeySet(a)
+	for k := range b {
+		keys.Insert(k)
+	}
+	return keys
+}
+
+// jsonEqual reports whether a and b serialize to semantically equal JSON.
+func jsonEqual(a, b interface{}) bool {
+	aj, aErr := json.Marshal(a)
+	bj, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	ac, aErr := canonicalizeJSON(aj)
+	bc, bErr := canonicalizeJSON(bj)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(ac) == string(bc)
+}
+
+// FieldDiff describes a single difference between two bundles. For scalar
+// fields, Old and New hold the two values. For fields with set semantics,
+// each element that was removed or added is reported separately, with
+// New or Old left empty, respectively.
+type FieldDiff struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %q -> %q", d.Field, d.Old, d.New)
+}
+
+// DiffBundles returns the differences between bundles a and b. Properties,
+// related images, and objects are compared as sets, and property values are
+// compared semantically, so reordering them or reformatting their JSON does
+// not produce a difference. Removed set elements are listed before added
+// ones, each in sorted order. DiffBundles returns nil if a and b are equal.
+func DiffBundles(a, b Bundle) []FieldDiff {
+	var diffs []FieldDiff
+	scalar := func(field, oldVal, newVal string) {
+		if oldVal != newVal {
+			diffs = append(diffs, FieldDiff{Field: field, Old: oldVal, New: newVal})
+		}
+	}
+	set := func(field string, oldVals, newVals []string) {
+		oldSet, newSet := sets.New[string](oldVals...), sets.New[string](newVals...)
+		for _, v := range sets.List(oldSet.Difference(newSet)) {
+			diffs = append(diffs, FieldDiff{Field: field, Old: v})
+		}
+		for _, v := range sets.List(newSet.Difference(oldSet)) {
+			diffs = append(diffs, FieldDiff{Field: field, New: v})
+		}
+	}
+
+	scalar("schema", a.Schema, b.Schema)
+	scalar("name", a.Name, b.Name)
+	scalar("package", a.Package, b.Package)
+	scalar("image", a.Image, b.Image)
+	set("properties", propertyStrings(a), propertyStrings(b))
+	set("relatedImages", relatedImageStrings(a), relatedImageStrings(b))
+	scalar("csvJSON", a.CsvJSON, b.CsvJSON)
+	set("objects", a.Objects, b.Objects)
+	return diffs
+}
+
+func propertyStrings(b Bundle) []string {
+	out := make([]string, 0, len(b.Properties))
+	for _, p := range b.Properties {
+		v, err := canonicalizeJSON(p.Value)
+		if err != nil {
+			v = p.Value
+		}
+		out = append(out, fmt.Sprintf("%s=%s", p.Type, v))
+	}
+	return out
+}
+
+func relatedImageStrings(b Bundle) []string {
+	out := make([]string, 0, len(b.RelatedImages))
+	for _, ri := range b.RelatedImages {
+		out = append(out, fmt.Sprintf("%s=%s", ri.Name, ri.Image))
+	}
+	return out
+}
+
+// ChannelChangeSet describes how the entries of a channel changed.
+type ChannelChangeSet struct {
+	Package string `json:"package"`
+	Channel string `json:"channel"`
+
+	// OldChannel is the name of the old channel, if it differs from
+	// Channel.
+	OldChannel string `json:"oldChannel,omitempty"`
+
+	Added   []string             `json:"added,omitempty"`
+	Removed []string             `json:"removed,omitempty"`
+	Changed []ChannelEntryChange `json:"changed,omitempty"`
+
+	// Distance is the edit distance between the channels: the number of
+	// entries added or removed, plus the number of replaces, skips, and
+	// skipRange edits to the entries in both channels.
+	Distance int `json:"distance"`
+}
+
+// ChannelEntryChange describes how an entry in both channels of a
+// ChannelChangeSet changed.
+type ChannelEntryChange struct {
+	Name         string        `json:"name"`
+	Replaces     *StringChange `json:"replaces,omitempty"`
+	SkipsAdded   []string      `json:"skipsAdded,omitempty"`
+	SkipsRemoved []string      `json:"skipsRemoved,omitempty"`
+	SkipRange    *StringChange `json:"skipRange,omitempty"`
+}
+
+// StringChange is a change of a string field from Old to New.
+type StringChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// ChannelDiff compares the entries of oldCh and newCh, which may have
+// different names, for example when a channel is renamed. Entries are
+// matched by name. All lists are sorted by name, and skips are compared as
+// sets.
+//
+// An error is returned if the channels are in different packages, or if
+// either channel has more than one entry with the same name.
+func ChannelDiff(oldCh, newCh Channel) (*ChannelChangeSet, error) {
+	if oldCh.Package != newCh.Package {
+		return nil, fmt.Errorf("cannot compare channel %q of package %q with channel %q of package %q", oldCh.Name, oldCh.Package, newCh.Name, newCh.Package)
+	}
+	entriesByName := func(ch Channel) (map[string]ChannelEntry, error) {
+		out := make(map[string]ChannelEntry, len(ch.Entries))
+		for _, e := range ch.Entries {
+			if _, ok := out[e.Name]; ok {
+				return nil, fmt.Errorf("package %q, channel %q: entry %q is defined more than once", ch.Package, ch.Name, e.Name)
+			}
+			out[e.Name] = e
+		}
+		return out, nil
+	}
+	oldEntries, err := entriesByName(oldCh)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := entriesByName(newCh)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &ChannelChangeSet{Package: newCh.Package, Channel: newCh.Name}
+	if oldCh.Name != newCh.Name {
+		cs.OldChannel = oldCh.Name
+	}
+	for _, name := range sets.List(unionKeys(oldEntries, newEntries)) {
+		o, inOld := oldEntries[name]
+		n, inNew := newEntries[name]
+		switch {
+		case !inOld:
+			cs.Added = append(cs.Added, name)
+			cs.Distance++
+			continue
+		case !inNew:
+			cs.Removed = append(cs.Removed, name)
+			cs.Distance++
+			continue
+		}
+
+		change := ChannelEntryChange{Name: name}
+		edits := 0
+		if o.Replaces != n.Replaces {
+			change.Replaces = &StringChange{Old: o.Replaces, New: n.Replaces}
+			edits++
+		}
+		oldSkips, newSkips := sets.New[string](o.Skips...), sets.New[string](n.Skips...)
+		if added := newSkips.Difference(oldSkips); added.Len() > 0 {
+			change.SkipsAdded = sets.List(added)
+			edits += added.Len()
+		}
+		if removed := oldSkips.Difference(newSkips); removed.Len() > 0 {
+			change.SkipsRemoved = sets.List(removed)
+			edits += removed.Len()
+		}
+		if o.SkipRange != n.SkipRange {
+			change.SkipRange = &StringChange{Old: o.SkipRange, New: n.SkipRange}
+			edits++
+		}
+		if edits > 0 {
+			cs.Changed = append(cs.Changed, change)
+			cs.Distance += edits
+		}
+	}
+	return cs, nil
+}
@@ -0,0 +1,306 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestDiffSummary(t *testing.T) {
+	type spec struct {
+		name      string
+		mod       func(*DeclarativeConfig)
+		opts      []DiffOption
+		assertion require.ErrorAssertionFunc
+		expected  DiffStats
+	}
+	specs := []spec{
+		{
+			name:      "Success/Unchanged",
+			mod:       func(*DeclarativeConfig) {},
+			assertion: require.NoError,
+			expected:  DiffStats{},
+		},
+		{
+			name: "Success/PropertyOrderIgnored",
+			mod: func(cfg *DeclarativeConfig) {
+				props := cfg.Bundles[0].Properties
+				for i, j := 0, len(props)-1; i < j; i, j = i+1, j-1 {
+					props[i], props[j] = props[j], props[i]
+				}
+			},
+			assertion: require.NoError,
+			expected:  DiffStats{},
+		},
+		{
+			name: "Success/Changes",
+			mod: func(cfg *DeclarativeConfig) {
+				cfg.Bundles = append(cfg.Bundles,
+					newTestBundle("anakin", "0.2.0"),
+					newTestBundle("anakin", "0.1.5"),
+				)
+				for i := range cfg.Channels {
+					if cfg.Channels[i].Name == "dark" {
+						cfg.Channels[i].Entries = append(cfg.Channels[i].Entries,
+							ChannelEntry{Name: testBundleName("anakin", "0.1.5"), Replaces: testBundleName("anakin", "0.1.1")},
+							ChannelEntry{Name: testBundleName("anakin", "0.2.0"), Replaces: testBundleName("anakin", "0.1.5")},
+						)
+					}
+				}
+				var bundles []Bundle
+				for _, b := range cfg.Bundles {
+					switch b.Name {
+					case testBundleName("boba-fett", "1.0.0"):
+						continue
+					case testBundleName("anakin", "0.0.1"):
+						b.Image = "mirror.example.com/anakin-bundle:v0.0.1"
+					}
+					bundles = append(bundles, b)
+				}
+				cfg.Bundles = bundles
+			},
+			assertion: require.NoError,
+			expected: DiffStats{
+				BundlesAdded:     2,
+				BundlesRemoved:   1,
+				BundlesChanged:   1,
+				ChannelsAffected: 1,
+				PackagesTouched:  []string{"anakin", "boba-fett"},
+				NewVersions:      map[string][]string{"anakin": {"0.1.5", "0.2.0"}},
+				RemovedVersions:  map[string][]string{"boba-fett": {"1.0.0"}},
+			},
+		},
+		{
+			name: "Success/PackageAndChannelRemoved",
+			mod: func(cfg *DeclarativeConfig) {
+				cfg.Packages[0].Description = "changed"
+				cfg.Channels = cfg.Channels[1:]
+			},
+			assertion: require.NoError,
+			expected: DiffStats{
+				ChannelsAffected: 1,
+				PackagesTouched:  []string{"anakin"},
+			},
+		},
+		{
+			name: "Success/RenamedWithoutDetectRenames",
+			mod: func(cfg *DeclarativeConfig) {
+				for i := range cfg.Bundles {
+					if cfg.Bundles[i].Name == testBundleName("anakin", "0.0.1") {
+						cfg.Bundles[i].Name = "anakin-skywalker.v0.0.1"
+					}
+				}
+			},
+			assertion: require.NoError,
+			expected: DiffStats{
+				BundlesAdded:    1,
+				BundlesRemoved:  1,
+				PackagesTouched: []string{"anakin"},
+				NewVersions:     map[string][]string{"anakin": {"0.0.1"}},
+				RemovedVersions: map[string][]string{"anakin": {"0.0.1"}},
+			},
+		},
+		{
+			name: "Success/Renamed",
+			mod: func(cfg *DeclarativeConfig) {
+				for i := range cfg.Bundles {
+					if cfg.Bundles[i].Name == testBundleName("anakin", "0.0.1") {
+						cfg.Bundles[i].Name = "anakin-skywalker.v0.0.1"
+					}
+				}
+			},
+			opts:      []DiffOption{DetectRenames()},
+			assertion: require.NoError,
+			expected: DiffStats{
+				PackagesTouched: []string{"anakin"},
+				BundlesRenamed:  1,
+				Renamed:         []BundleRename{{Package: "anakin", OldName: testBundleName("anakin", "0.0.1"), NewName: "anakin-skywalker.v0.0.1"}},
+			},
+		},
+		{
+			name: "Success/RenamedAndChanged",
+			mod: func(cfg *DeclarativeConfig) {
+				for i := range cfg.Bundles {
+					if cfg.Bundles[i].Name == testBundleName("anakin", "0.0.1") {
+						cfg.Bundles[i].Name = "anakin-skywalker.v0.0.1"
+						cfg.Bundles[i].Properties = append(cfg.Bundles[i].Properties, property.MustBuildGVK("test.io", "v1", "Sith"))
+					}
+				}
+			},
+			opts:      []DiffOption{DetectRenames()},
+			assertion: require.NoError,
+			expected: DiffStats{
+				BundlesAdded:    1,
+				BundlesRemoved:  1,
+				PackagesTouched: []string{"anakin"},
+				NewVersions:     map[string][]string{"anakin": {"0.0.1"}},
+				RemovedVersions: map[string][]string{"anakin": {"0.0.1"}},
+			},
+		},
+		{
+			name: "Error/AddedBundleWithoutVersion",
+			mod: func(cfg *DeclarativeConfig) {
+				cfg.Bundles = append(cfg.Bundles, newTestBundle("anakin", "0.2.0", withNoProperties()))
+			},
+			assertion: hasError(`package "anakin": bundle "anakin.v0.2.0" has multiple "olm.package" properties, expected exactly 1`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			oldCfg := buildValidDeclarativeConfig(false)
+			newCfg := buildValidDeclarativeConfig(false)
+			s.mod(&newCfg)
+			actual, err := DiffSummary(oldCfg, newCfg, s.opts...)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
+
+func TestDiffBundles(t *testing.T) {
+	type spec struct {
+		name     string
+		mod      func(*Bundle)
+		expected []FieldDiff
+	}
+	specs := []spec{
+		{
+			name: "Equal/Unchanged",
+			mod:  func(*Bundle) {},
+		},
+		{
+			name: "Equal/Reordered",
+			mod: func(b *Bundle) {
+				b.Properties[0], b.Properties[2] = b.Properties[2], b.Properties[0]
+				b.Objects[0], b.Objects[1] = b.Objects[1], b.Objects[0]
+			},
+		},
+		{
+			name: "Equal/ReformattedPropertyValue",
+			mod: func(b *Bundle) {
+				b.Properties[2].Value = json.RawMessage(`{ "version": "0.1.0", "packageName": "foo" }`)
+			},
+		},
+		{
+			name: "Different/Image",
+			mod: func(b *Bundle) {
+				b.Image = "mirror.example.com/foo-bundle:v0.1.0"
+			},
+			expected: []FieldDiff{
+				{Field: "image", Old: testBundleImage("foo", "0.1.0"), New: "mirror.example.com/foo-bundle:v0.1.0"},
+			},
+		},
+		{
+			name: "Different/Properties",
+			mod: func(b *Bundle) {
+				b.Properties[2] = property.MustBuildPackage("foo", "0.1.1")
+				b.Properties = append(b.Properties, property.MustBuildGVK("foo.example.com", "v1", "Foo"))
+			},
+			expected: []FieldDiff{
+				{Field: "properties", Old: `olm.package={"packageName":"foo","version":"0.1.0"}`},
+				{Field: "properties", New: `olm.gvk={"group":"foo.example.com","kind":"Foo","version":"v1"}`},
+				{Field: "properties", New: `olm.package={"packageName":"foo","version":"0.1.1"}`},
+			},
+		},
+		{
+			name: "Different/RelatedImages",
+			mod: func(b *Bundle) {
+				b.RelatedImages[0].Image = "mirror.example.com/foo-bundle:v0.1.0"
+			},
+			expected: []FieldDiff{
+				{Field: "relatedImages", Old: "bundle=" + testBundleImage("foo", "0.1.0")},
+				{Field: "relatedImages", New: "bundle=mirror.example.com/foo-bundle:v0.1.0"},
+			},
+		},
+		{
+			name: "Different/Objects",
+			mod: func(b *Bundle) {
+				b.Objects = b.Objects[:1]
+			},
+			expected: []FieldDiff{
+				{Field: "objects", Old: `{"kind": "CustomResourceDefinition", "apiVersion": "apiextensions.k8s.io/v1"}`},
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			b := newTestBundle("foo", "0.1.0")
+			s.mod(&b)
+			require.Equal(t, s.expected, DiffBundles(newTestBundle("foo", "0.1.0"), b))
+		})
+	}
+}
+
+func TestChannelDiff(t *testing.T) {
+	type spec struct {
+		name      string
+		oldCh     Channel
+		newCh     Channel
+		expected  *ChannelChangeSet
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/Changes",
+			oldCh: newTestChannel("foo", "alpha",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.1", "foo.v0.1.2"}},
+				ChannelEntry{Name: "foo.v0.2.1", Replaces: "foo.v0.2.0"},
+			),
+			newCh: newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.2.0", Skips: []string{"foo.v0.1.2", "foo.v0.1.3"}, SkipRange: "<0.2.0"},
+				ChannelEntry{Name: "foo.v0.2.1", Replaces: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.1"},
+			),
+			expected: &ChannelChangeSet{
+				Package:    "foo",
+				Channel:    "candidate",
+				OldChannel: "alpha",
+				Added:      []string{"foo.v0.3.0"},
+				Removed:    []string{"foo.v0.1.0"},
+				Changed: []ChannelEntryChange{{
+					Name:         "foo.v0.2.0",
+					Replaces:     &StringChange{Old: "foo.v0.1.0", New: ""},
+					SkipsAdded:   []string{"foo.v0.1.3"},
+					SkipsRemoved: []string{"foo.v0.1.1"},
+					SkipRange:    &StringChange{Old: "", New: "<0.2.0"},
+				}},
+				Distance: 6,
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/Identical",
+			oldCh:     newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0", Skips: []string{"a", "b"}}),
+			newCh:     newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0", Skips: []string{"b", "a"}}),
+			expected:  &ChannelChangeSet{Package: "foo", Channel: "stable"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/DifferentPackages",
+			oldCh:     newTestChannel("foo", "stable"),
+			newCh:     newTestChannel("bar", "stable"),
+			assertion: hasError(`cannot compare channel "stable" of package "foo" with channel "stable" of package "bar"`),
+		},
+		{
+			name:      "Error/DuplicateEntry",
+			oldCh:     newTestChannel("foo", "stable"),
+			newCh:     newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}, ChannelEntry{Name: "foo.v0.1.0"}),
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.1.0" is defined more than once`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := ChannelDiff(s.oldCh, s.newCh)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+			if actual != nil {
+				_, err := json.Marshal(actual)
+				require.NoError(t, err)
+			}
+		})
+	}
+}
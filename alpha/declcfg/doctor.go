@@ -0,0 +1,144 @@
+package declcfg
+
+import (
+	"fmt"
+)
+
+// Severity indicates how serious a Finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Checks run by Doctor.
+const (
+	CheckDanglingReferences = "dangling-references"
+	CheckReplacesCycles     = "replaces-cycles"
+	CheckChannelHeads       = "channel-heads"
+	CheckImagePinning       = "image-pinning"
+	CheckChannelNames       = "channel-names"
+)
+
+// DefaultDoctorChecks are the checks run by Doctor, in order, when
+// DoctorOptions.Checks is empty.
+var DefaultDoctorChecks = []string{
+	CheckDanglingReferences,
+	CheckReplacesCycles,
+	CheckChannelHeads,
+	CheckImagePinning,
+	CheckChannelNames,
+}
+
+// DoctorOptions configures Doctor.
+type DoctorOptions struct {
+	// Checks selects the checks to run, in order. If empty,
+	// DefaultDoctorChecks are run.
+	Checks []string
+
+	// MaxChannelHeads is the number of heads a channel may have before
+	// CheckChannelHeads reports it. Values less than 1 are treated as 1.
+	MaxChannelHeads int
+
+	// ChannelNamePatterns are the patterns passed to LintChannelNames by
+	// CheckChannelNames. If empty, every channel name is allowed.
+	ChannelNamePatterns []string
+}
+
+// Finding is a single issue reported by a Doctor check.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	LintIssue
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Check, f.LintIssue)
+}
+
+// ReportSummary counts the findings of a Report.
+type ReportSummary struct {
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Infos    int            `json:"infos"`
+	ByCheck  map[string]int `json:"byCheck"`
+
+	// Healthy is true if the report has no error findings.
+	Healthy bool `json:"healthy"`
+}
+
+// Report is the result of running Doctor.
+type Report struct {
+	// Checks lists the checks that were run, in order.
+	Checks   []string      `json:"checks"`
+	Findings []Finding     `json:"findings"`
+	Summary  ReportSummary `json:"summary"`
+}
+
+type doctorCheck struct {
+	severity Severity
+	run      func(cfg DeclarativeConfig, opts DoctorOptions) ([]LintIssue, error)
+}
+
+var doctorChecks = map[string]doctorCheck{
+	CheckDanglingReferences: {SeverityError, func(cfg DeclarativeConfig, _ DoctorOptions) ([]LintIssue, error) {
+		return LintDanglingReferences(cfg), nil
+	}},
+	CheckReplacesCycles: {SeverityError, func(cfg DeclarativeConfig, _ DoctorOptions) ([]LintIssue, error) {
+		return LintReplacesCycles(cfg), nil
+	}},
+	CheckChannelHeads: {SeverityWarning, func(cfg DeclarativeConfig, opts DoctorOptions) ([]LintIssue, error) {
+		return LintChannelHeads(cfg, opts.MaxChannelHeads), nil
+	}},
+	CheckImagePinning: {SeverityWarning, func(cfg DeclarativeConfig, _ DoctorOptions) ([]LintIssue, error) {
+		return LintUnpinnedImages(cfg), nil
+	}},
+	CheckChannelNames: {SeverityWarning, func(cfg DeclarativeConfig, opts DoctorOptions) ([]LintIssue, error) {
+		return LintChannelNames(cfg, opts.ChannelNamePatterns...)
+	}},
+}
+
+// Doctor runs the lints selected by opts against cfg and collects their
+// issues into a single report, tagging each with the severity of the check
+// that found it. Dangling references and replaces cycles are errors, since
+// they prevent cfg from being converted to a valid model; all other checks
+// produce warnings. An error is returned if opts selects an unknown check or
+// a check cannot be configured as requested.
+func Doctor(cfg DeclarativeConfig, opts DoctorOptions) (*Report, error) {
+	checks := opts.Checks
+	if len(checks) == 0 {
+		checks = DefaultDoctorChecks
+	}
+
+	report := &Report{
+		Checks:   append([]string(nil), checks...),
+		Findings: []Finding{},
+		Summary:  ReportSummary{ByCheck: map[string]int{}},
+	}
+	for _, name := range checks {
+		check, ok := doctorChecks[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		issues, err := check.run(cfg, opts)
+		if err != nil {
+			return nil, fmt.Errorf("check %q: %v", name, err)
+		}
+		for _, issue := range issues {
+			report.Findings = append(report.Findings, Finding{Check: name, Severity: check.severity, LintIssue: issue})
+			switch check.severity {
+			case SeverityError:
+				report.Summary.Errors++
+			case SeverityWarning:
+				report.Summary.Warnings++
+			case SeverityInfo:
+				report.Summary.Infos++
+			}
+		}
+		report.Summary.ByCheck[name] = len(issues)
+	}
+	report.Summary.Healthy = report.Summary.Errors == 0
+	return report, nil
+}
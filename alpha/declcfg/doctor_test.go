@@ -0,0 +1,112 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctor(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+			),
+			newTestChannel("foo", "Fast",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0"},
+			),
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/foo-bundle:v0.1.0"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0"},
+		},
+	}
+
+	type spec struct {
+		name      string
+		opts      DoctorOptions
+		expected  *Report
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/AllChecks",
+			opts: DoctorOptions{ChannelNamePatterns: []string{"stable", "fast"}},
+			expected: &Report{
+				Checks: DefaultDoctorChecks,
+				Findings: []Finding{
+					{Check: CheckReplacesCycles, Severity: SeverityError, LintIssue: LintIssue{Package: "foo", Channel: "stable", Bundle: "foo.v0.1.0", Message: "replaces chain contains a cycle: foo.v0.1.0 -> foo.v0.2.0 -> foo.v0.1.0"}},
+					{Check: CheckChannelHeads, Severity: SeverityWarning, LintIssue: LintIssue{Package: "foo", Channel: "Fast", Message: `channel has 2 heads: "foo.v0.1.0", "foo.v0.2.0"`}},
+					{Check: CheckImagePinning, Severity: SeverityWarning, LintIssue: LintIssue{Package: "foo", Bundle: "foo.v0.1.0", Message: `images not pinned by digest: "quay.io/example/foo-bundle:v0.1.0"`}},
+					{Check: CheckChannelNames, Severity: SeverityWarning, LintIssue: LintIssue{Package: "foo", Channel: "Fast", Message: "channel name does not match any allowed pattern"}},
+				},
+				Summary: ReportSummary{
+					Errors:   1,
+					Warnings: 3,
+					ByCheck: map[string]int{
+						CheckDanglingReferences: 0,
+						CheckReplacesCycles:     1,
+						CheckChannelHeads:       1,
+						CheckImagePinning:       1,
+						CheckChannelNames:       1,
+					},
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/SelectedChecks",
+			opts: DoctorOptions{Checks: []string{CheckChannelHeads}, MaxChannelHeads: 2},
+			expected: &Report{
+				Checks:   []string{CheckChannelHeads},
+				Findings: []Finding{},
+				Summary: ReportSummary{
+					ByCheck: map[string]int{CheckChannelHeads: 0},
+					Healthy: true,
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/UnknownCheck",
+			opts:      DoctorOptions{Checks: []string{"bogus"}},
+			assertion: hasError(`unknown check "bogus"`),
+		},
+		{
+			name:      "Error/InvalidChannelNamePattern",
+			opts:      DoctorOptions{Checks: []string{CheckChannelNames}, ChannelNamePatterns: []string{"("}},
+			assertion: hasError("check \"channel-names\": invalid channel name pattern \"(\": error parsing regexp: missing closing ): `(`"),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			report, err := Doctor(cfg, s.opts)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, report)
+		})
+	}
+}
+
+func TestDoctorReportJSON(t *testing.T) {
+	report, err := Doctor(DeclarativeConfig{
+		Bundles: []Bundle{{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/foo-bundle:v0.1.0"}},
+	}, DoctorOptions{Checks: []string{CheckImagePinning}})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"checks": ["image-pinning"],
+		"findings": [{
+			"check": "image-pinning",
+			"severity": "warning",
+			"package": "foo",
+			"bundle": "foo.v0.1.0",
+			"message": "images not pinned by digest: \"quay.io/example/foo-bundle:v0.1.0\""
+		}],
+		"summary": {"errors": 0, "warnings": 1, "infos": 0, "byCheck": {"image-pinning": 1}, "healthy": true}
+	}`, string(data))
+}
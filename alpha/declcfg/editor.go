@@ -0,0 +1,162 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// EditorDocument bundles a catalog with JSON Schemas that describe its
+// objects, so that editors can provide autocompletion and validation
+// without any other knowledge of the declarative config format.
+type EditorDocument struct {
+	// Schemas maps each of the olm.package, olm.channel, and olm.bundle
+	// schemas to a JSON Schema for objects of that schema.
+	Schemas map[string]interface{} `json:"schemas"`
+
+	// PropertySchemas maps each property type registered with the property
+	// package, including extensions added with property.AddToScheme, to a
+	// JSON Schema for its values.
+	PropertySchemas map[string]interface{} `json:"propertySchemas"`
+
+	// Catalog holds the objects of the catalog, in the same order as
+	// WriteJSON writes them.
+	Catalog []json.RawMessage `json:"catalog"`
+}
+
+// NewEditorDocument returns an EditorDocument for cfg. The schemas are
+// generated from the Go types used to decode each object and property, and
+// use the type, properties, items, additionalProperties, and format
+// keywords of JSON Schema. Values whose types define their own JSON
+// encoding, other than bundle object files, are left unconstrained.
+func NewEditorDocument(cfg DeclarativeConfig) (*EditorDocument, error) {
+	doc := &EditorDocument{
+		Schemas: map[string]interface{}{
+			SchemaPackage: jsonSchemaFor(reflect.TypeOf(Package{})),
+			SchemaChannel: jsonSchemaFor(reflect.TypeOf(Channel{})),
+			SchemaBundle:  jsonSchemaFor(reflect.TypeOf(Bundle{})),
+		},
+		PropertySchemas: map[string]interface{}{},
+		Catalog:         []json.RawMessage{},
+	}
+	for typ, t := range property.RegisteredTypes() {
+		doc.PropertySchemas[typ] = jsonSchemaFor(t)
+	}
+	if err := writeToEncoder(cfg, (*rawMessageCollector)(&doc.Catalog)); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// WriteEditorDocument writes the EditorDocument for cfg to w as a single
+// JSON document.
+func WriteEditorDocument(cfg DeclarativeConfig, w io.Writer) error {
+	doc, err := NewEditorDocument(cfg)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// rawMessageCollector is an encoder that appends each encoded value to a
+// slice.
+type rawMessageCollector []json.RawMessage
+
+func (c *rawMessageCollector) Encode(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	*c = append(*c, data)
+	return nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	fileTypes         = map[reflect.Type]bool{
+		reflect.TypeOf(property.File{}):         true,
+		reflect.TypeOf(property.BundleObject{}): true,
+	}
+)
+
+// jsonSchemaFor returns a JSON Schema describing how values of type t are
+// encoded by encoding/json.
+func jsonSchemaFor(t reflect.Type) map[string]interface{} {
+	return jsonSchemaForType(t, map[reflect.Type]bool{})
+}
+
+func jsonSchemaForType(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if fileTypes[t] {
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"ref":  map[string]interface{}{"type": "string"},
+				"data": map[string]interface{}{"type": "string", "format": "byte"},
+			},
+		}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) || visiting[t] {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		visiting[t] = true
+		defer delete(visiting, t)
+		props := map[string]interface{}{}
+		addStructFields(t, props, visiting)
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return map[string]interface{}{}
+}
+
+func addStructFields(t reflect.Type, props map[string]interface{}, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && !fileTypes[ft] {
+			addStructFields(ft, props, visiting)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = jsonSchemaForType(f.Type, visiting)
+	}
+}
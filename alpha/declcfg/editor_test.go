@@ -0,0 +1,61 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestWriteEditorDocument(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+		Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteEditorDocument(cfg, buf))
+
+	var doc struct {
+		Schemas         map[string]map[string]interface{} `json:"schemas"`
+		PropertySchemas map[string]map[string]interface{} `json:"propertySchemas"`
+		Catalog         []Meta                            `json:"catalog"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	require.Len(t, doc.Catalog, 3)
+	require.Equal(t, []string{SchemaPackage, SchemaChannel, SchemaBundle}, []string{doc.Catalog[0].Schema, doc.Catalog[1].Schema, doc.Catalog[2].Schema})
+
+	bundleProps := doc.Schemas[SchemaBundle]["properties"].(map[string]interface{})
+	require.Contains(t, bundleProps, "image")
+	require.Contains(t, bundleProps, "relatedImages")
+	require.NotContains(t, bundleProps, "CsvJSON")
+
+	pkgProps := doc.Schemas[SchemaPackage]["properties"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"type": "string"}, pkgProps["defaultChannel"])
+
+	for _, typ := range []string{property.TypePackage, property.TypeGVK, property.TypeBundleObject} {
+		require.Contains(t, doc.PropertySchemas, typ)
+	}
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "byte"},
+		doc.PropertySchemas[property.TypeBundleObject]["properties"].(map[string]interface{})["data"])
+}
+
+func TestJSONSchemaForRecursiveType(t *testing.T) {
+	type node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children,omitempty"`
+	}
+	require.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":     map[string]interface{}{"type": "string"},
+			"children": map[string]interface{}{"type": "array", "items": map[string]interface{}{}},
+		},
+	}, jsonSchemaFor(reflect.TypeOf(node{})))
+}
@@ -0,0 +1,54 @@
+package declcfg
+
+import (
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// EffectiveProperties returns the properties that apply to bundle bundleName
+// of package pkg, taking properties defined on the package into account.
+//
+// Bundles inherit their package's properties, with bundle properties taking
+// precedence by type: a package property is inherited only if the bundle has
+// no property of the same type. The bundle's own properties come first, in
+// their original order, followed by the inherited ones. Exact duplicates are
+// removed.
+func EffectiveProperties(cfg DeclarativeConfig, pkg, bundleName string) ([]property.Property, error) {
+	var (
+		pkgProps []property.Property
+		pkgFound bool
+		bundle   *Bundle
+	)
+	for _, p := range cfg.Packages {
+		if p.Name == pkg {
+			pkgProps = p.Properties
+			pkgFound = true
+			break
+		}
+	}
+	if !pkgFound {
+		return nil, fmt.Errorf("package %q not found", pkg)
+	}
+	for i, b := range cfg.Bundles {
+		if b.Package == pkg && b.Name == bundleName {
+			bundle = &cfg.Bundles[i]
+			break
+		}
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("package %q: bundle %q not found", pkg, bundleName)
+	}
+
+	bundleTypes := map[string]struct{}{}
+	for _, p := range bundle.Properties {
+		bundleTypes[p.Type] = struct{}{}
+	}
+	out := append([]property.Property(nil), bundle.Properties...)
+	for _, p := range pkgProps {
+		if _, ok := bundleTypes[p.Type]; !ok {
+			out = append(out, p)
+		}
+	}
+	return property.Deduplicate(out), nil
+}
@@ -0,0 +1,70 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestEffectiveProperties(t *testing.T) {
+	support := func(v string) property.Property {
+		return property.Property{Type: "example.com/support", Value: json.RawMessage(v)}
+	}
+	owner := property.Property{Type: "example.com/owner", Value: json.RawMessage(`"team-a"`)}
+
+	cfg := DeclarativeConfig{
+		Packages: []Package{
+			addPackageProperties(newTestPackage("foo", "stable", svgSmallCircle), []property.Property{owner, support(`"community"`)}),
+		},
+		Bundles: []Bundle{
+			{Package: "foo", Name: "foo.v0.1.0", Properties: []property.Property{property.MustBuildPackage("foo", "0.1.0")}},
+			{Package: "foo", Name: "foo.v0.2.0", Properties: []property.Property{property.MustBuildPackage("foo", "0.2.0"), support(`"certified"`), owner}},
+		},
+	}
+
+	type spec struct {
+		name      string
+		pkg       string
+		bundle    string
+		expected  []property.Property
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Inherited",
+			pkg:       "foo",
+			bundle:    "foo.v0.1.0",
+			expected:  []property.Property{property.MustBuildPackage("foo", "0.1.0"), owner, support(`"community"`)},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/OverriddenAndDeduplicated",
+			pkg:       "foo",
+			bundle:    "foo.v0.2.0",
+			expected:  []property.Property{property.MustBuildPackage("foo", "0.2.0"), support(`"certified"`), owner},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/UnknownPackage",
+			pkg:       "bar",
+			bundle:    "foo.v0.1.0",
+			assertion: hasError(`package "bar" not found`),
+		},
+		{
+			name:      "Error/UnknownBundle",
+			pkg:       "foo",
+			bundle:    "foo.v0.3.0",
+			assertion: hasError(`package "foo": bundle "foo.v0.3.0" not found`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := EffectiveProperties(cfg, s.pkg, s.bundle)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package declcfg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+var (
+	// ErrPackageNotFound is returned by DefaultChannelHead when the
+	// requested package does not exist.
+	ErrPackageNotFound = errors.New("package not found")
+
+	// ErrDefaultChannelNotFound is returned by DefaultChannelHead when the
+	// package's default channel is unset or does not exist.
+	ErrDefaultChannelNotFound = errors.New("default channel not found")
+
+	// ErrMultipleChannelHeads is returned by DefaultChannelHead when the
+	// default channel does not have exactly one head.
+	ErrMultipleChannelHeads = errors.New("channel does not have exactly one head")
+)
+
+// DefaultChannelHead returns the bundle at the head of the default channel
+// of package pkg, which is the version of the package that is installed
+// when no channel is requested. The returned error wraps
+// ErrPackageNotFound, ErrDefaultChannelNotFound, or ErrMultipleChannelHeads
+// for those cases, so callers can tell them apart with errors.Is.
+func DefaultChannelHead(cfg DeclarativeConfig, pkg string) (*Bundle, error) {
+	var p *Package
+	for i := range cfg.Packages {
+		if cfg.Packages[i].Name == pkg {
+			p = &cfg.Packages[i]
+			break
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("package %q: %w", pkg, ErrPackageNotFound)
+	}
+	if p.DefaultChannel == "" {
+		return nil, fmt.Errorf("package %q: %w: package has no default channel", pkg, ErrDefaultChannelNotFound)
+	}
+
+	var ch *Channel
+	for i := range cfg.Channels {
+		if cfg.Channels[i].Package == pkg && cfg.Channels[i].Name == p.DefaultChannel {
+			ch = &cfg.Channels[i]
+			break
+		}
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("package %q: %w: channel %q does not exist", pkg, ErrDefaultChannelNotFound, p.DefaultChannel)
+	}
+
+	heads := channelHeads(*ch)
+	if len(heads) != 1 {
+		sort.Strings(heads)
+		return nil, fmt.Errorf("package %q, channel %q: %w: found %d heads [%s]", pkg, ch.Name, ErrMultipleChannelHeads, len(heads), strings.Join(heads, ", "))
+	}
+
+	for i := range cfg.Bundles {
+		if cfg.Bundles[i].Package == pkg && cfg.Bundles[i].Name == heads[0] {
+			return &cfg.Bundles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("package %q, channel %q: bundle %q not found", pkg, ch.Name, heads[0])
+}
+
+// VersionTieError is returned by LatestVersion when several bundles share
+// the highest version of a package.
+type VersionTieError struct {
+	Package string
+	Version string
+	Bundles []string
+}
+
+func (e *VersionTieError) Error() string {
+	return fmt.Sprintf("package %q: version %q is shared by bundles %s", e.Package, e.Version, quotedList(e.Bundles))
+}
+
+// LatestVersion returns the bundle with the highest version among the
+// bundles in any channel of package pkg, along with the name of a channel
+// that contains it: the package's default channel if it does, or else the
+// first such channel in lexical order. Versions are compared by semver
+// precedence, so the newest bundle may be in a channel other than the
+// default channel. Bundles whose version cannot be parsed cause an error.
+//
+// If several bundles share the highest version, the one whose name is
+// first in lexical order is returned, together with a *VersionTieError
+// naming all of them.
+func LatestVersion(cfg DeclarativeConfig, pkg string) (*Bundle, string, error) {
+	var p *Package
+	for i := range cfg.Packages {
+		if cfg.Packages[i].Name == pkg {
+			p = &cfg.Packages[i]
+			break
+		}
+	}
+	if p == nil {
+		return nil, "", fmt.Errorf("package %q: %w", pkg, ErrPackageNotFound)
+	}
+
+	channelsByEntry := map[string][]string{}
+	for _, ch := range cfg.Channels {
+		if ch.Package != pkg {
+			continue
+		}
+		for _, e := range ch.Entries {
+			channelsByEntry[e.Name] = append(channelsByEntry[e.Name], ch.Name)
+		}
+	}
+
+	var (
+		latest  []*Bundle
+		version semver.Version
+	)
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		if b.Package != pkg || len(channelsByEntry[b.Name]) == 0 {
+			continue
+		}
+		v, err := parseVersionProperty(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("package %q: %v", pkg, err)
+		}
+		switch c := v.Compare(version); {
+		case len(latest) == 0 || c > 0:
+			latest, version = []*Bundle{b}, *v
+		case c == 0:
+			latest = append(latest, b)
+		}
+	}
+	if len(latest) == 0 {
+		return nil, "", fmt.Errorf("package %q: no bundles found in any channel", pkg)
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Name < latest[j].Name })
+
+	b := latest[0]
+	channels := channelsByEntry[b.Name]
+	channel := channels[0]
+	for _, ch := range channels {
+		if ch == p.DefaultChannel {
+			channel = ch
+			break
+		}
+		if ch < channel {
+			channel = ch
+		}
+	}
+
+	if len(latest) > 1 {
+		names := make([]string, 0, len(latest))
+		for _, l := range latest {
+			names = append(names, l.Name)
+		}
+		return b, channel, &VersionTieError{Package: pkg, Version: version.String(), Bundles: names}
+	}
+	return b, channel, nil
+}
@@ -0,0 +1,128 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultChannelHead(t *testing.T) {
+	type spec struct {
+		name      string
+		cfg       DeclarativeConfig
+		pkg       string
+		expected  string
+		expectErr error
+	}
+	specs := []spec{
+		{
+			name: "Success",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+					),
+					newTestChannel("foo", "fast", ChannelEntry{Name: testBundleName("foo", "0.3.0")}),
+				},
+				Bundles: []Bundle{
+					newTestBundle("foo", "0.1.0"),
+					newTestBundle("foo", "0.2.0"),
+					newTestBundle("foo", "0.3.0"),
+				},
+			},
+			pkg:      "foo",
+			expected: testBundleName("foo", "0.2.0"),
+		},
+		{
+			name: "Error/MissingPackage",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			},
+			pkg:       "bar",
+			expectErr: ErrPackageNotFound,
+		},
+		{
+			name: "Error/MissingDefaultChannel",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "fast", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+			},
+			pkg:       "foo",
+			expectErr: ErrDefaultChannelNotFound,
+		},
+		{
+			name: "Error/MultipleHeads",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+						ChannelEntry{Name: testBundleName("foo", "0.2.0")},
+					),
+				},
+			},
+			pkg:       "foo",
+			expectErr: ErrMultipleChannelHeads,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			b, err := DefaultChannelHead(s.cfg, s.pkg)
+			if s.expectErr != nil {
+				require.ErrorIs(t, err, s.expectErr)
+				require.Nil(t, b)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, s.expected, b.Name)
+		})
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+			),
+			newTestChannel("foo", "fast", ChannelEntry{Name: testBundleName("foo", "0.3.0")}),
+			newTestChannel("foo", "candidate", ChannelEntry{Name: testBundleName("foo", "0.3.0")}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.3.0"),
+			newTestBundle("foo", "1.0.0"), // not in any channel
+		},
+	}
+
+	b, channel, err := LatestVersion(cfg, "foo")
+	require.NoError(t, err)
+	require.Equal(t, testBundleName("foo", "0.3.0"), b.Name)
+	require.Equal(t, "candidate", channel)
+
+	// The default channel is preferred when it contains the latest bundle.
+	cfg.Channels[0].Entries = append(cfg.Channels[0].Entries, ChannelEntry{Name: testBundleName("foo", "0.3.0"), Replaces: testBundleName("foo", "0.2.0")})
+	_, channel, err = LatestVersion(cfg, "foo")
+	require.NoError(t, err)
+	require.Equal(t, "stable", channel)
+
+	// Ties are broken by bundle name and reported.
+	tie := newTestBundle("foo", "0.3.0")
+	tie.Name = "foo.v0.3.0-rebuild"
+	cfg.Bundles = append(cfg.Bundles, tie)
+	cfg.Channels[1].Entries = append(cfg.Channels[1].Entries, ChannelEntry{Name: tie.Name})
+	b, channel, err = LatestVersion(cfg, "foo")
+	var tieErr *VersionTieError
+	require.ErrorAs(t, err, &tieErr)
+	require.EqualError(t, err, `package "foo": version "0.3.0" is shared by bundles "foo.v0.3.0", "foo.v0.3.0-rebuild"`)
+	require.Equal(t, testBundleName("foo", "0.3.0"), b.Name)
+	require.Equal(t, "stable", channel)
+
+	_, _, err = LatestVersion(cfg, "bar")
+	require.ErrorIs(t, err, ErrPackageNotFound)
+}
@@ -0,0 +1,127 @@
+package declcfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// BundleIdentity returns a stable, content-based identity for b that does not
+// depend on where the bundle's images are hosted. Two bundles have the same
+// identity when they have:
+//   - the same Name and Package,
+//   - the same set of properties, where each property's value is compared
+//     semantically (JSON key order and whitespace are ignored), and
+//   - the same set of related image names.
+//
+// Image and the Image field of each related image are deliberately excluded,
+// so a catalog mirrored to another registry has the same bundle identities
+// as its source. Properties that embed image references in their values
+// (e.g. olm.bundle.object) are compared as-is. Schema and the GRPC-compat
+// fields (CsvJSON and Objects) are derived data and are also excluded.
+//
+// If a property value is not valid JSON, its raw bytes are used instead.
+func BundleIdentity(b Bundle) string {
+	props := sets.New[string]()
+	for _, p := range b.Properties {
+		v, err := canonicalizeJSON(p.Value)
+		if err != nil {
+			v = p.Value
+		}
+		props.Insert(fmt.Sprintf("%s=%s", p.Type, v))
+	}
+	relatedImageNames := sets.New[string]()
+	for _, ri := range b.RelatedImages {
+		relatedImageNames.Insert(ri.Name)
+	}
+
+	h := sha256.New()
+	writeIdentityField(h, "name", b.Name)
+	writeIdentityField(h, "package", b.Package)
+	for _, p := range sets.List(props) {
+		writeIdentityField(h, "property", p)
+	}
+	for _, n := range sets.List(relatedImageNames) {
+		writeIdentityField(h, "relatedImage", n)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// writeIdentityField writes a length-prefixed field so that adjacent fields
+// cannot be confused with one another.
+func writeIdentityField(w io.Writer, key, value string) {
+	_, _ = fmt.Fprintf(w, "%s:%d:%s\n", key, len(value), value)
+}
+
+// canonicalizeJSON re-encodes in with sorted object keys and no insignificant
+// whitespace. HTML characters are not escaped.
+func canonicalizeJSON(in []byte) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// FindDuplicateBundles returns groups of bundle names within the same package
+// whose bundles are identical except for their Name, as determined by
+// BundleIdentity. This usually indicates the same content was accidentally
+// published more than once.
+//
+// Duplicates are not merged, since doing so would change the semantics of the
+// channels that reference them; the groups are meant for human review. Names
+// within a group are sorted, and groups are sorted by package and then by
+// their first name. Bundles that have no duplicates are omitted.
+func FindDuplicateBundles(cfg DeclarativeConfig) [][]string {
+	type group struct {
+		pkg   string
+		names sets.Set[string]
+	}
+	groups := map[string]*group{}
+	for _, b := range cfg.Bundles {
+		anonymous := b
+		anonymous.Name = ""
+		id := BundleIdentity(anonymous)
+		g, ok := groups[id]
+		if !ok {
+			g = &group{pkg: b.Package, names: sets.New[string]()}
+			groups[id] = g
+		}
+		g.names.Insert(b.Name)
+	}
+
+	var dups []group
+	for _, g := range groups {
+		if g.names.Len() > 1 {
+			dups = append(dups, *g)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].pkg != dups[j].pkg {
+			return dups[i].pkg < dups[j].pkg
+		}
+		return sets.List(dups[i].names)[0] < sets.List(dups[j].names)[0]
+	})
+
+	out := make([][]string, 0, len(dups))
+	for _, g := range dups {
+		out = append(out, sets.List(g.names))
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestBundleIdentity(t *testing.T) {
+	base := newTestBundle("foo", "0.1.0")
+	baseID := BundleIdentity(base)
+
+	type spec struct {
+		name  string
+		mod   func(*Bundle)
+		equal bool
+	}
+	specs := []spec{
+		{
+			name:  "Equal/Unchanged",
+			mod:   func(*Bundle) {},
+			equal: true,
+		},
+		{
+			name: "Equal/DifferentRegistry",
+			mod: func(b *Bundle) {
+				b.Image = "mirror.example.com/foo-bundle:v0.1.0"
+				for i := range b.RelatedImages {
+					b.RelatedImages[i].Image = "mirror.example.com/foo-bundle:v0.1.0"
+				}
+			},
+			equal: true,
+		},
+		{
+			name: "Equal/PropertyOrder",
+			mod: func(b *Bundle) {
+				for i, j := 0, len(b.Properties)-1; i < j; i, j = i+1, j-1 {
+					b.Properties[i], b.Properties[j] = b.Properties[j], b.Properties[i]
+				}
+			},
+			equal: true,
+		},
+		{
+			name: "Equal/PropertyValueFormatting",
+			mod: func(b *Bundle) {
+				for i, p := range b.Properties {
+					if p.Type == property.TypePackage {
+						b.Properties[i].Value = json.RawMessage(`{ "version": "0.1.0", "packageName": "foo" }`)
+					}
+				}
+			},
+			equal: true,
+		},
+		{
+			name: "Equal/GRPCCompatFields",
+			mod: func(b *Bundle) {
+				b.CsvJSON = ""
+				b.Objects = nil
+			},
+			equal: true,
+		},
+		{
+			name: "Different/Name",
+			mod: func(b *Bundle) {
+				b.Name = "foo.v0.1.0-rebuild"
+			},
+		},
+		{
+			name: "Different/Package",
+			mod: func(b *Bundle) {
+				b.Package = "bar"
+			},
+		},
+		{
+			name: "Different/PropertyValue",
+			mod: func(b *Bundle) {
+				b.Properties = append(b.Properties, property.MustBuildGVK("foo.example.com", "v1", "Foo"))
+			},
+		},
+		{
+			name: "Different/RelatedImageName",
+			mod: func(b *Bundle) {
+				b.RelatedImages = append(b.RelatedImages, RelatedImage{Name: "operator", Image: "quay.io/example/foo:v0.1.0"})
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			b := newTestBundle("foo", "0.1.0")
+			s.mod(&b)
+			if s.equal {
+				require.Equal(t, baseID, BundleIdentity(b))
+			} else {
+				require.NotEqual(t, baseID, BundleIdentity(b))
+			}
+		})
+	}
+}
+
+func TestFindDuplicateBundles(t *testing.T) {
+	renamed := func(b Bundle, name string) Bundle {
+		b.Name = name
+		return b
+	}
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.2.0"),
+			renamed(newTestBundle("foo", "0.1.0"), "foo.v0.1.0-republished"),
+			renamed(newTestBundle("foo", "0.1.0"), "foo.v0.1.0-again"),
+			newTestBundle("bar", "0.1.0"),
+			renamed(newTestBundle("bar", "0.1.0"), "bar.v0.1.0-copy"),
+			// Bundles in different packages are never duplicates.
+			func() Bundle {
+				b := renamed(newTestBundle("foo", "0.2.0"), "baz.v0.2.0")
+				b.Package = "baz"
+				return b
+			}(),
+		},
+	}
+	require.Equal(t, [][]string{
+		{testBundleName("bar", "0.1.0"), "bar.v0.1.0-copy"},
+		{testBundleName("foo", "0.1.0"), "foo.v0.1.0-again", "foo.v0.1.0-republished"},
+	}, FindDuplicateBundles(cfg))
+
+	require.Empty(t, FindDuplicateBundles(DeclarativeConfig{Bundles: []Bundle{newTestBundle("foo", "0.1.0")}}))
+}
@@ -0,0 +1,155 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/docker/distribution/reference"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// BundleImages is the set of images referenced by a single bundle.
+type BundleImages struct {
+	Package string
+	Bundle  string
+	Images  sets.Set[string]
+}
+
+// AllImages returns every bundle image and related image referenced by cfg.
+// Empty image references are omitted.
+func AllImages(cfg DeclarativeConfig) sets.Set[string] {
+	images := sets.New[string]()
+	for _, bi := range ImagesByBundle(cfg) {
+		images = images.Union(bi.Images)
+	}
+	return images
+}
+
+// ImagesByBundle returns the bundle image and related images referenced by
+// each bundle of cfg, in the order the bundles appear in cfg. Empty image
+// references are omitted.
+func ImagesByBundle(cfg DeclarativeConfig) []BundleImages {
+	out := make([]BundleImages, 0, len(cfg.Bundles))
+	for _, b := range cfg.Bundles {
+		images := sets.New[string]()
+		if b.Image != "" {
+			images.Insert(b.Image)
+		}
+		for _, ri := range b.RelatedImages {
+			if ri.Image != "" {
+				images.Insert(ri.Image)
+			}
+		}
+		out = append(out, BundleImages{Package: b.Package, Bundle: b.Name, Images: images})
+	}
+	return out
+}
+
+// ImageExtractor returns the image references embedded in a property value.
+type ImageExtractor func(value json.RawMessage) ([]string, error)
+
+// ImageFieldExtractor returns an ImageExtractor that collects the string
+// values of every object field named in fields, at any depth of the property
+// value.
+func ImageFieldExtractor(fields ...string) ImageExtractor {
+	names := sets.New[string](fields...)
+	return func(value json.RawMessage) ([]string, error) {
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return nil, err
+		}
+		var images []string
+		var walk func(v interface{})
+		walk = func(v interface{}) {
+			switch t := v.(type) {
+			case map[string]interface{}:
+				for k, fv := range t {
+					if s, ok := fv.(string); ok && names.Has(k) {
+						images = append(images, s)
+						continue
+					}
+					walk(fv)
+				}
+			case []interface{}:
+				for _, e := range t {
+					walk(e)
+				}
+			}
+		}
+		walk(v)
+		return images, nil
+	}
+}
+
+// LintPropertyImages reports images referenced by bundle properties that are
+// not listed in the bundle's related images (or as the bundle image), and
+// would therefore be missed by tools that mirror a catalog's images.
+// extractors maps each property type to scan to the extractor used to find
+// the images in its values; properties of other types are ignored. An error
+// is returned if an extractor fails.
+func LintPropertyImages(cfg DeclarativeConfig, extractors map[string]ImageExtractor) ([]LintIssue, error) {
+	var issues []LintIssue
+	for _, b := range cfg.Bundles {
+		known := sets.New[string](b.Image)
+		for _, ri := range b.RelatedImages {
+			known.Insert(ri.Image)
+		}
+		reported := sets.New[string]()
+		for _, p := range b.Properties {
+			extract, ok := extractors[p.Type]
+			if !ok {
+				continue
+			}
+			images, err := extract(p.Value)
+			if err != nil {
+				return nil, fmt.Errorf("package %q, bundle %q: extract images from %q property: %v", b.Package, b.Name, p.Type, err)
+			}
+			sort.Strings(images)
+			for _, img := range images {
+				if img == "" || known.Has(img) || reported.Has(img) {
+					continue
+				}
+				reported.Insert(img)
+				issues = append(issues, LintIssue{
+					Package: b.Package,
+					Bundle:  b.Name,
+					Message: fmt.Sprintf("%q property references image %q, which is not a related image", p.Type, img),
+				})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// ValidateImageRefs reports bundle images and related images whose
+// references are not syntactically valid, such as those with illegal
+// characters or malformed tags or digests. Short names like "busybox" are
+// accepted and normalized as Docker does. Empty references are reported
+// unless AllowEmptyImages is provided; all other options are ignored.
+func ValidateImageRefs(cfg DeclarativeConfig, opts ...ValidateOption) []LintIssue {
+	options := ValidateOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var issues []LintIssue
+	check := func(b Bundle, what, image string) {
+		if image == "" {
+			if !options.allowEmptyImages {
+				issues = append(issues, LintIssue{Package: b.Package, Bundle: b.Name, Message: fmt.Sprintf("%s is empty", what)})
+			}
+			return
+		}
+		if _, err := reference.ParseNormalizedNamed(image); err != nil {
+			issues = append(issues, LintIssue{Package: b.Package, Bundle: b.Name, Message: fmt.Sprintf("%s %q is invalid: %v", what, image, err)})
+		}
+	}
+	for _, b := range cfg.Bundles {
+		check(b, "bundle image", b.Image)
+		for _, ri := range b.RelatedImages {
+			check(b, fmt.Sprintf("related image %q", ri.Name), ri.Image)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,131 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestImages(t *testing.T) {
+	withRelatedImages := func(b Bundle, images ...RelatedImage) Bundle {
+		b.RelatedImages = append(b.RelatedImages, images...)
+		return b
+	}
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			withRelatedImages(newTestBundle("foo", "0.1.0"),
+				RelatedImage{Name: "operator", Image: "quay.io/example/foo:v0.1.0"},
+				RelatedImage{Name: "empty"},
+			),
+			withRelatedImages(newTestBundle("bar", "0.1.0"),
+				RelatedImage{Name: "operator", Image: "quay.io/example/foo:v0.1.0"},
+			),
+			newTestBundle("foo", "0.2.0", withNoBundleImage(), func(b *Bundle) {
+				b.RelatedImages = nil
+			}),
+		},
+	}
+
+	require.Equal(t, []BundleImages{
+		{
+			Package: "foo",
+			Bundle:  testBundleName("foo", "0.1.0"),
+			Images:  sets.New[string](testBundleImage("foo", "0.1.0"), "quay.io/example/foo:v0.1.0"),
+		},
+		{
+			Package: "bar",
+			Bundle:  testBundleName("bar", "0.1.0"),
+			Images:  sets.New[string](testBundleImage("bar", "0.1.0"), "quay.io/example/foo:v0.1.0"),
+		},
+		{
+			Package: "foo",
+			Bundle:  testBundleName("foo", "0.2.0"),
+			Images:  sets.New[string](),
+		},
+	}, ImagesByBundle(cfg))
+
+	require.Equal(t, sets.New[string](
+		testBundleImage("foo", "0.1.0"),
+		testBundleImage("bar", "0.1.0"),
+		"quay.io/example/foo:v0.1.0",
+	), AllImages(cfg))
+}
+
+func TestImageFieldExtractor(t *testing.T) {
+	extract := ImageFieldExtractor("image", "proxyImage")
+	images, err := extract(json.RawMessage(`{"image":"a","operands":[{"image":"b","tag":"c"},{"proxyImage":"d"}],"nested":{"image":{"not":"a string"}}}`))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a", "b", "d"}, images)
+
+	_, err = extract(json.RawMessage(`{`))
+	require.Error(t, err)
+}
+
+func TestLintPropertyImages(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{
+				Schema:        SchemaBundle,
+				Package:       "foo",
+				Name:          "foo.v0.1.0",
+				Image:         "quay.io/example/foo-bundle:v0.1.0",
+				RelatedImages: []RelatedImage{{Name: "operand", Image: "quay.io/example/operand:v1"}},
+				Properties: []property.Property{
+					property.MustBuildPackage("foo", "0.1.0"),
+					{Type: "example.com/operands", Value: json.RawMessage(`{"operands":[{"image":"quay.io/example/operand:v1"},{"image":"quay.io/example/sidecar:v1"},{"image":"quay.io/example/sidecar:v1"}]}`)},
+					{Type: "example.com/bundle", Value: json.RawMessage(`{"image":"quay.io/example/foo-bundle:v0.1.0"}`)},
+					{Type: "example.com/ignored", Value: json.RawMessage(`{"image":"quay.io/example/ignored:v1"}`)},
+				},
+			},
+		},
+	}
+	extractors := map[string]ImageExtractor{
+		"example.com/operands": ImageFieldExtractor("image"),
+		"example.com/bundle":   ImageFieldExtractor("image"),
+	}
+
+	issues, err := LintPropertyImages(cfg, extractors)
+	require.NoError(t, err)
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `"example.com/operands" property references image "quay.io/example/sidecar:v1", which is not a related image`},
+	}, issues)
+
+	extractors["example.com/bundle"] = func(json.RawMessage) ([]string, error) { return nil, errors.New("boom") }
+	_, err = LintPropertyImages(cfg, extractors)
+	require.EqualError(t, err, `package "foo", bundle "foo.v0.1.0": extract images from "example.com/bundle" property: boom`)
+}
+
+func TestValidateImageRefs(t *testing.T) {
+	const digest = "@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/foo-bundle:v0.1.0", RelatedImages: []RelatedImage{
+				{Name: "operator", Image: "quay.io/example/foo" + digest},
+				{Name: "short", Image: "busybox"},
+				{Name: "bad-tag", Image: "quay.io/example/foo:v0.1.0+build"},
+				{Name: "upper", Image: "quay.io/Example/foo:v1"},
+				{Name: "bad-digest", Image: "quay.io/example/foo@sha256:abc"},
+			}},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0", RelatedImages: []RelatedImage{{Name: "empty"}}},
+		},
+	}
+
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "bad-tag" "quay.io/example/foo:v0.1.0+build" is invalid: invalid reference format`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "upper" "quay.io/Example/foo:v1" is invalid: invalid reference format: repository name must be lowercase`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "bad-digest" "quay.io/example/foo@sha256:abc" is invalid: invalid reference format`},
+		{Package: "foo", Bundle: "foo.v0.2.0", Message: "bundle image is empty"},
+		{Package: "foo", Bundle: "foo.v0.2.0", Message: `related image "empty" is empty`},
+	}, ValidateImageRefs(cfg))
+
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "bad-tag" "quay.io/example/foo:v0.1.0+build" is invalid: invalid reference format`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "upper" "quay.io/Example/foo:v1" is invalid: invalid reference format: repository name must be lowercase`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `related image "bad-digest" "quay.io/example/foo@sha256:abc" is invalid: invalid reference format`},
+	}, ValidateImageRefs(cfg, AllowEmptyImages()))
+}
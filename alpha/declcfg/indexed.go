@@ -0,0 +1,172 @@
+package declcfg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotIndexed is returned by IndexedReader.Read when the requested object
+// is not in the index.
+var ErrNotIndexed = errors.New("object not found in index")
+
+// IndexEntry locates a single object in a catalog written by WriteIndexed.
+// Offset and Length are the position and size in bytes of the object's JSON
+// encoding in the catalog data, excluding its trailing newline.
+type IndexEntry struct {
+	Schema  string `json:"schema"`
+	Package string `json:"package,omitempty"`
+	Name    string `json:"name"`
+	Offset  int64  `json:"offset"`
+	Length  int64  `json:"length"`
+}
+
+type indexKey struct {
+	schema, pkg, name string
+}
+
+// WriteIndexed writes cfg to dataW as a stream of compact JSON objects, in
+// the same order as WriteCompactJSON, and writes an index of the objects to
+// indexW. The index has one compact JSON IndexEntry per line, in the order
+// the objects appear in the data, so the same cfg always produces the same
+// data and index.
+//
+// Objects without a name cannot be looked up and are not indexed. An error
+// is returned if two objects have the same schema, package, and name.
+func WriteIndexed(cfg DeclarativeConfig, dataW, indexW io.Writer) error {
+	enc := &indexingEncoder{w: &countingWriter{w: dataW}, seen: map[indexKey]struct{}{}}
+	enc.enc = json.NewEncoder(enc.w)
+	enc.enc.SetEscapeHTML(false)
+	if err := writeToEncoder(cfg, enc); err != nil {
+		return err
+	}
+
+	indexEnc := json.NewEncoder(indexW)
+	for _, e := range enc.entries {
+		if err := indexEnc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// indexingEncoder encodes objects as compact JSON and records an IndexEntry
+// for each of them.
+type indexingEncoder struct {
+	w       *countingWriter
+	enc     *json.Encoder
+	seen    map[indexKey]struct{}
+	entries []IndexEntry
+}
+
+func (e *indexingEncoder) Encode(v interface{}) error {
+	var key indexKey
+	switch o := v.(type) {
+	case Package:
+		key = indexKey{SchemaPackage, "", o.Name}
+	case Channel:
+		key = indexKey{SchemaChannel, o.Package, o.Name}
+	case Bundle:
+		key = indexKey{SchemaBundle, o.Package, o.Name}
+	case Meta:
+		key = indexKey{o.Schema, o.Package, o.Name}
+	default:
+		return fmt.Errorf("cannot index object of type %T", v)
+	}
+
+	start := e.w.n
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	if key.name == "" {
+		return nil
+	}
+	if _, ok := e.seen[key]; ok {
+		id, _ := streamIdentity(&Meta{Schema: key.schema, Package: key.pkg, Name: key.name})
+		return fmt.Errorf("%s: duplicate object cannot be indexed", id)
+	}
+	e.seen[key] = struct{}{}
+	e.entries = append(e.entries, IndexEntry{
+		Schema:  key.schema,
+		Package: key.pkg,
+		Name:    key.name,
+		Offset:  start,
+		Length:  e.w.n - start - 1,
+	})
+	return nil
+}
+
+// IndexedReader reads individual objects from a catalog written by
+// WriteIndexed, without parsing the rest of the catalog.
+type IndexedReader struct {
+	data    io.ReaderAt
+	entries []IndexEntry
+	byKey   map[indexKey]IndexEntry
+}
+
+// NewIndexedReader returns an IndexedReader for the catalog data, using the
+// index read from index. The index is read in full; data is only read by
+// Read.
+func NewIndexedReader(data io.ReaderAt, index io.Reader) (*IndexedReader, error) {
+	r := &IndexedReader{data: data, byKey: map[indexKey]IndexEntry{}}
+	dec := json.NewDecoder(bufio.NewReader(index))
+	for {
+		var e IndexEntry
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("read index entry %d: %v", len(r.entries), err)
+		}
+		if e.Offset < 0 || e.Length < 0 {
+			return nil, fmt.Errorf("read index entry %d: invalid offset %d or length %d", len(r.entries), e.Offset, e.Length)
+		}
+		r.entries = append(r.entries, e)
+		r.byKey[indexKey{e.Schema, e.Package, e.Name}] = e
+	}
+	return r, nil
+}
+
+// Entries returns the index entries, in the order the objects appear in the
+// catalog data.
+func (r *IndexedReader) Entries() []IndexEntry {
+	out := make([]IndexEntry, len(r.entries))
+	copy(out, r.entries)
+	return out
+}
+
+// Read returns the object with the given schema, package, and name. Package
+// objects have an empty package. The returned error wraps ErrNotIndexed if
+// the object is not in the index.
+func (r *IndexedReader) Read(schema, pkg, name string) (*Meta, error) {
+	id, _ := streamIdentity(&Meta{Schema: schema, Package: pkg, Name: name})
+	e, ok := r.byKey[indexKey{schema, pkg, name}]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, ErrNotIndexed)
+	}
+	buf := make([]byte, e.Length)
+	if _, err := r.data.ReadAt(buf, e.Offset); err != nil {
+		return nil, fmt.Errorf("%s: read %d bytes at offset %d: %v", id, e.Length, e.Offset, err)
+	}
+	var m Meta
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("%s: parse object at offset %d: %v", id, e.Offset, err)
+	}
+	if m.Schema != schema || m.Package != pkg || m.Name != name {
+		return nil, fmt.Errorf("%s: index does not match catalog data at offset %d", id, e.Offset)
+	}
+	return &m, nil
+}
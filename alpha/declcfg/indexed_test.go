@@ -0,0 +1,62 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIndexed(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+
+	var data, index bytes.Buffer
+	require.NoError(t, WriteIndexed(cfg, &data, &index))
+
+	// The data is the same as that written by WriteCompactJSON.
+	var compact bytes.Buffer
+	require.NoError(t, WriteCompactJSON(cfg, &compact))
+	require.Equal(t, compact.String(), data.String())
+
+	r, err := NewIndexedReader(bytes.NewReader(data.Bytes()), &index)
+	require.NoError(t, err)
+	require.NotEmpty(t, r.Entries())
+
+	for _, b := range cfg.Bundles {
+		m, err := r.Read(SchemaBundle, b.Package, b.Name)
+		require.NoError(t, err)
+		var actual Bundle
+		require.NoError(t, json.Unmarshal(m.Blob, &actual))
+		require.Equal(t, b.Name, actual.Name)
+		require.Equal(t, b.Image, actual.Image)
+	}
+	for _, p := range cfg.Packages {
+		m, err := r.Read(SchemaPackage, "", p.Name)
+		require.NoError(t, err)
+		require.Equal(t, p.Name, m.Name)
+	}
+
+	_, err = r.Read(SchemaBundle, "anakin", "anakin.v9.9.9")
+	require.ErrorIs(t, err, ErrNotIndexed)
+	require.EqualError(t, err, `package "anakin", bundle "anakin.v9.9.9": object not found in index`)
+}
+
+func TestWriteIndexedDuplicate(t *testing.T) {
+	cfg := DeclarativeConfig{Bundles: []Bundle{newTestBundle("foo", "0.1.0"), newTestBundle("foo", "0.1.0")}}
+	err := WriteIndexed(cfg, &bytes.Buffer{}, &bytes.Buffer{})
+	require.EqualError(t, err, `package "foo", bundle "foo.v0.1.0": duplicate object cannot be indexed`)
+}
+
+func TestIndexedReaderStaleIndex(t *testing.T) {
+	cfg := DeclarativeConfig{Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)}}
+	var data, index bytes.Buffer
+	require.NoError(t, WriteIndexed(cfg, &data, &index))
+
+	stale := strings.Replace(index.String(), `"name":"foo"`, `"name":"bar"`, 1)
+	r, err := NewIndexedReader(bytes.NewReader(data.Bytes()), strings.NewReader(stale))
+	require.NoError(t, err)
+	_, err = r.Read(SchemaPackage, "", "bar")
+	require.EqualError(t, err, `package "bar": index does not match catalog data at offset 0`)
+}
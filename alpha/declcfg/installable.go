@@ -0,0 +1,150 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// typeMaxOpenShiftVersion is the bundle property OLM uses to block upgrades
+// of OpenShift clusters beyond the given major.minor version.
+const typeMaxOpenShiftVersion = "olm.maxOpenShiftVersion"
+
+// InstallConstraints describe the cluster that a bundle is being selected
+// for. Unset constraints are not checked.
+type InstallConstraints struct {
+	// KubeVersion is the cluster's Kubernetes version. Bundles whose
+	// olm.csv.metadata minKubeVersion is greater are not installable.
+	KubeVersion *semver.Version
+
+	// ClusterVersion is the cluster's OpenShift version. Bundles with an
+	// olm.maxOpenShiftVersion property whose major.minor version is lower
+	// are not installable.
+	ClusterVersion *semver.Version
+
+	// AvailableGVKs are the APIs available on the cluster. If non-nil,
+	// bundles with an olm.gvk.required property that is not in
+	// AvailableGVKs are not installable.
+	AvailableGVKs []property.GVK
+}
+
+// NewestInstallable returns the newest bundle in the given channel that
+// satisfies constraints. It walks the replaces chain from the channel head
+// downward and returns the first bundle that satisfies constraints, so
+// bundles that are only skipped by the chain are never returned. An error
+// is returned if the channel does not exist or has no single head, the
+// replaces chain has a cycle, a bundle on the chain is missing or has
+// invalid properties, or no bundle on the chain satisfies constraints.
+func NewestInstallable(cfg DeclarativeConfig, pkg, channel string, constraints InstallConstraints) (*Bundle, error) {
+	var ch *Channel
+	for i := range cfg.Channels {
+		if cfg.Channels[i].Package == pkg && cfg.Channels[i].Name == channel {
+			ch = &cfg.Channels[i]
+			break
+		}
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("package %q: channel %q not found", pkg, channel)
+	}
+	head, err := channelHead(*ch)
+	if err != nil {
+		return nil, fmt.Errorf("package %q, channel %q: %v", pkg, channel, err)
+	}
+
+	entries := map[string]ChannelEntry{}
+	for _, e := range ch.Entries {
+		entries[e.Name] = e
+	}
+	bundles := map[string]*Bundle{}
+	for i := range cfg.Bundles {
+		if cfg.Bundles[i].Package == pkg {
+			bundles[cfg.Bundles[i].Name] = &cfg.Bundles[i]
+		}
+	}
+
+	visited := sets.New[string]()
+	for name := head; name != ""; {
+		e, ok := entries[name]
+		if !ok {
+			// The chain continues outside the channel.
+			break
+		}
+		if visited.Has(name) {
+			return nil, fmt.Errorf("package %q, channel %q: replaces chain has a cycle at %q", pkg, channel, name)
+		}
+		visited.Insert(name)
+
+		b, ok := bundles[name]
+		if !ok {
+			return nil, fmt.Errorf("package %q, channel %q: bundle %q not found", pkg, channel, name)
+		}
+		ok, err := isInstallable(*b, constraints)
+		if err != nil {
+			return nil, fmt.Errorf("package %q, bundle %q: %v", pkg, name, err)
+		}
+		if ok {
+			return b, nil
+		}
+		name = e.Replaces
+	}
+	return nil, fmt.Errorf("package %q, channel %q: no bundle satisfies the install constraints", pkg, channel)
+}
+
+func isInstallable(b Bundle, constraints InstallConstraints) (bool, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return false, fmt.Errorf("parse properties: %v", err)
+	}
+
+	if constraints.KubeVersion != nil {
+		for _, md := range props.CSVMetadatas {
+			if md.MinKubeVersion == "" {
+				continue
+			}
+			minVersion, err := semver.ParseTolerant(md.MinKubeVersion)
+			if err != nil {
+				return false, fmt.Errorf("invalid minKubeVersion %q: %v", md.MinKubeVersion, err)
+			}
+			if constraints.KubeVersion.LT(minVersion) {
+				return false, nil
+			}
+		}
+	}
+
+	if constraints.ClusterVersion != nil {
+		for _, p := range props.Others {
+			if p.Type != typeMaxOpenShiftVersion {
+				continue
+			}
+			var raw string
+			if err := json.Unmarshal(p.Value, &raw); err != nil {
+				return false, fmt.Errorf("invalid %q property: %v", typeMaxOpenShiftVersion, err)
+			}
+			maxVersion, err := semver.ParseTolerant(raw)
+			if err != nil {
+				return false, fmt.Errorf("invalid %q property: %v", typeMaxOpenShiftVersion, err)
+			}
+			cv := constraints.ClusterVersion
+			if cv.Major > maxVersion.Major || (cv.Major == maxVersion.Major && cv.Minor > maxVersion.Minor) {
+				return false, nil
+			}
+		}
+	}
+
+	if constraints.AvailableGVKs != nil {
+		available := map[property.GVK]struct{}{}
+		for _, gvk := range constraints.AvailableGVKs {
+			available[gvk] = struct{}{}
+		}
+		for _, req := range props.GVKsRequired {
+			if _, ok := available[property.GVK(req)]; !ok {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
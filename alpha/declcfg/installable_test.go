@@ -0,0 +1,161 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestNewestInstallable(t *testing.T) {
+	bundle := func(version string, props ...property.Property) Bundle {
+		return Bundle{
+			Schema:     SchemaBundle,
+			Package:    "foo",
+			Name:       testBundleName("foo", version),
+			Properties: append([]property.Property{property.MustBuildPackage("foo", version)}, props...),
+		}
+	}
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.3.0"), Replaces: testBundleName("foo", "0.2.0")},
+			),
+			newTestChannel("foo", "broken", ChannelEntry{Name: testBundleName("foo", "9.9.9")}),
+		},
+		Bundles: []Bundle{
+			bundle("0.1.0"),
+			bundle("0.2.0",
+				property.MustBuild(&property.CSVMetadata{MinKubeVersion: "1.25.0"}),
+				property.Property{Type: typeMaxOpenShiftVersion, Value: json.RawMessage(`"4.14"`)},
+			),
+			bundle("0.3.0",
+				property.MustBuild(&property.CSVMetadata{MinKubeVersion: "1.27.0"}),
+				property.MustBuildGVKRequired("bar.example.com", "v1", "Bar"),
+			),
+		},
+	}
+	version := func(v string) *semver.Version {
+		sv := semver.MustParse(v)
+		return &sv
+	}
+
+	type spec struct {
+		name        string
+		channel     string
+		constraints InstallConstraints
+		expected    string
+		assertion   require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/NoConstraints",
+			channel:   "stable",
+			expected:  testBundleName("foo", "0.3.0"),
+			assertion: require.NoError,
+		},
+		{
+			name:    "Success/AllSatisfied",
+			channel: "stable",
+			constraints: InstallConstraints{
+				KubeVersion:   version("1.28.0"),
+				AvailableGVKs: []property.GVK{{Group: "bar.example.com", Version: "v1", Kind: "Bar"}},
+			},
+			expected:  testBundleName("foo", "0.3.0"),
+			assertion: require.NoError,
+		},
+		{
+			name:        "Success/KubeVersionTooOld",
+			channel:     "stable",
+			constraints: InstallConstraints{KubeVersion: version("1.26.3")},
+			expected:    testBundleName("foo", "0.2.0"),
+			assertion:   require.NoError,
+		},
+		{
+			name:        "Success/MissingRequiredGVK",
+			channel:     "stable",
+			constraints: InstallConstraints{AvailableGVKs: []property.GVK{}},
+			expected:    testBundleName("foo", "0.2.0"),
+			assertion:   require.NoError,
+		},
+		{
+			name:    "Success/ClusterVersionTooNew",
+			channel: "stable",
+			constraints: InstallConstraints{
+				KubeVersion:    version("1.26.0"),
+				ClusterVersion: version("4.15.2"),
+			},
+			expected:  testBundleName("foo", "0.1.0"),
+			assertion: require.NoError,
+		},
+		{
+			name:    "Success/ClusterVersionPatchIgnored",
+			channel: "stable",
+			constraints: InstallConstraints{
+				KubeVersion:    version("1.26.0"),
+				ClusterVersion: version("4.14.9"),
+			},
+			expected:  testBundleName("foo", "0.2.0"),
+			assertion: require.NoError,
+		},
+		{
+			name:        "Success/OnlyOldestSatisfies",
+			channel:     "stable",
+			constraints: InstallConstraints{KubeVersion: version("1.26.0"), ClusterVersion: version("4.15.0"), AvailableGVKs: []property.GVK{}},
+			expected:    testBundleName("foo", "0.1.0"),
+			assertion:   require.NoError,
+		},
+		{
+			name:      "Error/UnknownChannel",
+			channel:   "fast",
+			assertion: hasError(`package "foo": channel "fast" not found`),
+		},
+		{
+			name:      "Error/MissingBundle",
+			channel:   "broken",
+			assertion: hasError(`package "foo", channel "broken": bundle "foo.v9.9.9" not found`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			b, err := NewestInstallable(cfg, "foo", s.channel, s.constraints)
+			s.assertion(t, err)
+			if s.expected == "" {
+				require.Nil(t, b)
+				return
+			}
+			require.Equal(t, s.expected, b.Name)
+		})
+	}
+
+	// A skipped bundle is not on the replaces chain, so it is not returned
+	// even though it is newer than the installable bundle the chain leads to.
+	skipped := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.2.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.3.0"), Replaces: testBundleName("foo", "0.1.0"), Skips: []string{testBundleName("foo", "0.2.0")}},
+			),
+		},
+		Bundles: []Bundle{
+			bundle("0.1.0"),
+			bundle("0.2.0"),
+			bundle("0.3.0", property.MustBuild(&property.CSVMetadata{MinKubeVersion: "1.27.0"})),
+		},
+	}
+	b, err := NewestInstallable(skipped, "foo", "stable", InstallConstraints{KubeVersion: version("1.26.0")})
+	require.NoError(t, err)
+	require.Equal(t, testBundleName("foo", "0.1.0"), b.Name)
+
+	_, err = NewestInstallable(DeclarativeConfig{
+		Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.2.0")})},
+		Bundles:  []Bundle{cfg.Bundles[1]},
+	}, "foo", "stable", InstallConstraints{KubeVersion: version("1.24.0")})
+	require.EqualError(t, err, `package "foo", channel "stable": no bundle satisfies the install constraints`)
+}
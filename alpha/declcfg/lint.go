@@ -0,0 +1,703 @@
+package declcfg
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// LintIssue describes a problem found by a lint. Lints report content that
+// is structurally valid but is very likely a mistake, so, unlike validation,
+// they never prevent a declarative config from being used.
+type LintIssue struct {
+	Package string `json:"package,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Bundle  string `json:"bundle,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	var loc []string
+	if i.Package != "" {
+		loc = append(loc, fmt.Sprintf("package %q", i.Package))
+	}
+	if i.Channel != "" {
+		loc = append(loc, fmt.Sprintf("channel %q", i.Channel))
+	}
+	if i.Bundle != "" {
+		loc = append(loc, fmt.Sprintf("bundle %q", i.Bundle))
+	}
+	if len(loc) == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(loc, ", "), i.Message)
+}
+
+// LintSkipsAndReplaces reports channel entries whose Skips contain the entry's
+// Replaces, and entries that list the same bundle in Skips more than once.
+func LintSkipsAndReplaces(cfg DeclarativeConfig) []LintIssue {
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			seen := map[string]int{}
+			for _, s := range e.Skips {
+				seen[s]++
+				if seen[s] == 2 {
+					issues = append(issues, LintIssue{
+						Package: ch.Package,
+						Channel: ch.Name,
+						Bundle:  e.Name,
+						Message: fmt.Sprintf("skips %q more than once", s),
+					})
+				}
+			}
+			if e.Replaces != "" && seen[e.Replaces] > 0 {
+				issues = append(issues, LintIssue{
+					Package: ch.Package,
+					Channel: ch.Name,
+					Bundle:  e.Name,
+					Message: fmt.Sprintf("both replaces and skips %q", e.Replaces),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// DefaultKubeVersionConstraintTypes are the property types that
+// LintKubeVersionConstraints accepts as a kube version constraint when no
+// types are provided.
+var DefaultKubeVersionConstraintTypes = []string{property.TypeCSVMetadata, property.TypePackageRequired}
+
+// LintKubeVersionConstraints reports bundles that do not declare a kube
+// version constraint using at least one of the property types in
+// constraintTypes, or DefaultKubeVersionConstraintTypes if none are provided.
+// An olm.csv.metadata property only counts as a constraint if it sets
+// minKubeVersion.
+func LintKubeVersionConstraints(cfg DeclarativeConfig, constraintTypes ...string) []LintIssue {
+	if len(constraintTypes) == 0 {
+		constraintTypes = DefaultKubeVersionConstraintTypes
+	}
+	accepted := map[string]struct{}{}
+	quoted := make([]string, 0, len(constraintTypes))
+	for _, t := range constraintTypes {
+		accepted[t] = struct{}{}
+		quoted = append(quoted, fmt.Sprintf("%q", t))
+	}
+
+	var issues []LintIssue
+	for _, b := range cfg.Bundles {
+		if !hasKubeVersionConstraint(b, accepted) {
+			issues = append(issues, LintIssue{
+				Package: b.Package,
+				Bundle:  b.Name,
+				Message: fmt.Sprintf("no kube version constraint: missing property of type %s", strings.Join(quoted, " or ")),
+			})
+		}
+	}
+	return issues
+}
+
+func hasKubeVersionConstraint(b Bundle, accepted map[string]struct{}) bool {
+	for _, p := range b.Properties {
+		if _, ok := accepted[p.Type]; !ok {
+			continue
+		}
+		if p.Type != property.TypeCSVMetadata {
+			return true
+		}
+		props, err := property.Parse([]property.Property{p})
+		if err == nil && props.CSVMetadatas[0].MinKubeVersion != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// LintUnreferencedBundles reports bundles that are not an entry of any
+// channel in their package, and are therefore unreachable. Bundles named in
+// allowed are intentionally hidden and are not reported.
+func LintUnreferencedBundles(cfg DeclarativeConfig, allowed ...string) []LintIssue {
+	type key struct {
+		pkg  string
+		name string
+	}
+	referenced := map[key]struct{}{}
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			referenced[key{ch.Package, e.Name}] = struct{}{}
+		}
+	}
+	allowedSet := map[string]struct{}{}
+	for _, name := range allowed {
+		allowedSet[name] = struct{}{}
+	}
+
+	var issues []LintIssue
+	for _, b := range cfg.Bundles {
+		if _, ok := referenced[key{b.Package, b.Name}]; ok {
+			continue
+		}
+		if _, ok := allowedSet[b.Name]; ok {
+			continue
+		}
+		issues = append(issues, LintIssue{
+			Package: b.Package,
+			Bundle:  b.Name,
+			Message: "not an entry in any channel",
+		})
+	}
+	return issues
+}
+
+// LintChannelNames reports channels whose names do not fully match at least
+// one of patterns. Patterns are regular expressions, so exact names such as
+// "stable" can be mixed with patterns such as `v\d+\.\d+`. If no patterns
+// are provided, every channel name is allowed.
+func LintChannelNames(cfg DeclarativeConfig, patterns ...string) ([]LintIssue, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid channel name pattern %q: %v", p, err)
+		}
+		regexps = append(regexps, regexp.MustCompile(`^(?:`+p+`)$`))
+	}
+
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		matched := false
+		for _, re := range regexps {
+			if re.MatchString(ch.Name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			issues = append(issues, LintIssue{
+				Package: ch.Package,
+				Channel: ch.Name,
+				Message: "channel name does not match any allowed pattern",
+			})
+		}
+	}
+	return issues, nil
+}
+
+// LintDanglingReferences reports channel entries that do not have a bundle in
+// the channel's package, and entries that replace a bundle that does not
+// exist in the package. Skips are not checked, since skipping bundles that
+// have been removed from the catalog is common and harmless.
+func LintDanglingReferences(cfg DeclarativeConfig) []LintIssue {
+	bundles := map[string]sets.Set[string]{}
+	for _, b := range cfg.Bundles {
+		if _, ok := bundles[b.Package]; !ok {
+			bundles[b.Package] = sets.New[string]()
+		}
+		bundles[b.Package].Insert(b.Name)
+	}
+
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			if !bundles[ch.Package].Has(e.Name) {
+				issues = append(issues, LintIssue{
+					Package: ch.Package,
+					Channel: ch.Name,
+					Bundle:  e.Name,
+					Message: "entry does not have a bundle in the package",
+				})
+			}
+			if e.Replaces != "" && !bundles[ch.Package].Has(e.Replaces) {
+				issues = append(issues, LintIssue{
+					Package: ch.Package,
+					Channel: ch.Name,
+					Bundle:  e.Name,
+					Message: fmt.Sprintf("replaces %q, which is not a bundle in the package", e.Replaces),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// LintReplacesCycles reports cycles in the replaces chain of each channel.
+// Each cycle is reported once, on its lexically smallest entry.
+func LintReplacesCycles(cfg DeclarativeConfig) []LintIssue {
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		replaces := map[string]string{}
+		for _, e := range ch.Entries {
+			if e.Replaces != "" {
+				replaces[e.Name] = e.Replaces
+			}
+		}
+
+		for _, e := range ch.Entries {
+			// Follow the chain from e. If it returns to e, e is on a cycle.
+			cycle := []string{e.Name}
+			onCycle := false
+			for cur, ok := replaces[e.Name]; ok && len(cycle) <= len(replaces); cur, ok = replaces[cur] {
+				if cur == e.Name {
+					onCycle = true
+					break
+				}
+				cycle = append(cycle, cur)
+			}
+			if !onCycle || sets.List(sets.New[string](cycle...))[0] != e.Name {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Package: ch.Package,
+				Channel: ch.Name,
+				Bundle:  e.Name,
+				Message: fmt.Sprintf("replaces chain contains a cycle: %s -> %s", strings.Join(cycle, " -> "), e.Name),
+			})
+		}
+	}
+	return issues
+}
+
+// LintChannelHeads reports channels with more than maxHeads heads. A head is
+// an entry that is not replaced or skipped by any other entry in the
+// channel. Multiple heads usually indicate a gap in the upgrade graph that
+// leaves some bundles without an upgrade path. A maxHeads less than 1 is
+// treated as 1.
+func LintChannelHeads(cfg DeclarativeConfig, maxHeads int) []LintIssue {
+	if maxHeads < 1 {
+		maxHeads = 1
+	}
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		incoming := sets.New[string]()
+		for _, e := range ch.Entries {
+			if e.Replaces != "" && e.Replaces != e.Name {
+				incoming.Insert(e.Replaces)
+			}
+			for _, s := range e.Skips {
+				if s != e.Name {
+					incoming.Insert(s)
+				}
+			}
+		}
+		heads := sets.New[string]()
+		for _, e := range ch.Entries {
+			if !incoming.Has(e.Name) {
+				heads.Insert(e.Name)
+			}
+		}
+		if heads.Len() > maxHeads {
+			issues = append(issues, LintIssue{
+				Package: ch.Package,
+				Channel: ch.Name,
+				Message: fmt.Sprintf("channel has %d heads: %s", heads.Len(), quotedList(sets.List(heads))),
+			})
+		}
+	}
+	return issues
+}
+
+// LintSkipsHead reports entries that skip the bundle that should be the
+// head of their channel. The channel's head is the entry that no other
+// entry replaces or skips (see channelHead), so a skip that targets the
+// intended head makes an older entry the head instead, and hides the newer
+// bundle from upgrades. This is detected by comparing versions: an entry
+// that skips a channel entry newer than the channel's head is reported.
+// Such skips have usually been copied from another entry without being
+// updated.
+//
+// Channels that do not have exactly one head are skipped, as are entries
+// whose bundle has no valid version; see LintChannelHeads.
+func LintSkipsHead(cfg DeclarativeConfig) []LintIssue {
+	type key struct {
+		pkg  string
+		name string
+	}
+	versions := map[key]semver.Version{}
+	for i := range cfg.Bundles {
+		if v, err := parseVersionProperty(&cfg.Bundles[i]); err == nil {
+			versions[key{cfg.Bundles[i].Package, cfg.Bundles[i].Name}] = *v
+		}
+	}
+
+	var issues []LintIssue
+	for _, ch := range cfg.Channels {
+		head, err := channelHead(ch)
+		if err != nil {
+			continue
+		}
+		headVersion, ok := versions[key{ch.Package, head}]
+		if !ok {
+			continue
+		}
+		entries := sets.New[string]()
+		for _, e := range ch.Entries {
+			entries.Insert(e.Name)
+		}
+		for _, e := range ch.Entries {
+			for _, s := range sets.List(sets.New[string](e.Skips...)) {
+				if s == e.Name || !entries.Has(s) {
+					continue
+				}
+				v, ok := versions[key{ch.Package, s}]
+				if !ok || v.LTE(headVersion) {
+					continue
+				}
+				issues = append(issues, LintIssue{
+					Package: ch.Package,
+					Channel: ch.Name,
+					Bundle:  e.Name,
+					Message: fmt.Sprintf("skips %q, which is newer than the channel head %q", s, head),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// LintUnpinnedImages reports bundles whose image or related images are not
+// referenced by digest. Empty image references are ignored.
+func LintUnpinnedImages(cfg DeclarativeConfig) []LintIssue {
+	var issues []LintIssue
+	for _, bi := range ImagesByBundle(cfg) {
+		var unpinned []string
+		for _, img := range sets.List(bi.Images) {
+			if !strings.Contains(img, "@") {
+				unpinned = append(unpinned, img)
+			}
+		}
+		if len(unpinned) > 0 {
+			issues = append(issues, LintIssue{
+				Package: bi.Package,
+				Bundle:  bi.Bundle,
+				Message: fmt.Sprintf("images not pinned by digest: %s", quotedList(unpinned)),
+			})
+		}
+	}
+	return issues
+}
+
+// LintNonCanonicalOthers reports objects in Others whose blobs are not in
+// canonical compact JSON form, with sorted object keys and no insignificant
+// whitespace. Such blobs cause noisy diffs when catalogs are regenerated.
+// See OthersBlobCanonical.
+func LintNonCanonicalOthers(cfg DeclarativeConfig) []LintIssue {
+	var issues []LintIssue
+	for _, o := range cfg.Others {
+		canonical, err := canonicalizeJSON(o.Blob)
+		var msg string
+		switch {
+		case err != nil:
+			msg = fmt.Sprintf("%s object %q is not valid JSON: %v", o.Schema, o.Name, err)
+		case !bytes.Equal(canonical, o.Blob):
+			msg = fmt.Sprintf("%s object %q is not in canonical JSON form", o.Schema, o.Name)
+		default:
+			continue
+		}
+		issues = append(issues, LintIssue{Package: o.Package, Message: msg})
+	}
+	return issues
+}
+
+// CheckChannelConnectivity reports structural problems in the upgrade graph
+// of ch, whose edges lead from each entry to the bundles it replaces and
+// skips. The graph must be a single weakly-connected DAG: each connected
+// component is reported if there is more than one, and each cycle is
+// reported with the entries on it.
+//
+// Besides the channel's entries, the graph includes the bundles in bundles
+// that belong to ch's package and are referenced by an entry, since
+// replacing or skipping a bundle outside the channel is allowed. Other
+// references are ignored; LintDanglingReferences reports them.
+func CheckChannelConnectivity(ch Channel, bundles []Bundle) []LintIssue {
+	known := sets.New[string]()
+	for _, b := range bundles {
+		if b.Package == ch.Package {
+			known.Insert(b.Name)
+		}
+	}
+
+	nodes := sets.New[string]()
+	for _, e := range ch.Entries {
+		nodes.Insert(e.Name)
+	}
+	edges := map[string][]string{}
+	for _, e := range ch.Entries {
+		targets := append([]string{}, e.Skips...)
+		if e.Replaces != "" {
+			targets = append(targets, e.Replaces)
+		}
+		for _, t := range targets {
+			if !nodes.Has(t) && !known.Has(t) {
+				continue
+			}
+			edges[e.Name] = append(edges[e.Name], t)
+		}
+	}
+	for _, ts := range edges {
+		nodes.Insert(ts...)
+	}
+
+	var issues []LintIssue
+
+	components := weakComponents(sets.List(nodes), edges)
+	if len(components) > 1 {
+		for i, c := range components {
+			issues = append(issues, LintIssue{
+				Package: ch.Package,
+				Channel: ch.Name,
+				Message: fmt.Sprintf("upgrade graph is disconnected: component %d of %d contains %s", i+1, len(components), quotedList(c)),
+			})
+		}
+	}
+
+	for _, c := range cycles(sets.List(nodes), edges) {
+		issues = append(issues, LintIssue{
+			Package: ch.Package,
+			Channel: ch.Name,
+			Message: fmt.Sprintf("upgrade graph contains a cycle between %s", quotedList(c)),
+		})
+	}
+	return issues
+}
+
+// weakComponents returns the weakly-connected components of the directed
+// graph with the given nodes and edges. Each component is sorted, and
+// components are ordered by their first node.
+func weakComponents(nodes []string, edges map[string][]string) [][]string {
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(n string) string {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+		return parent[n]
+	}
+	for _, n := range nodes {
+		parent[n] = n
+	}
+	for from, tos := range edges {
+		for _, to := range tos {
+			parent[find(from)] = find(to)
+		}
+	}
+
+	members := map[string][]string{}
+	var roots []string
+	for _, n := range nodes {
+		r := find(n)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], n)
+	}
+	components := make([][]string, 0, len(roots))
+	for _, r := range roots {
+		components = append(components, members[r])
+	}
+	return components
+}
+
+// cycles returns the strongly-connected components of the directed graph
+// with the given nodes and edges that contain a cycle, using Tarjan's
+// algorithm. Each cycle is sorted, and cycles are ordered by their first
+// node.
+func cycles(nodes []string, edges map[string][]string) [][]string {
+	var (
+		index   = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = sets.New[string]()
+		stack   []string
+		out     [][]string
+	)
+	var visit func(string)
+	visit = func(n string) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack.Insert(n)
+		selfLoop := false
+		for _, m := range edges[n] {
+			if m == n {
+				selfLoop = true
+			}
+			if _, ok := index[m]; !ok {
+				visit(m)
+				if lowlink[m] < lowlink[n] {
+					lowlink[n] = lowlink[m]
+				}
+			} else if onStack.Has(m) && index[m] < lowlink[n] {
+				lowlink[n] = index[m]
+			}
+		}
+		if lowlink[n] != index[n] {
+			return
+		}
+		var scc []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack.Delete(m)
+			scc = append(scc, m)
+			if m == n {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLoop {
+			out = append(out, sets.List(sets.New[string](scc...)))
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := index[n]; !ok {
+			visit(n)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+// LintUnreachableFromDefaultHead reports bundles that cannot upgrade to the
+// head of their package's default channel. Starting from the head, the
+// upgrade edges of every channel in the package are followed backwards:
+// each bundle reaches the bundles it replaces or skips, and the bundles
+// whose versions are in its skipRange. Bundles that are not reached
+// include those that are newer than the default channel's head, such as
+// bundles only published to a faster channel, so this is reported as a
+// lint rather than enforced by Validate. Packages whose default channel
+// does not exist are skipped.
+func LintUnreachableFromDefaultHead(cfg DeclarativeConfig) []LintIssue {
+	bundlesByPackage := map[string][]*Bundle{}
+	for i := range cfg.Bundles {
+		bundlesByPackage[cfg.Bundles[i].Package] = append(bundlesByPackage[cfg.Bundles[i].Package], &cfg.Bundles[i])
+	}
+	channelsByPackage := map[string][]Channel{}
+	for _, ch := range cfg.Channels {
+		channelsByPackage[ch.Package] = append(channelsByPackage[ch.Package], ch)
+	}
+
+	var issues []LintIssue
+	for _, p := range cfg.Packages {
+		var heads []string
+		for _, ch := range channelsByPackage[p.Name] {
+			if ch.Name == p.DefaultChannel {
+				heads = channelHeads(ch)
+				break
+			}
+		}
+		if len(heads) == 0 {
+			continue
+		}
+
+		versions := map[string]semver.Version{}
+		for _, b := range bundlesByPackage[p.Name] {
+			if v, err := parseVersionProperty(b); err == nil {
+				versions[b.Name] = *v
+			}
+		}
+		edges := map[string][]string{}
+		for _, ch := range channelsByPackage[p.Name] {
+			for _, e := range ch.Entries {
+				if e.Replaces != "" {
+					edges[e.Name] = append(edges[e.Name], e.Replaces)
+				}
+				edges[e.Name] = append(edges[e.Name], e.Skips...)
+				if e.SkipRange == "" {
+					continue
+				}
+				if r, err := semver.ParseRange(e.SkipRange); err == nil {
+					for name, v := range versions {
+						if name != e.Name && r(v) {
+							edges[e.Name] = append(edges[e.Name], name)
+						}
+					}
+				}
+			}
+		}
+
+		reached := sets.New[string](heads...)
+		queue := append([]string{}, heads...)
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, next := range edges[cur] {
+				if !reached.Has(next) {
+					reached.Insert(next)
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		for _, b := range bundlesByPackage[p.Name] {
+			if reached.Has(b.Name) {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Package: p.Name,
+				Bundle:  b.Name,
+				Message: fmt.Sprintf("cannot upgrade to the head of default channel %q", p.DefaultChannel),
+			})
+		}
+	}
+	return issues
+}
+
+// CardinalityRule limits how many properties of Type a bundle may have.
+// A Max of zero or less means there is no maximum.
+type CardinalityRule struct {
+	Type string
+	Min  int
+	Max  int
+}
+
+// DefaultCardinalityRules are the rules CheckPropertyCardinality always
+// applies, unless they are overridden by a rule for the same type.
+var DefaultCardinalityRules = []CardinalityRule{
+	{Type: property.TypePackage, Min: 1, Max: 1},
+	{Type: property.TypeCSVMetadata, Max: 1},
+}
+
+// CheckPropertyCardinality reports property types of which b has too few or
+// too many properties, according to DefaultCardinalityRules and rules. A
+// rule in rules replaces the default rule for the same type, so extension
+// property types can be given rules and the defaults can be relaxed. Issues
+// are ordered by property type.
+func CheckPropertyCardinality(b Bundle, rules ...CardinalityRule) []LintIssue {
+	byType := map[string]CardinalityRule{}
+	for _, r := range DefaultCardinalityRules {
+		byType[r.Type] = r
+	}
+	for _, r := range rules {
+		byType[r.Type] = r
+	}
+	counts := map[string]int{}
+	for _, p := range b.Properties {
+		counts[p.Type]++
+	}
+
+	var issues []LintIssue
+	for _, t := range sets.List(sets.KeySet(byType)) {
+		r, n := byType[t], counts[t]
+		var msg string
+		switch {
+		case r.Max > 0 && r.Min == r.Max && n != r.Min:
+			msg = fmt.Sprintf("has %d %q properties, expected exactly %d", n, t, r.Min)
+		case n < r.Min:
+			msg = fmt.Sprintf("has %d %q properties, expected at least %d", n, t, r.Min)
+		case r.Max > 0 && n > r.Max:
+			msg = fmt.Sprintf("has %d %q properties, expected at most %d", n, t, r.Max)
+		default:
+			continue
+		}
+		issues = append(issues, LintIssue{Package: b.Package, Bundle: b.Name, Message: msg})
+	}
+	return issues
+}
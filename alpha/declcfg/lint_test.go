@@ -0,0 +1,342 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestLintIssueString(t *testing.T) {
+	require.Equal(t, "oops", LintIssue{Message: "oops"}.String())
+	require.Equal(t, `package "foo", channel "stable", bundle "foo.v0.1.0": oops`, LintIssue{
+		Package: "foo",
+		Channel: "stable",
+		Bundle:  "foo.v0.1.0",
+		Message: "oops",
+	}.String())
+}
+
+func TestLintSkipsAndReplaces(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.0"}},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.0", "foo.v0.1.0", "foo.v0.1.0"}},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.0"}},
+			),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "stable", Bundle: "foo.v0.2.0", Message: `both replaces and skips "foo.v0.1.0"`},
+		{Package: "foo", Channel: "stable", Bundle: "foo.v0.3.0", Message: `skips "foo.v0.1.0" more than once`},
+	}, LintSkipsAndReplaces(cfg))
+}
+
+func TestLintKubeVersionConstraints(t *testing.T) {
+	withProps := func(name string, props ...property.Property) Bundle {
+		return Bundle{Schema: SchemaBundle, Package: "foo", Name: name, Properties: append([]property.Property{property.MustBuildPackage("foo", "0.1.0")}, props...)}
+	}
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			withProps("none"),
+			withProps("csv-without-min", property.MustBuild(&property.CSVMetadata{DisplayName: "Foo"})),
+			withProps("csv-with-min", property.MustBuild(&property.CSVMetadata{MinKubeVersion: "1.25.0"})),
+			withProps("package-required", property.MustBuildPackageRequired("bar", ">=1.0.0")),
+			withProps("custom", property.Property{Type: "example.com/kube-version", Value: json.RawMessage(`">=1.25"`)}),
+		},
+	}
+
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "none", Message: `no kube version constraint: missing property of type "olm.csv.metadata" or "olm.package.required"`},
+		{Package: "foo", Bundle: "csv-without-min", Message: `no kube version constraint: missing property of type "olm.csv.metadata" or "olm.package.required"`},
+		{Package: "foo", Bundle: "custom", Message: `no kube version constraint: missing property of type "olm.csv.metadata" or "olm.package.required"`},
+	}, LintKubeVersionConstraints(cfg))
+
+	issues := LintKubeVersionConstraints(cfg, "example.com/kube-version")
+	require.Len(t, issues, 4)
+	require.Equal(t, `package "foo", bundle "none": no kube version constraint: missing property of type "example.com/kube-version"`, issues[0].String())
+}
+
+func TestLintUnreferencedBundles(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.1.0")}),
+			// An entry in another package does not reference foo's bundle.
+			newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("foo", "0.2.0")}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.3.0"),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: testBundleName("foo", "0.2.0"), Message: "not an entry in any channel"},
+		{Package: "foo", Bundle: testBundleName("foo", "0.3.0"), Message: "not an entry in any channel"},
+	}, LintUnreferencedBundles(cfg))
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: testBundleName("foo", "0.2.0"), Message: "not an entry in any channel"},
+	}, LintUnreferencedBundles(cfg, testBundleName("foo", "0.3.0")))
+}
+
+func TestLintChannelNames(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable"),
+			newTestChannel("foo", "candidate"),
+			newTestChannel("foo", "v1.2"),
+			newTestChannel("foo", "v1.2-beta"),
+			newTestChannel("bar", "stable-v1"),
+		},
+	}
+
+	issues, err := LintChannelNames(cfg)
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	issues, err = LintChannelNames(cfg, "stable", "candidate", `v\d+\.\d+`)
+	require.NoError(t, err)
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "v1.2-beta", Message: "channel name does not match any allowed pattern"},
+		{Package: "bar", Channel: "stable-v1", Message: "channel name does not match any allowed pattern"},
+	}, issues)
+
+	_, err = LintChannelNames(cfg, "stable", "v[")
+	require.EqualError(t, err, "invalid channel name pattern \"v[\": error parsing regexp: missing closing ]: `[`")
+}
+
+func TestLintDanglingReferences(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.0.1", Skips: []string{"foo.v0.0.2"}},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+			),
+		},
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0"},
+			{Schema: SchemaBundle, Package: "bar", Name: "foo.v0.3.0"},
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "stable", Bundle: "foo.v0.1.0", Message: `replaces "foo.v0.0.1", which is not a bundle in the package`},
+		{Package: "foo", Channel: "stable", Bundle: "foo.v0.3.0", Message: "entry does not have a bundle in the package"},
+	}, LintDanglingReferences(cfg))
+}
+
+func TestLintReplacesCycles(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.3.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.3.0"},
+				ChannelEntry{Name: "foo.v0.5.0", Replaces: "foo.v0.5.0"},
+			),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "fast", Bundle: "foo.v0.1.0", Message: "replaces chain contains a cycle: foo.v0.1.0 -> foo.v0.3.0 -> foo.v0.2.0 -> foo.v0.1.0"},
+		{Package: "foo", Channel: "fast", Bundle: "foo.v0.5.0", Message: "replaces chain contains a cycle: foo.v0.5.0 -> foo.v0.5.0"},
+	}, LintReplacesCycles(cfg))
+}
+
+func TestLintChannelHeads(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Skips: []string{"foo.v0.2.0"}},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0"},
+			),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "fast", Message: `channel has 2 heads: "foo.v0.2.0", "foo.v0.3.0"`},
+	}, LintChannelHeads(cfg, 0))
+	require.Empty(t, LintChannelHeads(cfg, 2))
+}
+
+func TestLintSkipsHead(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.0"}},
+			),
+			// The usual pattern: 0.3.0 replaces 0.1.0 and skips 0.2.0, which
+			// is not the head even though nothing replaces it.
+			newTestChannel("foo", "candidate",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.2.0"}},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.3.0"}},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0"},
+			),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.3.0"),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "fast", Bundle: "foo.v0.2.0", Message: `skips "foo.v0.3.0", which is newer than the channel head "foo.v0.2.0"`},
+	}, LintSkipsHead(cfg))
+}
+
+func TestLintUnpinnedImages(t *testing.T) {
+	const digest = "@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/foo-bundle" + digest, RelatedImages: []RelatedImage{
+				{Name: "operator", Image: "quay.io/example/foo:v0.1.0"},
+				{Name: "proxy", Image: "quay.io/example/proxy:latest"},
+			}},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0", Image: "quay.io/example/foo-bundle" + digest, RelatedImages: []RelatedImage{
+				{Name: "operator", Image: "quay.io/example/foo" + digest},
+			}},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.3.0", Image: "quay.io/example/foo-bundle:v0.3.0"},
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `images not pinned by digest: "quay.io/example/foo:v0.1.0", "quay.io/example/proxy:latest"`},
+		{Package: "foo", Bundle: "foo.v0.3.0", Message: `images not pinned by digest: "quay.io/example/foo-bundle:v0.3.0"`},
+	}, LintUnpinnedImages(cfg))
+}
+
+func TestLintNonCanonicalOthers(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Others: []Meta{
+			{Schema: "custom.1", Package: "foo", Name: "canonical", Blob: json.RawMessage(`{"a":1,"name":"canonical","package":"foo","schema":"custom.1"}`)},
+			{Schema: "custom.1", Package: "foo", Name: "unsorted", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo","name":"unsorted"}`)},
+			{Schema: "custom.1", Package: "foo", Name: "pretty", Blob: json.RawMessage("{\n  \"name\": \"pretty\"\n}")},
+			{Schema: "custom.1", Name: "newline", Blob: json.RawMessage("{\"name\":\"newline\"}\n")},
+			{Schema: "custom.1", Name: "invalid", Blob: json.RawMessage(`{`)},
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Message: `custom.1 object "unsorted" is not in canonical JSON form`},
+		{Package: "foo", Message: `custom.1 object "pretty" is not in canonical JSON form`},
+		{Message: `custom.1 object "newline" is not in canonical JSON form`},
+		{Message: `custom.1 object "invalid" is not valid JSON: unexpected EOF`},
+	}, LintNonCanonicalOthers(cfg))
+}
+
+func TestCheckChannelConnectivity(t *testing.T) {
+	bundles := []Bundle{
+		{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.0.1"},
+		{Schema: SchemaBundle, Package: "bar", Name: "bar.v0.1.0"},
+	}
+
+	connected := newTestChannel("foo", "stable",
+		ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.0.1"},
+		ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+		ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0", Skips: []string{"foo.v0.1.0", "foo.v0.0.0"}},
+	)
+	require.Empty(t, CheckChannelConnectivity(connected, bundles))
+
+	broken := newTestChannel("foo", "fast",
+		ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.3.0"},
+		ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+		ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+		ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.3.0"},
+		ChannelEntry{Name: "foo.v1.0.0", Replaces: "bar.v0.1.0"},
+		ChannelEntry{Name: "foo.v1.1.0", Skips: []string{"foo.v1.1.0"}},
+	)
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Channel: "fast", Message: `upgrade graph is disconnected: component 1 of 3 contains "foo.v0.1.0", "foo.v0.2.0", "foo.v0.3.0", "foo.v0.4.0"`},
+		{Package: "foo", Channel: "fast", Message: `upgrade graph is disconnected: component 2 of 3 contains "foo.v1.0.0"`},
+		{Package: "foo", Channel: "fast", Message: `upgrade graph is disconnected: component 3 of 3 contains "foo.v1.1.0"`},
+		{Package: "foo", Channel: "fast", Message: `upgrade graph contains a cycle between "foo.v0.1.0", "foo.v0.2.0", "foo.v0.3.0"`},
+		{Package: "foo", Channel: "fast", Message: `upgrade graph contains a cycle between "foo.v1.1.0"`},
+	}, CheckChannelConnectivity(broken, bundles))
+}
+
+func TestLintUnreachableFromDefaultHead(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Packages: []Package{
+			newTestPackage("foo", "stable", svgSmallCircle),
+			newTestPackage("bar", "missing", svgSmallCircle),
+		},
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.4.0"), Replaces: testBundleName("foo", "0.2.0"), SkipRange: "<0.2.0"},
+			),
+			newTestChannel("foo", "legacy",
+				ChannelEntry{Name: testBundleName("foo", "0.1.0"), Skips: []string{testBundleName("foo", "0.0.1")}},
+				ChannelEntry{Name: testBundleName("foo", "0.1.5")},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: testBundleName("foo", "0.5.0"), Replaces: testBundleName("foo", "0.4.0")},
+			),
+			newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "0.1.0")}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.0.1"),
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.1.5"),
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.3.0"),
+			newTestBundle("foo", "0.4.0"),
+			newTestBundle("foo", "0.5.0"),
+			newTestBundle("bar", "0.1.0"),
+		},
+	}
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: testBundleName("foo", "0.3.0"), Message: `cannot upgrade to the head of default channel "stable"`},
+		{Package: "foo", Bundle: testBundleName("foo", "0.5.0"), Message: `cannot upgrade to the head of default channel "stable"`},
+	}, LintUnreachableFromDefaultHead(cfg))
+}
+
+func TestCheckPropertyCardinality(t *testing.T) {
+	b := newTestBundle("foo", "0.1.0", func(b *Bundle) {
+		b.Properties = append(b.Properties,
+			property.MustBuildPackage("foo", "0.1.1"),
+			property.Property{Type: property.TypeCSVMetadata, Value: json.RawMessage(`{}`)},
+			property.Property{Type: property.TypeCSVMetadata, Value: json.RawMessage(`{}`)},
+			property.Property{Type: "example.com/owner", Value: json.RawMessage(`"a"`)},
+			property.Property{Type: "example.com/owner", Value: json.RawMessage(`"b"`)},
+		)
+	})
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `has 2 "olm.csv.metadata" properties, expected at most 1`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `has 2 "olm.package" properties, expected exactly 1`},
+	}, CheckPropertyCardinality(b))
+
+	require.Equal(t, []LintIssue{
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `has 2 "example.com/owner" properties, expected at most 1`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `has 0 "example.com/team" properties, expected at least 1`},
+		{Package: "foo", Bundle: "foo.v0.1.0", Message: `has 2 "olm.package" properties, expected exactly 1`},
+	}, CheckPropertyCardinality(b,
+		CardinalityRule{Type: "example.com/owner", Max: 1},
+		CardinalityRule{Type: "example.com/team", Min: 1},
+		CardinalityRule{Type: property.TypeCSVMetadata},
+	))
+
+	require.Empty(t, CheckPropertyCardinality(newTestBundle("foo", "0.1.0")))
+}
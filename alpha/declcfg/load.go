@@ -1,20 +1,25 @@
 package declcfg
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	pathpkg "path"
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"github.com/joelanford/ignore"
 	"github.com/operator-framework/api/pkg/operators"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/operator-framework/operator-registry/alpha/property"
@@ -47,10 +52,56 @@ func WalkMetasFS(root fs.FS, walkFn WalkMetasFSFunc) error {
 type WalkMetasReaderFunc func(meta *Meta, err error) error
 
 func WalkMetasReader(r io.Reader, walkFn WalkMetasReaderFunc) error {
-	dec := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	return walkMetasReader(r, false, detectFormat, walkFn)
+}
+
+// documentFormat is the format of the documents read by walkMetasReader.
+type documentFormat int
+
+const (
+	// detectFormat detects whether the documents are JSON or YAML from
+	// their content.
+	detectFormat documentFormat = iota
+	jsonFormat
+	yamlFormat
+)
+
+// walkMetasReader is like WalkMetasReader, but if keepOriginal is set, each
+// meta's Blob holds the JSON bytes of the object exactly as they were read.
+// The documents in r are decoded as the given format.
+//
+// In addition to a stream of YAML or JSON documents, r may contain a single
+// JSON array whose elements are the objects, which is detected by a leading
+// '['. A stream of objects never starts with '[', so the two forms cannot be
+// confused. Arrays are not detected in YAML documents.
+func walkMetasReader(r io.Reader, keepOriginal bool, format documentFormat, walkFn WalkMetasReaderFunc) error {
+	br := bufio.NewReader(r)
+	if format != yamlFormat && isJSONArray(br) {
+		return walkMetasArray(br, keepOriginal, walkFn)
+	}
+	var dec interface{ Decode(interface{}) error }
+	switch format {
+	case jsonFormat:
+		dec = json.NewDecoder(br)
+	case yamlFormat:
+		dec = yaml.NewYAMLToJSONDecoder(br)
+	default:
+		dec = yaml.NewYAMLOrJSONDecoder(br, 4096)
+	}
 	for {
 		var in Meta
-		if err := dec.Decode(&in); err != nil {
+		var err error
+		if keepOriginal {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err == nil {
+				if err = json.Unmarshal(raw, &in); err == nil {
+					in.Blob = raw
+				}
+			}
+		} else {
+			err = dec.Decode(&in)
+		}
+		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
@@ -64,6 +115,55 @@ func WalkMetasReader(r io.Reader, walkFn WalkMetasReaderFunc) error {
 	return nil
 }
 
+// isJSONArray reports whether the first non-whitespace byte of br is '['.
+// It does not consume anything from br.
+func isJSONArray(br *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		peek, err := br.Peek(n)
+		if len(peek) < n {
+			return false
+		}
+		switch peek[n-1] {
+		case ' ', '\t', '\r', '\n':
+			if err != nil {
+				return false
+			}
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// walkMetasArray calls walkFn for each element of the JSON array read from r.
+// Errors for an element, including those returned by walkFn, are reported
+// with the element's index.
+func walkMetasArray(r io.Reader, keepOriginal bool, walkFn WalkMetasReaderFunc) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return walkFn(nil, err)
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return walkFn(nil, fmt.Errorf("parse array: %s", FormatUnmarshalError(data, err)))
+	}
+	for i, elem := range elems {
+		var in Meta
+		if err := json.Unmarshal(elem, &in); err != nil {
+			return walkFn(nil, fmt.Errorf("array index %d: %s", i, FormatUnmarshalError(elem, err)))
+		}
+		if keepOriginal {
+			in.Blob = elem
+		}
+		if err := walkFn(&in, nil); err != nil {
+			return fmt.Errorf("array index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
 type WalkFunc func(path string, cfg *DeclarativeConfig, err error) error
 
 // WalkFS walks root using a gitignore-style filename matcher to skip files
@@ -110,7 +210,25 @@ func walkFiles(root fs.FS, fn func(root fs.FS, path string, err error) error) er
 }
 
 type LoadOptions struct {
-	concurrency int
+	concurrency         int
+	resolvePropertyRefs bool
+	strictSchemas       sets.Set[string]
+	lazyRuntimeFields   bool
+	othersBlobFormat    OthersBlobFormat
+	maxBundles          int
+	maxFileBytes        int64
+	oversizedFileAction OversizedFileAction
+
+	// documentFormat is the format of the documents read by loadReader.
+	documentFormat documentFormat
+
+	// bundleCount counts the bundles parsed so far by a single load, across
+	// all of the files it reads.
+	bundleCount *atomic.Int64
+
+	// fileHook, if set, is called by LoadFS with each file's config before
+	// it is merged, and may modify it. It is called concurrently.
+	fileHook func(path string, cfg *DeclarativeConfig)
 }
 
 type LoadOption func(*LoadOptions)
@@ -121,6 +239,129 @@ func WithConcurrency(concurrency int) LoadOption {
 	}
 }
 
+// WithPropertyRefs enables or disables inlining of property values that
+// reference an external file. When enabled, a property value of the form
+//
+//	{"$ref": "path/to/value.json"}
+//
+// is replaced by the contents of the referenced file, which must contain valid
+// JSON. References are resolved relative to the directory of the file that
+// contains them and may not point outside of the filesystem being loaded.
+// Referenced files are not meta objects, so they should be excluded from the
+// catalog with a .indexignore file. Property references are disabled by
+// default.
+func WithPropertyRefs(resolve bool) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.resolvePropertyRefs = resolve
+	}
+}
+
+// StrictSchemas causes loading to fail when an object's schema is not
+// olm.package, olm.channel, olm.bundle, or one of the provided extension
+// schemas. By default, objects with any other schema are loaded into Others,
+// which also lets misspelled schemas go unnoticed.
+func StrictSchemas(extensions ...string) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.strictSchemas = sets.New[string](SchemaPackage, SchemaChannel, SchemaBundle)
+		opts.strictSchemas.Insert(extensions...)
+	}
+}
+
+// WithLazyRuntimeFields enables or disables lazy population of the bundle
+// CsvJSON and Objects fields, which are only needed to serve the GRPC API.
+// By default, every bundle's properties are parsed while loading so that its
+// olm.bundle.object properties can be decoded into those fields. When lazy
+// population is enabled, no property value is decoded while loading:
+// properties are left as raw JSON until a caller parses them, for example
+// with property.Parse, and the fields are only populated when
+// Bundle.PopulateRuntimeFields is called, which ConvertToModel and
+// WriteBinary do automatically. This reduces memory and CPU use for
+// analysis-only loads. Errors from malformed olm.bundle.object properties
+// are also deferred until the fields are populated.
+func WithLazyRuntimeFields(lazy bool) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.lazyRuntimeFields = lazy
+	}
+}
+
+// OthersBlobFormat controls how the blobs of objects loaded into
+// DeclarativeConfig.Others are stored.
+type OthersBlobFormat int
+
+const (
+	// OthersBlobDefault stores blobs re-encoded from their decoded form,
+	// which sorts object keys but may alter numbers and adds a trailing
+	// newline.
+	OthersBlobDefault OthersBlobFormat = iota
+
+	// OthersBlobCanonical stores blobs in canonical compact JSON form,
+	// with sorted object keys, no insignificant whitespace, and numbers
+	// preserved exactly. This keeps catalog output stable regardless of
+	// how the input was formatted.
+	OthersBlobCanonical
+
+	// OthersBlobOriginal stores the JSON bytes of each object exactly as
+	// they were read, for strict round-tripping. Objects read from YAML are
+	// stored as their JSON conversion.
+	OthersBlobOriginal
+)
+
+// WithOthersBlobFormat sets how the blobs of objects in Others are stored.
+// Use LintNonCanonicalOthers to find blobs that are not in canonical form.
+func WithOthersBlobFormat(format OthersBlobFormat) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.othersBlobFormat = format
+	}
+}
+
+// MaxBundles limits the number of bundles a load may contain. Once more than
+// n bundles have been read, loading stops with an error without parsing the
+// rest of the catalog, which bounds the resources used by untrusted input.
+// By default, the number of bundles is not limited.
+func MaxBundles(n int) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.maxBundles = n
+	}
+}
+
+// OversizedFileAction is what a load does with a file that exceeds the
+// limit set by MaxFileBytes.
+type OversizedFileAction int
+
+const (
+	// OversizedFileError fails the load.
+	OversizedFileError OversizedFileAction = iota
+
+	// OversizedFileSkip ignores the file, as if it were not in the
+	// filesystem.
+	OversizedFileSkip
+)
+
+// MaxFileBytes limits the size of each file a load reads to n bytes, which
+// bounds the memory used by untrusted catalogs containing pathologically
+// large files. A file whose size, as reported by the filesystem, exceeds n
+// is handled according to action without being read. Files are also
+// counted as they are read, in case the reported size is wrong, and a file
+// that turns out to be too large is handled the same way. By default, file
+// size is not limited.
+func MaxFileBytes(n int64, action OversizedFileAction) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.maxFileBytes = n
+		opts.oversizedFileAction = action
+	}
+}
+
+func newLoadOptions(opts []LoadOption) LoadOptions {
+	options := LoadOptions{
+		concurrency: runtime.NumCPU(),
+		bundleCount: &atomic.Int64{},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 // LoadFS loads a declarative config from the provided root FS. LoadFS walks the
 // filesystem from root and uses a gitignore-style filename matcher to skip files
 // that match patterns found in .indexignore files found throughout the filesystem.
@@ -131,12 +372,7 @@ func LoadFS(ctx context.Context, root fs.FS, opts ...LoadOption) (*DeclarativeCo
 		return nil, fmt.Errorf("no declarative config filesystem provided")
 	}
 
-	options := LoadOptions{
-		concurrency: runtime.NumCPU(),
-	}
-	for _, opt := range opts {
-		opt(&options)
-	}
+	options := newLoadOptions(opts)
 
 	var (
 		fcfg     = &DeclarativeConfig{}
@@ -162,7 +398,7 @@ func LoadFS(ctx context.Context, root fs.FS, opts ...LoadOption) (*DeclarativeCo
 		wg.Add(1)
 		eg.Go(func() error {
 			defer wg.Done()
-			return parsePaths(ctx, root, pathChan, cfgChan)
+			return parsePaths(ctx, root, pathChan, cfgChan, options)
 		})
 	}
 
@@ -182,6 +418,48 @@ func LoadFS(ctx context.Context, root fs.FS, opts ...LoadOption) (*DeclarativeCo
 	return fcfg, nil
 }
 
+// LoadFSGlob loads a declarative config from the files in fsys that match at
+// least one of patterns, using the syntax of path.Match. Files are loaded in
+// lexical order of their paths, so the result is deterministic. Unlike
+// LoadFS, .indexignore files are not consulted; non-matching files are
+// simply never read.
+func LoadFSGlob(fsys fs.FS, patterns []string, opts ...LoadOption) (*DeclarativeConfig, error) {
+	if fsys == nil {
+		return nil, fmt.Errorf("no declarative config filesystem provided")
+	}
+	options := newLoadOptions(opts)
+
+	paths := sets.New[string]()
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("glob %q: %v", pattern, err)
+		}
+		for _, match := range matches {
+			info, err := fs.Stat(fsys, match)
+			if err != nil {
+				return nil, fmt.Errorf("stat %q: %v", match, err)
+			}
+			if !info.IsDir() {
+				paths.Insert(match)
+			}
+		}
+	}
+
+	fcfg := &DeclarativeConfig{}
+	for _, path := range sets.List(paths) {
+		cfg, err := loadFile(fsys, path, options)
+		if err != nil {
+			return nil, fmt.Errorf("load %q: %v", path, err)
+		}
+		fcfg.Packages = append(fcfg.Packages, cfg.Packages...)
+		fcfg.Channels = append(fcfg.Channels, cfg.Channels...)
+		fcfg.Bundles = append(fcfg.Bundles, cfg.Bundles...)
+		fcfg.Others = append(fcfg.Others, cfg.Others...)
+	}
+	return fcfg, nil
+}
+
 func sendPaths(ctx context.Context, root fs.FS, pathChan chan<- string) error {
 	defer close(pathChan)
 	return walkFiles(root, func(_ fs.FS, path string, err error) error {
@@ -197,7 +475,7 @@ func sendPaths(ctx context.Context, root fs.FS, pathChan chan<- string) error {
 	})
 }
 
-func parsePaths(ctx context.Context, root fs.FS, pathChan <-chan string, cfgChan chan<- *DeclarativeConfig) error {
+func parsePaths(ctx context.Context, root fs.FS, pathChan <-chan string, cfgChan chan<- *DeclarativeConfig, options LoadOptions) error {
 	for {
 		select {
 		case <-ctx.Done(): // don't block on receiving from pathChan
@@ -206,10 +484,13 @@ func parsePaths(ctx context.Context, root fs.FS, pathChan <-chan string, cfgChan
 			if !ok {
 				return nil
 			}
-			cfg, err := LoadFile(root, path)
+			cfg, err := loadFile(root, path, options)
 			if err != nil {
 				return err
 			}
+			if options.fileHook != nil {
+				options.fileHook(path, cfg)
+			}
 			select {
 			case cfgChan <- cfg:
 			case <-ctx.Done(): // don't block on sending to cfgChan
@@ -238,28 +519,90 @@ func mergeCfgs(ctx context.Context, cfgChan <-chan *DeclarativeConfig, fcfg *Dec
 }
 
 func readBundleObjects(bundles []Bundle, root fs.FS, path string) error {
-	for bi, b := range bundles {
-		props, err := property.Parse(b.Properties)
+	for i := range bundles {
+		if err := readObjects(&bundles[i], root, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readObjects(b *Bundle, root fs.FS, path string) error {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return fmt.Errorf("package %q, bundle %q: parse properties: %v", b.Package, b.Name, err)
+	}
+	for oi, obj := range props.BundleObjects {
+		objID := fmt.Sprintf(" %q", obj.GetRef())
+		if !obj.IsRef() {
+			objID = fmt.Sprintf("[%d]", oi)
+		}
+
+		d, err := obj.GetData(root, filepath.Dir(path))
 		if err != nil {
-			return fmt.Errorf("package %q, bundle %q: parse properties: %v", b.Package, b.Name, err)
+			return fmt.Errorf("package %q, bundle %q: get data for bundle object%s: %v", b.Package, b.Name, objID, err)
 		}
-		for oi, obj := range props.BundleObjects {
-			objID := fmt.Sprintf(" %q", obj.GetRef())
-			if !obj.IsRef() {
-				objID = fmt.Sprintf("[%d]", oi)
-			}
+		objJson, err := yaml.ToJSON(d)
+		if err != nil {
+			return fmt.Errorf("package %q, bundle %q: convert object%s to JSON: %v", b.Package, b.Name, objID, err)
+		}
+		b.Objects = append(b.Objects, string(objJson))
+	}
+	b.CsvJSON = extractCSV(b.Objects)
+	return nil
+}
 
-			d, err := obj.GetData(root, filepath.Dir(path))
-			if err != nil {
-				return fmt.Errorf("package %q, bundle %q: get data for bundle object%s: %v", b.Package, b.Name, objID, err)
-			}
-			objJson, err := yaml.ToJSON(d)
-			if err != nil {
-				return fmt.Errorf("package %q, bundle %q: convert object%s to JSON: %v", b.Package, b.Name, objID, err)
-			}
-			bundles[bi].Objects = append(bundles[bi].Objects, string(objJson))
+// pendingBundleObjects records where a lazily loaded bundle was loaded from,
+// so that its olm.bundle.object references can be resolved later.
+type pendingBundleObjects struct {
+	root fs.FS
+	path string
+}
+
+func hasBundleObjects(b Bundle) bool {
+	for _, p := range b.Properties {
+		if p.Type == property.TypeBundleObject {
+			return true
+		}
+	}
+	return false
+}
+
+// PopulateRuntimeFields computes b.CsvJSON and b.Objects from b's
+// olm.bundle.object properties. If b was loaded with WithLazyRuntimeFields,
+// object references are resolved against the filesystem b was loaded from.
+// Otherwise, if b.Objects is empty, the fields are computed from inline
+// object data, and an error is returned for any object reference, since
+// there is no filesystem to resolve it against. PopulateRuntimeFields does
+// nothing if the fields are already populated.
+func (b *Bundle) PopulateRuntimeFields() error {
+	if b.pendingObjects != nil {
+		if err := readObjects(b, b.pendingObjects.root, b.pendingObjects.path); err != nil {
+			return err
+		}
+		b.pendingObjects = nil
+		return nil
+	}
+	if len(b.Objects) > 0 || !hasBundleObjects(*b) {
+		return nil
+	}
+	return readObjects(b, noRefsFS{}, ".")
+}
+
+// noRefsFS is used to populate the runtime fields of bundles that were not
+// loaded from a filesystem.
+type noRefsFS struct{}
+
+func (noRefsFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("bundle was not loaded from a filesystem")}
+}
+
+// PopulateBundleRuntimeFields calls PopulateRuntimeFields on every bundle in cfg.
+func PopulateBundleRuntimeFields(cfg *DeclarativeConfig) error {
+	for i := range cfg.Bundles {
+		if err := cfg.Bundles[i].PopulateRuntimeFields(); err != nil {
+			return err
 		}
-		bundles[bi].CsvJSON = extractCSV(bundles[bi].Objects)
 	}
 	return nil
 }
@@ -279,10 +622,20 @@ func extractCSV(objs []string) string {
 
 // LoadReader reads yaml or json from the passed in io.Reader and unmarshals it into a DeclarativeConfig struct.
 // Path references will not be de-referenced so callers are responsible for de-referencing if necessary.
+//
+// YAML anchors, aliases, and merge keys are expanded as each object is
+// decoded, so they can be used to share fragments such as property values.
+// As in YAML itself, anchors are scoped to a single document and cannot be
+// referenced from other documents in the same stream. Anchors are not
+// preserved: configs are always written out in fully-expanded form.
 func LoadReader(r io.Reader) (*DeclarativeConfig, error) {
+	return loadReader(r, LoadOptions{})
+}
+
+func loadReader(r io.Reader, options LoadOptions) (*DeclarativeConfig, error) {
 	cfg := &DeclarativeConfig{}
 
-	if err := WalkMetasReader(r, func(in *Meta, err error) error {
+	if err := walkMetasReader(r, options.othersBlobFormat != OthersBlobDefault, options.documentFormat, func(in *Meta, err error) error {
 		if err != nil {
 			return err
 		}
@@ -300,6 +653,9 @@ func LoadReader(r io.Reader) (*DeclarativeConfig, error) {
 			}
 			cfg.Channels = append(cfg.Channels, c)
 		case SchemaBundle:
+			if options.maxBundles > 0 && options.bundleCount != nil && options.bundleCount.Add(1) > int64(options.maxBundles) {
+				return fmt.Errorf("catalog exceeds the maximum of %d bundles: limit exceeded while streaming, so the rest of the catalog was not parsed", options.maxBundles)
+			}
 			var b Bundle
 			if err := json.Unmarshal(in.Blob, &b); err != nil {
 				return fmt.Errorf("parse bundle: %v", err)
@@ -308,6 +664,13 @@ func LoadReader(r io.Reader) (*DeclarativeConfig, error) {
 		case "":
 			return fmt.Errorf("object '%s' is missing root schema field", string(in.Blob))
 		default:
+			if options.othersBlobFormat == OthersBlobCanonical {
+				blob, err := canonicalizeJSON(in.Blob)
+				if err != nil {
+					return fmt.Errorf("canonicalize %s object %q: %v", in.Schema, in.Name, err)
+				}
+				in.Blob = blob
+			}
 			cfg.Others = append(cfg.Others, *in)
 		}
 		return nil
@@ -319,21 +682,162 @@ func LoadReader(r io.Reader) (*DeclarativeConfig, error) {
 
 // LoadFile will unmarshall declarative config components from a single filename provided in 'path'
 // located at a filesystem hierarchy 'root'
-func LoadFile(root fs.FS, path string) (*DeclarativeConfig, error) {
+func LoadFile(root fs.FS, path string, opts ...LoadOption) (*DeclarativeConfig, error) {
+	return loadFile(root, path, newLoadOptions(opts))
+}
+
+func loadFile(root fs.FS, path string, options LoadOptions) (*DeclarativeConfig, error) {
 	file, err := root.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	cfg, err := LoadReader(file)
+	var (
+		r  io.Reader = file
+		lr *limitedReader
+	)
+	if options.maxFileBytes > 0 {
+		if info, err := file.Stat(); err == nil && info.Size() > options.maxFileBytes {
+			return oversizedFile(path, info.Size(), options)
+		}
+		lr = &limitedReader{r: file, remaining: options.maxFileBytes}
+		r = lr
+	}
+
+	cfg, err := loadReader(r, options)
+	if lr != nil && lr.exceeded {
+		return oversizedFile(path, -1, options)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if options.strictSchemas != nil {
+		for _, o := range cfg.Others {
+			if !options.strictSchemas.Has(o.Schema) {
+				return nil, fmt.Errorf("unknown schema %q for object %q in package %q", o.Schema, o.Name, o.Package)
+			}
+		}
+	}
+
+	if options.resolvePropertyRefs {
+		if err := resolvePropertyRefs(cfg, root, path); err != nil {
+			return nil, fmt.Errorf("resolve property references: %v", err)
+		}
+	}
+
+	if options.lazyRuntimeFields {
+		for i, b := range cfg.Bundles {
+			if hasBundleObjects(b) {
+				cfg.Bundles[i].pendingObjects = &pendingBundleObjects{root: root, path: path}
+			}
+		}
+		return cfg, nil
+	}
+
 	if err := readBundleObjects(cfg.Bundles, root, path); err != nil {
 		return nil, fmt.Errorf("read bundle objects: %v", err)
 	}
 
 	return cfg, nil
 }
+
+// propertyRef is the form of a property value that refers to a file
+// containing the actual value.
+type propertyRef struct {
+	Ref string `json:"$ref"`
+}
+
+func resolvePropertyRefs(cfg *DeclarativeConfig, root fs.FS, path string) error {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	for _, p := range cfg.Packages {
+		if err := resolvePropertyRefsInSlice(p.Properties, root, dir); err != nil {
+			return fmt.Errorf("package %q: %v", p.Name, err)
+		}
+	}
+	for _, c := range cfg.Channels {
+		if err := resolvePropertyRefsInSlice(c.Properties, root, dir); err != nil {
+			return fmt.Errorf("package %q, channel %q: %v", c.Package, c.Name, err)
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if err := resolvePropertyRefsInSlice(b.Properties, root, dir); err != nil {
+			return fmt.Errorf("package %q, bundle %q: %v", b.Package, b.Name, err)
+		}
+	}
+	return nil
+}
+
+func resolvePropertyRefsInSlice(props []property.Property, root fs.FS, dir string) error {
+	for i, p := range props {
+		ref, ok := parsePropertyRef(p.Value)
+		if !ok {
+			continue
+		}
+		refPath := pathpkg.Join(dir, ref)
+		if pathpkg.IsAbs(ref) || !fs.ValidPath(refPath) {
+			return fmt.Errorf("property[%d] of type %q: reference %q must be a relative path within the catalog", i, p.Type, ref)
+		}
+		data, err := fs.ReadFile(root, refPath)
+		if err != nil {
+			return fmt.Errorf("property[%d] of type %q: read reference %q: %v", i, p.Type, ref, err)
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, data); err != nil {
+			return fmt.Errorf("property[%d] of type %q: reference %q is not valid JSON: %v", i, p.Type, ref, err)
+		}
+		props[i].Value = buf.Bytes()
+	}
+	return nil
+}
+
+// parsePropertyRef returns the referenced path if value is an object whose
+// only field is "$ref".
+func parsePropertyRef(value json.RawMessage) (string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil || len(fields) != 1 {
+		return "", false
+	}
+	var ref propertyRef
+	if err := json.Unmarshal(value, &ref); err != nil || ref.Ref == "" {
+		return "", false
+	}
+	return ref.Ref, true
+}
+
+// oversizedFile returns the result of loading a file at path that exceeds
+// the limit set by MaxFileBytes. A negative size means the size is unknown.
+func oversizedFile(path string, size int64, options LoadOptions) (*DeclarativeConfig, error) {
+	if options.oversizedFileAction == OversizedFileSkip {
+		return &DeclarativeConfig{}, nil
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("file %q exceeds the maximum size of %d bytes", path, options.maxFileBytes)
+	}
+	return nil, fmt.Errorf("file %q is %d bytes, which exceeds the maximum size of %d bytes", path, size, options.maxFileBytes)
+}
+
+// limitedReader reads from r until more than remaining bytes have been
+// read, at which point it sets exceeded and returns an error.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errors.New("file size limit exceeded")
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return 0, errors.New("file size limit exceeded")
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -1,6 +1,7 @@
 package declcfg_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -18,6 +19,22 @@ import (
 	"github.com/operator-framework/operator-registry/alpha/property"
 )
 
+func BenchmarkLoadBinary(b *testing.B) {
+	fbc := generateFBC(b, 300, 450, 3000)
+	var buf bytes.Buffer
+	if err := declcfg.WriteBinary(*fbc, &buf); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := declcfg.LoadBinary(bytes.NewReader(data)); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
 func BenchmarkLoadFS(b *testing.B) {
 	fbc := generateFBC(b, 300, 450, 3000)
 	b.ResetTimer()
@@ -296,3 +313,42 @@ func genCsv(pkgName, ver string) v1alpha1.ClusterServiceVersion {
 	}
 	return csv
 }
+
+func BenchmarkBundlesEqual(b *testing.B) {
+	fbc := generateFBC(b, 300, 450, 3000)
+	other := copyBundles(fbc.Bundles)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := range fbc.Bundles {
+			if !declcfg.BundlesEqual(fbc.Bundles[j], other[j]) {
+				b.Fatalf("bundle %q: expected copies to be equal", fbc.Bundles[j].Name)
+			}
+		}
+	}
+}
+
+func BenchmarkBundleHashCacheEqual(b *testing.B) {
+	fbc := generateFBC(b, 300, 450, 3000)
+	other := copyBundles(fbc.Bundles)
+	cache := declcfg.NewBundleHashCache()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := range fbc.Bundles {
+			if !cache.Equal(&fbc.Bundles[j], &other[j]) {
+				b.Fatalf("bundle %q: expected copies to be equal", fbc.Bundles[j].Name)
+			}
+		}
+	}
+}
+
+func copyBundles(in []declcfg.Bundle) []declcfg.Bundle {
+	out := make([]declcfg.Bundle, len(in))
+	for i, b := range in {
+		out[i] = b
+		out[i].Properties = append([]property.Property{}, b.Properties...)
+		out[i].RelatedImages = append([]declcfg.RelatedImage{}, b.RelatedImages...)
+	}
+	return out
+}
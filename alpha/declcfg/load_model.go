@@ -0,0 +1,115 @@
+package declcfg
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/operator-registry/alpha/model"
+)
+
+// LoadModelFS loads the declarative config in fsys, as LoadFS does with
+// opts, and converts it to a model, as ConvertToModel does, without first
+// building the full DeclarativeConfig. Each bundle is converted as soon as
+// its file is loaded, concurrently with the other files, and only the
+// converted fields are kept. Objects in Others are not needed by the model,
+// so they are dropped. Packages and channels are kept until every file has
+// been loaded, since a package's objects may span several files.
+//
+// If the conversion fails, the files of the objects that caused it are
+// determined where possible: objects defined in more than one file,
+// channels and bundles of an unknown package, and bundles that could not
+// be converted, for example because their olm.package property is invalid,
+// are reported with their file paths. Otherwise, the conversion error is
+// returned as is.
+func LoadModelFS(ctx context.Context, fsys fs.FS, opts ...LoadOption) (model.Model, error) {
+	var (
+		mu      sync.Mutex
+		origins = map[indexKey][]string{}
+		bundles []modelBundleSource
+	)
+	convert := func(path string, cfg *DeclarativeConfig) {
+		converted := make([]modelBundleSource, 0, len(cfg.Bundles))
+		for _, b := range cfg.Bundles {
+			converted = append(converted, newModelBundleSource(b))
+		}
+		cfg.Bundles = nil
+		cfg.Others = nil
+
+		mu.Lock()
+		defer mu.Unlock()
+		bundles = append(bundles, converted...)
+		for _, p := range cfg.Packages {
+			k := indexKey{SchemaPackage, "", p.Name}
+			origins[k] = append(origins[k], path)
+		}
+		for _, c := range cfg.Channels {
+			k := indexKey{SchemaChannel, c.Package, c.Name}
+			origins[k] = append(origins[k], path)
+		}
+		for _, b := range converted {
+			k := indexKey{SchemaBundle, b.pkg, b.name}
+			origins[k] = append(origins[k], path)
+		}
+	}
+	opts = append(opts[:len(opts):len(opts)], func(o *LoadOptions) { o.fileHook = convert })
+
+	cfg, err := LoadFS(ctx, fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m, err := convertToModel(cfg.Packages, cfg.Channels, bundles)
+	if err != nil {
+		if fileErr := modelErrorsWithFiles(cfg.Channels, bundles, origins); fileErr != nil {
+			return nil, fileErr
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// modelErrorsWithFiles returns the problems with channels and bundles that
+// prevent them from being converted to a model and can be attributed to
+// files, using the files each object was loaded from. Errors are sorted,
+// since the order in which files are loaded is not deterministic.
+func modelErrorsWithFiles(channels []Channel, bundles []modelBundleSource, origins map[indexKey][]string) error {
+	var errs []error
+	for k, paths := range origins {
+		if len(paths) > 1 {
+			id, _ := streamIdentity(&Meta{Schema: k.schema, Package: k.pkg, Name: k.name})
+			sorted := append([]string{}, paths...)
+			sort.Strings(sorted)
+			errs = append(errs, fmt.Errorf("%s is defined more than once, in files %s", id, quotedList(sorted)))
+		}
+	}
+
+	firstPath := func(k indexKey) string {
+		if paths := origins[k]; len(paths) > 0 {
+			return paths[0]
+		}
+		return ""
+	}
+	for _, c := range channels {
+		if _, ok := origins[indexKey{SchemaPackage, "", c.Package}]; !ok {
+			errs = append(errs, fmt.Errorf("file %q: unknown package %q for channel %q", firstPath(indexKey{SchemaChannel, c.Package, c.Name}), c.Package, c.Name))
+		}
+	}
+	for _, b := range bundles {
+		path := firstPath(indexKey{SchemaBundle, b.pkg, b.name})
+		if _, ok := origins[indexKey{SchemaPackage, "", b.pkg}]; !ok {
+			errs = append(errs, fmt.Errorf("file %q: unknown package %q for bundle %q", path, b.pkg, b.name))
+			continue
+		}
+		if b.err != nil {
+			errs = append(errs, fmt.Errorf("file %q: %v", path, b.err))
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return strings.Compare(errs[i].Error(), errs[j].Error()) < 0 })
+	return utilerrors.NewAggregate(errs)
+}
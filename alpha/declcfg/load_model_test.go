@@ -0,0 +1,74 @@
+package declcfg
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadModelFS(t *testing.T) {
+	const (
+		pkgFoo = `{"schema":"olm.package","name":"foo","defaultChannel":"stable"}`
+		chFoo  = `{"schema":"olm.channel","package":"foo","name":"stable","entries":[{"name":"foo.v0.1.0"}]}`
+		bFoo   = `{"schema":"olm.bundle","package":"foo","name":"foo.v0.1.0","image":"foo-bundle:v0.1.0","properties":[{"type":"olm.package","value":{"packageName":"foo","version":"0.1.0"}}]}`
+	)
+	type spec struct {
+		name      string
+		fsys      fstest.MapFS
+		expectErr string
+	}
+	specs := []spec{
+		{
+			name: "Success",
+			fsys: fstest.MapFS{
+				"foo/package.json": {Data: []byte(pkgFoo + chFoo)},
+				"foo/bundles.json": {Data: []byte(bFoo)},
+				"foo/other.json":   {Data: []byte(`{"schema":"custom","package":"foo","name":"x"}`)},
+			},
+		},
+		{
+			name: "Error/DuplicatePackage",
+			fsys: fstest.MapFS{
+				"a.json": {Data: []byte(pkgFoo + chFoo + bFoo)},
+				"b.json": {Data: []byte(pkgFoo)},
+			},
+			expectErr: `package "foo" is defined more than once, in files "a.json", "b.json"`,
+		},
+		{
+			name: "Error/UnknownPackage",
+			fsys: fstest.MapFS{
+				"foo.json": {Data: []byte(pkgFoo + chFoo + bFoo)},
+				"bar.json": {Data: []byte(`{"schema":"olm.channel","package":"bar","name":"stable","entries":[{"name":"bar.v0.1.0"}]}`)},
+			},
+			expectErr: `file "bar.json": unknown package "bar" for channel "stable"`,
+		},
+		{
+			name: "Error/InvalidVersion",
+			fsys: fstest.MapFS{
+				"foo.json":         {Data: []byte(pkgFoo + chFoo)},
+				"bundles/foo.json": {Data: []byte(`{"schema":"olm.bundle","package":"foo","name":"foo.v0.1.0","image":"foo-bundle:v0.1.0","properties":[{"type":"olm.package","value":{"packageName":"foo","version":"bad"}}]}`)},
+			},
+			expectErr: `file "bundles/foo.json": error parsing bundle "foo.v0.1.0" version "bad": No Major.Minor.Patch elements found`,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			m, err := LoadModelFS(context.Background(), s.fsys)
+			if s.expectErr != "" {
+				require.EqualError(t, err, s.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, m, "foo")
+			require.Contains(t, m["foo"].Channels["stable"].Bundles, "foo.v0.1.0")
+
+			cfg, err := LoadFS(context.Background(), s.fsys)
+			require.NoError(t, err)
+			expected, err := ConvertToModel(*cfg)
+			require.NoError(t, err)
+			require.Equal(t, expected, m)
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package declcfg
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"os"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -93,6 +96,88 @@ func TestLoadReader(t *testing.T) {
 	}
 }
 
+func TestLoadReaderYAMLAnchors(t *testing.T) {
+	in := `---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/example/foo-bundle:v0.1.0
+properties: &props
+  - type: olm.package
+    value: {packageName: foo, version: 0.1.0}
+  - type: olm.gvk
+    value: &v1 {group: foo.example.com, version: v1, kind: Foo}
+  - type: olm.gvk.required
+    value:
+      <<: *v1
+      kind: Bar
+x-copy-of-properties: *props
+`
+	cfg, err := LoadReader(strings.NewReader(in))
+	require.NoError(t, err)
+	require.Len(t, cfg.Bundles, 1)
+	require.Equal(t, []property.Property{
+		property.MustBuildPackage("foo", "0.1.0"),
+		property.MustBuildGVK("foo.example.com", "v1", "Foo"),
+		property.MustBuildGVKRequired("foo.example.com", "v1", "Bar"),
+	}, cfg.Bundles[0].Properties)
+
+	// Anchors are expanded, not preserved, on write.
+	var buf bytes.Buffer
+	require.NoError(t, WriteYAML(*cfg, &buf))
+	require.NotContains(t, buf.String(), "&")
+	require.NotContains(t, buf.String(), "*")
+
+	// Anchors are scoped to a single YAML document.
+	crossDocument := `---
+schema: olm.package
+name: foo
+properties: &shared
+  - type: example.com/a
+    value: {}
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+properties: *shared
+`
+	_, err = LoadReader(strings.NewReader(crossDocument))
+	require.ErrorContains(t, err, `unknown anchor 'shared' referenced`)
+}
+
+func TestLoadReaderArray(t *testing.T) {
+	in := `
+[
+  {"schema": "olm.package", "name": "foo", "defaultChannel": "stable"},
+  {"schema": "olm.channel", "package": "foo", "name": "stable", "entries": [{"name": "foo.v0.1.0"}]},
+  {"schema": "olm.bundle", "package": "foo", "name": "foo.v0.1.0", "image": "quay.io/example/foo-bundle:v0.1.0",
+   "properties": [{"type": "olm.package", "value": {"packageName": "foo", "version": "0.1.0"}}]},
+  {"schema": "custom.example.com", "package": "foo", "x": 1}
+]`
+	cfg, err := LoadReader(strings.NewReader(in))
+	require.NoError(t, err)
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Channels, 1)
+	require.Len(t, cfg.Bundles, 1)
+	require.Len(t, cfg.Others, 1)
+	require.Equal(t, "foo.v0.1.0", cfg.Bundles[0].Name)
+
+	cfg, err = LoadReader(strings.NewReader(" \n[]"))
+	require.NoError(t, err)
+	require.Equal(t, &DeclarativeConfig{}, cfg)
+
+	_, err = LoadReader(strings.NewReader(`[{"schema": "olm.package", "name": "foo"}, ["bar"]]`))
+	require.EqualError(t, err, "array index 1: json: cannot unmarshal array into Go value of type map[string]interface {} at offset 1, line 1, column 2 (indicated by <==)\n [ <== \n    \"bar\"\n]")
+
+	_, err = LoadReader(strings.NewReader(`[{"schema": "olm.package", "name": "foo"}, {"name": "bar"}]`))
+	require.EqualError(t, err, `array index 1: object '{"name":"bar"}
+' is missing root schema field`)
+}
+
 func TestWalkMetasFS(t *testing.T) {
 	type spec struct {
 		name              string
@@ -372,7 +457,7 @@ var (
 		Data: []byte(`[This is not yaml or json.}`),
 	}
 	notObject = &fstest.MapFile{
-		Data: []byte(`[]`),
+		Data: []byte(`"foo"`),
 	}
 	invalidFS = fstest.MapFS{
 		"invalid-bundle.json":  invalidBundle,
@@ -974,3 +1059,526 @@ func TestLoadFile(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadFSPropertyRefs(t *testing.T) {
+	catalog := []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+properties:
+  - type: example.com/notes
+    value: {"$ref": "notes.json"}
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/example/foo-bundle:v0.1.0
+properties:
+  - type: olm.package
+    value:
+      packageName: foo
+      version: 0.1.0
+  - type: example.com/config
+    value: {"$ref": "values/config.json"}
+`)
+	refFS := func(files map[string]string) fstest.MapFS {
+		fsys := fstest.MapFS{
+			"foo/catalog.yaml": &fstest.MapFile{Data: catalog},
+			"foo/.indexignore": &fstest.MapFile{Data: []byte("notes.json\nvalues/\n")},
+		}
+		for name, data := range files {
+			fsys[name] = &fstest.MapFile{Data: []byte(data)}
+		}
+		return fsys
+	}
+
+	type spec struct {
+		name      string
+		fsys      fs.FS
+		opts      []LoadOption
+		assertion require.ErrorAssertionFunc
+		expect    func(*testing.T, *DeclarativeConfig)
+	}
+	specs := []spec{
+		{
+			name:      "Success/DisabledByDefault",
+			fsys:      refFS(nil),
+			assertion: require.NoError,
+			expect: func(t *testing.T, cfg *DeclarativeConfig) {
+				require.JSONEq(t, `{"$ref": "notes.json"}`, string(cfg.Packages[0].Properties[0].Value))
+			},
+		},
+		{
+			name: "Success/Resolved",
+			fsys: refFS(map[string]string{
+				"foo/notes.json":         `{"text": "hello"}`,
+				"foo/values/config.json": "{\n  \"replicas\": 3\n}\n",
+			}),
+			opts:      []LoadOption{WithPropertyRefs(true)},
+			assertion: require.NoError,
+			expect: func(t *testing.T, cfg *DeclarativeConfig) {
+				require.Equal(t, `{"text":"hello"}`, string(cfg.Packages[0].Properties[0].Value))
+				require.Equal(t, `{"replicas":3}`, string(cfg.Bundles[0].Properties[1].Value))
+			},
+		},
+		{
+			name: "Error/NotJSON",
+			fsys: refFS(map[string]string{
+				"foo/notes.json":         `text: hello`,
+				"foo/values/config.json": `{}`,
+			}),
+			opts:      []LoadOption{WithPropertyRefs(true)},
+			assertion: hasError(`resolve property references: package "foo": property[0] of type "example.com/notes": reference "notes.json" is not valid JSON: invalid character 'e' in literal true (expecting 'r')`),
+		},
+		{
+			name: "Error/Missing",
+			fsys: refFS(map[string]string{
+				"foo/notes.json": `{}`,
+			}),
+			opts:      []LoadOption{WithPropertyRefs(true)},
+			assertion: hasError(`resolve property references: package "foo", bundle "foo.v0.1.0": property[1] of type "example.com/config": read reference "values/config.json": open foo/values/config.json: file does not exist`),
+		},
+		{
+			name: "Error/Traversal",
+			fsys: fstest.MapFS{
+				"foo/catalog.yaml": &fstest.MapFile{Data: []byte(`{"schema": "olm.package", "name": "foo", "properties": [{"type": "example.com/notes", "value": {"$ref": "../../secret.json"}}]}`)},
+			},
+			opts:      []LoadOption{WithPropertyRefs(true)},
+			assertion: hasError(`resolve property references: package "foo": property[0] of type "example.com/notes": reference "../../secret.json" must be a relative path within the catalog`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadFS(context.Background(), s.fsys, s.opts...)
+			s.assertion(t, err)
+			if s.expect != nil {
+				s.expect(t, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadFSStrictSchemas(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+---
+schema: olm.bundl
+package: foo
+name: foo.v0.1.0
+---
+schema: example.com/extension
+package: foo
+name: ext
+`)},
+	}
+	bundleFixed := fstest.MapFS{
+		"foo/catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+---
+schema: example.com/extension
+package: foo
+name: ext
+`)},
+	}
+
+	type spec struct {
+		name      string
+		fsys      fs.FS
+		opts      []LoadOption
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/LenientByDefault",
+			fsys:      fsys,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/RegisteredExtension",
+			fsys:      bundleFixed,
+			opts:      []LoadOption{StrictSchemas("example.com/extension")},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/UnregisteredExtension",
+			fsys:      bundleFixed,
+			opts:      []LoadOption{StrictSchemas()},
+			assertion: hasError(`unknown schema "example.com/extension" for object "ext" in package "foo"`),
+		},
+		{
+			name:      "Error/Typo",
+			fsys:      fsys,
+			opts:      []LoadOption{StrictSchemas("example.com/extension")},
+			assertion: hasError(`unknown schema "olm.bundl" for object "foo.v0.1.0" in package "foo"`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := LoadFS(context.Background(), s.fsys, s.opts...)
+			s.assertion(t, err)
+		})
+	}
+}
+
+func TestLoadFSMaxFileBytes(t *testing.T) {
+	small := `{"schema":"olm.package","name":"foo"}`
+	large := `{"schema":"olm.package","name":"bar","description":"` + strings.Repeat("x", 100) + `"}`
+	fsys := fstest.MapFS{
+		"foo/catalog.json": &fstest.MapFile{Data: []byte(small)},
+		"bar/catalog.json": &fstest.MapFile{Data: []byte(large)},
+	}
+
+	type spec struct {
+		name      string
+		fsys      fs.FS
+		opts      []LoadOption
+		packages  int
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Unlimited",
+			fsys:      fsys,
+			packages:  2,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/Skip",
+			fsys:      fsys,
+			opts:      []LoadOption{MaxFileBytes(int64(len(small)), OversizedFileSkip)},
+			packages:  1,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/SkipUnreportedSize",
+			fsys:      zeroSizeFS{fsys},
+			opts:      []LoadOption{MaxFileBytes(int64(len(small)), OversizedFileSkip)},
+			packages:  1,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/Oversized",
+			fsys:      fsys,
+			opts:      []LoadOption{MaxFileBytes(64, OversizedFileError)},
+			assertion: hasError(fmt.Sprintf(`file "bar/catalog.json" is %d bytes, which exceeds the maximum size of 64 bytes`, len(large))),
+		},
+		{
+			name:      "Error/OversizedUnreportedSize",
+			fsys:      zeroSizeFS{fsys},
+			opts:      []LoadOption{MaxFileBytes(64, OversizedFileError)},
+			assertion: hasError(`file "bar/catalog.json" exceeds the maximum size of 64 bytes`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadFS(context.Background(), s.fsys, s.opts...)
+			s.assertion(t, err)
+			if err == nil {
+				require.Len(t, cfg.Packages, s.packages)
+			}
+		})
+	}
+}
+
+// zeroSizeFS reports a size of zero for every regular file it opens.
+type zeroSizeFS struct {
+	fs.FS
+}
+
+func (z zeroSizeFS) Open(name string) (fs.File, error) {
+	f, err := z.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return f, nil
+	}
+	return zeroSizeFile{f}, nil
+}
+
+type zeroSizeFile struct {
+	fs.File
+}
+
+func (z zeroSizeFile) Stat() (fs.FileInfo, error) {
+	info, err := z.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return zeroSizeInfo{info}, nil
+}
+
+type zeroSizeInfo struct {
+	fs.FileInfo
+}
+
+func (zeroSizeInfo) Size() int64 { return 0 }
+
+func TestLoadFSMaxBundles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+`)},
+		"bar/catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: bar
+---
+schema: olm.bundle
+package: bar
+name: bar.v0.1.0
+`)},
+	}
+
+	type spec struct {
+		name      string
+		opts      []LoadOption
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Unlimited",
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/AtLimit",
+			opts:      []LoadOption{MaxBundles(3)},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/LimitExceededAcrossFiles",
+			opts:      []LoadOption{MaxBundles(2)},
+			assertion: hasError(`catalog exceeds the maximum of 2 bundles: limit exceeded while streaming, so the rest of the catalog was not parsed`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			for _, concurrency := range []int{1, 4} {
+				_, err := LoadFS(context.Background(), fsys, append(s.opts, WithConcurrency(concurrency))...)
+				s.assertion(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadFSGlob(t *testing.T) {
+	fsys := fstest.MapFS{
+		"catalog/foo/catalog.json": &fstest.MapFile{Data: []byte(`{"schema": "olm.package", "name": "foo"}`)},
+		"catalog/bar/catalog.json": &fstest.MapFile{Data: []byte(`{"schema": "olm.package", "name": "bar"}`)},
+		"catalog/bar/extra.yaml":   &fstest.MapFile{Data: []byte(`{"schema": "olm.package", "name": "extra"}`)},
+		"catalog/baz.json":         &fstest.MapFile{Data: []byte(`{"schema": "olm.package", "name": "baz"}`)},
+		"config/settings.json":     &fstest.MapFile{Data: []byte(`{"not": "a catalog"}`)},
+		"broken/catalog.json":      &fstest.MapFile{Data: []byte(`{"name": "broken"}`)},
+	}
+	packageNames := func(cfg *DeclarativeConfig) []string {
+		var names []string
+		for _, p := range cfg.Packages {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	type spec struct {
+		name      string
+		patterns  []string
+		expected  []string
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/SinglePattern",
+			patterns:  []string{"catalog/*/catalog.json"},
+			expected:  []string{"bar", "foo"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/OverlappingPatterns",
+			patterns:  []string{"catalog/*/catalog.json", "catalog/*", "catalog/bar/*"},
+			expected:  []string{"bar", "extra", "baz", "foo"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/NoMatches",
+			patterns:  []string{"*.json"},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/BadPattern",
+			patterns:  []string{"catalog/["},
+			assertion: hasError(`glob "catalog/[": syntax error in pattern`),
+		},
+		{
+			name:     "Error/ParseFailureReportsPath",
+			patterns: []string{"*/catalog.json"},
+			assertion: func(t require.TestingT, err error, _ ...interface{}) {
+				require.ErrorContains(t, err, `load "broken/catalog.json": object`)
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadFSGlob(fsys, s.patterns)
+			s.assertion(t, err)
+			if err == nil {
+				require.Equal(t, s.expected, packageNames(cfg))
+			}
+		})
+	}
+}
+
+func TestLoadFSLazyRuntimeFields(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/catalog.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/example/foo-bundle:v0.1.0
+properties:
+- type: olm.package
+  value:
+    packageName: foo
+    version: 0.1.0
+- type: olm.bundle.object
+  value:
+    ref: objects/csv.yaml
+- type: olm.bundle.object
+  value:
+    data: eyJraW5kIjogIkN1c3RvbVJlc291cmNlRGVmaW5pdGlvbiIsICJhcGlWZXJzaW9uIjogImFwaWV4dGVuc2lvbnMuazhzLmlvL3YxIn0=
+`)},
+		"foo/objects/csv.yaml": &fstest.MapFile{Data: []byte(`kind: ClusterServiceVersion
+apiVersion: operators.coreos.com/v1alpha1
+metadata:
+  name: foo.v0.1.0
+`)},
+		".indexignore": &fstest.MapFile{Data: []byte("objects\n")},
+	}
+
+	eager, err := LoadFS(context.Background(), fsys)
+	require.NoError(t, err)
+	require.Len(t, eager.Bundles[0].Objects, 2)
+	require.NotEmpty(t, eager.Bundles[0].CsvJSON)
+
+	lazy, err := LoadFS(context.Background(), fsys, WithLazyRuntimeFields(true))
+	require.NoError(t, err)
+	require.Empty(t, lazy.Bundles[0].Objects)
+	require.Empty(t, lazy.Bundles[0].CsvJSON)
+	require.Equal(t, BundleIdentity(eager.Bundles[0]), BundleIdentity(lazy.Bundles[0]))
+
+	eagerModel, err := ConvertToModel(*eager)
+	require.NoError(t, err)
+	lazyModel, err := ConvertToModel(*lazy)
+	require.NoError(t, err)
+	require.Equal(t, eagerModel, lazyModel)
+
+	require.NoError(t, PopulateBundleRuntimeFields(lazy))
+	require.Equal(t, eager, lazy)
+}
+
+func TestLoadFSLazyRuntimeFieldsDefersErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+properties:
+- type: olm.bundle.object
+  value:
+    ref: missing.yaml
+`)},
+	}
+
+	_, err := LoadFS(context.Background(), fsys)
+	require.Error(t, err)
+
+	cfg, err := LoadFS(context.Background(), fsys, WithLazyRuntimeFields(true))
+	require.NoError(t, err)
+	require.Error(t, cfg.Bundles[0].PopulateRuntimeFields())
+}
+
+func TestLoadFSOthersBlobFormat(t *testing.T) {
+	const blob = "{\n  \"schema\": \"custom.1\",\n  \"package\": \"foo\",\n  \"name\": \"bar\",\n  \"value\": 12345678901234567890\n}"
+	fsys := fstest.MapFS{
+		"catalog.json": &fstest.MapFile{Data: []byte(blob)},
+	}
+
+	type spec struct {
+		name     string
+		format   OthersBlobFormat
+		expected string
+	}
+	specs := []spec{
+		{
+			name:     "Default",
+			format:   OthersBlobDefault,
+			expected: `{"name":"bar","package":"foo","schema":"custom.1","value":12345678901234567000}` + "\n",
+		},
+		{
+			name:     "Canonical",
+			format:   OthersBlobCanonical,
+			expected: `{"name":"bar","package":"foo","schema":"custom.1","value":12345678901234567890}`,
+		},
+		{
+			name:     "Original",
+			format:   OthersBlobOriginal,
+			expected: blob,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadFS(context.Background(), fsys, WithOthersBlobFormat(s.format))
+			require.NoError(t, err)
+			require.Len(t, cfg.Others, 1)
+			require.Equal(t, Meta{Schema: "custom.1", Package: "foo", Name: "bar", Blob: json.RawMessage(s.expected)}, cfg.Others[0])
+		})
+	}
+}
+
+func TestBundlePopulateRuntimeFields(t *testing.T) {
+	csv := `{"kind":"ClusterServiceVersion","apiVersion":"operators.coreos.com/v1alpha1","metadata":{"name":"foo.v0.1.0"}}`
+	b := Bundle{
+		Schema:  SchemaBundle,
+		Package: "foo",
+		Name:    "foo.v0.1.0",
+		Properties: []property.Property{
+			property.MustBuildPackage("foo", "0.1.0"),
+			property.MustBuildBundleObjectData([]byte(csv)),
+		},
+	}
+	require.NoError(t, b.PopulateRuntimeFields())
+	require.Equal(t, []string{csv}, b.Objects)
+	require.Equal(t, csv, b.CsvJSON)
+
+	// Populated fields are left alone.
+	b.Objects = []string{"{}"}
+	require.NoError(t, b.PopulateRuntimeFields())
+	require.Equal(t, []string{"{}"}, b.Objects)
+
+	ref := Bundle{
+		Schema:     SchemaBundle,
+		Package:    "foo",
+		Name:       "foo.v0.1.0",
+		Properties: []property.Property{property.MustBuildBundleObjectRef("csv.yaml")},
+	}
+	require.EqualError(t, ref.PopulateRuntimeFields(), `package "foo", bundle "foo.v0.1.0": get data for bundle object "csv.yaml": open csv.yaml: bundle was not loaded from a filesystem`)
+}
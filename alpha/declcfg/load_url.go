@@ -0,0 +1,152 @@
+package declcfg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultLoadURLMaxSize is the default limit, in bytes, on the size of
+	// a document loaded by LoadURL, after decompression.
+	DefaultLoadURLMaxSize = 64 << 20
+
+	// DefaultLoadURLTimeout is the default time limit for LoadURL to fetch
+	// a document.
+	DefaultLoadURLTimeout = time.Minute
+)
+
+type loadURLOptions struct {
+	client  *http.Client
+	maxSize int64
+	timeout time.Duration
+}
+
+// LoadURLOption configures LoadURL.
+type LoadURLOption func(*loadURLOptions)
+
+// WithLoadURLHTTPClient sets the client used by LoadURL. By default,
+// http.DefaultClient is used.
+func WithLoadURLHTTPClient(client *http.Client) LoadURLOption {
+	return func(opts *loadURLOptions) {
+		opts.client = client
+	}
+}
+
+// WithLoadURLMaxSize sets the maximum size, in bytes, of the decompressed
+// document that LoadURL will read. Larger documents cause an error.
+func WithLoadURLMaxSize(maxSize int64) LoadURLOption {
+	return func(opts *loadURLOptions) {
+		opts.maxSize = maxSize
+	}
+}
+
+// WithLoadURLTimeout sets the time limit for LoadURL to fetch the document,
+// including reading the response body.
+func WithLoadURLTimeout(timeout time.Duration) LoadURLOption {
+	return func(opts *loadURLOptions) {
+		opts.timeout = timeout
+	}
+}
+
+// loadURLContentTypes are the media types accepted by LoadURL, and the
+// format that documents of each type are decoded as. Documents with a
+// generic type, or without one, are decoded as JSON or YAML depending on
+// their content.
+var loadURLContentTypes = map[string]documentFormat{
+	"application/json":         jsonFormat,
+	"application/x-ndjson":     jsonFormat,
+	"application/yaml":         yamlFormat,
+	"application/x-yaml":       yamlFormat,
+	"text/yaml":                yamlFormat,
+	"text/x-yaml":              yamlFormat,
+	"":                         detectFormat,
+	"text/plain":               detectFormat,
+	"application/octet-stream": detectFormat,
+	"application/gzip":         detectFormat,
+	"application/x-gzip":       detectFormat,
+}
+
+// LoadURL fetches a JSON or YAML declarative config document from url with
+// an HTTP GET request and loads it like LoadReader does. Documents served
+// with a JSON or YAML Content-Type are decoded as that format; for plain
+// text, generic binary data, gzip, or a missing Content-Type, the format is
+// detected from the content. Responses with any other Content-Type are
+// rejected, which guards against loading HTML error pages. Compressed
+// documents are decompressed transparently if they are served with a gzip
+// Content-Encoding or Content-Type, or start with the gzip magic number.
+//
+// Requests are bound by ctx and by a timeout, and documents larger than a
+// maximum size after decompression are rejected; see DefaultLoadURLTimeout
+// and DefaultLoadURLMaxSize.
+func LoadURL(ctx context.Context, url string, opts ...LoadURLOption) (*DeclarativeConfig, error) {
+	options := loadURLOptions{
+		client:  http.DefaultClient,
+		maxSize: DefaultLoadURLMaxSize,
+		timeout: DefaultLoadURLTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.1")
+	resp, err := options.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %q: unexpected status %s", url, resp.Status)
+	}
+
+	mediaType := ""
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err = mime.ParseMediaType(ct)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %q: parse content type: %v", url, err)
+		}
+	}
+	format, ok := loadURLContentTypes[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("fetch %q: unsupported content type %q", url, mediaType)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var r io.Reader = body
+	if magic, _ := body.Peek(2); resp.Header.Get("Content-Encoding") == "gzip" || bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %q: decompress: %v", url, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, options.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %q: %v", url, err)
+	}
+	if int64(len(data)) > options.maxSize {
+		return nil, fmt.Errorf("fetch %q: document exceeds maximum size of %d bytes", url, options.maxSize)
+	}
+
+	cfg, err := loadReader(bytes.NewReader(data), LoadOptions{documentFormat: format})
+	if err != nil {
+		return nil, fmt.Errorf("load %q: %v", url, err)
+	}
+	return cfg, nil
+}
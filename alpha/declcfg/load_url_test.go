@@ -0,0 +1,116 @@
+package declcfg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadURL(t *testing.T) {
+	const (
+		jsonDoc = `{"schema":"olm.package","name":"foo","defaultChannel":"stable"}
+{"schema":"olm.channel","package":"foo","name":"stable","entries":[{"name":"foo.v0.1.0"}]}`
+		yamlDoc = `schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+- name: foo.v0.1.0
+`
+	)
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return buf.Bytes()
+	}
+
+	mux := http.NewServeMux()
+	serve := func(path, contentType, contentEncoding string, body []byte) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			if contentEncoding != "" {
+				w.Header().Set("Content-Encoding", contentEncoding)
+			}
+			_, _ = w.Write(body)
+		})
+	}
+	serve("/catalog.json", "application/json", "", []byte(jsonDoc))
+	serve("/catalog.yaml", "application/yaml; charset=utf-8", "", []byte(yamlDoc))
+	serve("/catalog.json.gz", "application/gzip", "", gzipped(jsonDoc))
+	serve("/encoded.yaml", "application/yaml", "gzip", gzipped(yamlDoc))
+	serve("/catalog.txt", "text/plain", "", []byte(yamlDoc))
+	serve("/mislabeled.json", "application/json", "", []byte(yamlDoc))
+	serve("/error.html", "text/html", "", []byte("<html>not found</html>"))
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	type spec struct {
+		name      string
+		path      string
+		opts      []LoadURLOption
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{name: "Success/JSON", path: "/catalog.json", assertion: require.NoError},
+		{name: "Success/YAML", path: "/catalog.yaml", assertion: require.NoError},
+		{name: "Success/GzipContentType", path: "/catalog.json.gz", assertion: require.NoError},
+		{name: "Success/GzipContentEncoding", path: "/encoded.yaml", assertion: require.NoError},
+		{name: "Success/DetectedFormat", path: "/catalog.txt", assertion: require.NoError},
+		{
+			name:      "Error/YAMLServedAsJSON",
+			path:      "/mislabeled.json",
+			assertion: hasError(`load "` + srv.URL + `/mislabeled.json": invalid character 's' looking for beginning of value`),
+		},
+		{
+			name:      "Error/UnsupportedContentType",
+			path:      "/error.html",
+			assertion: hasError(`fetch "` + srv.URL + `/error.html": unsupported content type "text/html"`),
+		},
+		{
+			name:      "Error/NotFound",
+			path:      "/missing",
+			assertion: hasError(`fetch "` + srv.URL + `/missing": unexpected status 404 Not Found`),
+		},
+		{
+			name:      "Error/TooLarge",
+			path:      "/catalog.json.gz",
+			opts:      []LoadURLOption{WithLoadURLMaxSize(100)},
+			assertion: hasError(`fetch "` + srv.URL + `/catalog.json.gz": document exceeds maximum size of 100 bytes`),
+		},
+		{
+			name:      "Error/Timeout",
+			path:      "/slow",
+			opts:      []LoadURLOption{WithLoadURLTimeout(10 * time.Millisecond)},
+			assertion: require.Error,
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg, err := LoadURL(context.Background(), srv.URL+s.path, s.opts...)
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			require.Len(t, cfg.Packages, 1)
+			require.Equal(t, "foo", cfg.Packages[0].Name)
+			require.Len(t, cfg.Channels, 1)
+			require.Equal(t, "stable", cfg.Channels[0].Name)
+		})
+	}
+}
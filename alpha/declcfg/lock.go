@@ -0,0 +1,110 @@
+package declcfg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/docker/distribution/reference"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// LockEntry pins a single bundle of a catalog. Digest is the digest of the
+// bundle image, without its repository, so that a mirrored catalog has the
+// same lock as its source. Identity is the bundle's BundleIdentity.
+type LockEntry struct {
+	Package  string
+	Bundle   string
+	Version  string
+	Digest   string
+	Identity string
+}
+
+// Lock returns a LockEntry for every bundle in cfg, sorted by package, then
+// by version, and then by bundle name. Comparing the lock of a rebuilt
+// catalog with a previous one detects bundles whose image or content
+// changed unexpectedly.
+//
+// Every bundle must have a valid olm.package version and an image that is
+// pinned by digest; tagged images can be pinned with action.PinImageDigests
+// first. All bundles that do not meet these requirements are returned as an
+// aggregate error.
+func Lock(cfg DeclarativeConfig) ([]LockEntry, error) {
+	var (
+		entries []LockEntry
+		errs    []error
+	)
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		v, err := parseVersionProperty(b)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("package %q: %v", b.Package, err))
+			continue
+		}
+		digest, err := pinnedDigest(b.Image)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("package %q, bundle %q: %v", b.Package, b.Name, err))
+			continue
+		}
+		entries = append(entries, LockEntry{
+			Package:  b.Package,
+			Bundle:   b.Name,
+			Version:  v.String(),
+			Digest:   digest,
+			Identity: BundleIdentity(*b),
+		})
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Package != entries[j].Package {
+			return entries[i].Package < entries[j].Package
+		}
+		vi, vj := semver.MustParse(entries[i].Version), semver.MustParse(entries[j].Version)
+		if c := vi.Compare(vj); c != 0 {
+			return c < 0
+		}
+		return entries[i].Bundle < entries[j].Bundle
+	})
+	return entries, nil
+}
+
+// WriteLock writes the lock of cfg to w, one line per bundle:
+//
+//	<package> <bundle> <version> <digest> <identity>
+//
+// Lines are in the order returned by Lock, so locks of the same catalog are
+// byte-for-byte identical and changes show up as line-level diffs.
+func WriteLock(cfg DeclarativeConfig, w io.Writer) error {
+	entries, err := Lock(cfg)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(bw, "%s %s %s %s %s\n", e.Package, e.Bundle, e.Version, e.Digest, e.Identity); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// pinnedDigest returns the digest of image, which must be pinned by digest.
+func pinnedDigest(image string) (string, error) {
+	if image == "" {
+		return "", fmt.Errorf("image is not set")
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %v", image, err)
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return "", fmt.Errorf("image %q is not pinned by digest", image)
+	}
+	return canonical.Digest().String(), nil
+}
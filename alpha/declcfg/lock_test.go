@@ -0,0 +1,63 @@
+package declcfg
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLock(t *testing.T) {
+	const (
+		digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+	withImage := func(image string) func(*Bundle) {
+		return func(b *Bundle) { b.Image = image }
+	}
+
+	type spec struct {
+		name      string
+		cfg       DeclarativeConfig
+		expected  func(DeclarativeConfig) string
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/Sorted",
+			cfg: DeclarativeConfig{Bundles: []Bundle{
+				newTestBundle("foo", "0.10.0", withImage("quay.io/foo/bundle@"+digestB)),
+				newTestBundle("foo", "0.2.0", withImage("quay.io/foo/bundle@"+digestA)),
+				newTestBundle("bar", "1.0.0", withImage("registry.example.com/bar-bundle:v1.0.0@"+digestA)),
+			}},
+			expected: func(cfg DeclarativeConfig) string {
+				return fmt.Sprintf("bar bar.v1.0.0 1.0.0 %s %s\nfoo foo.v0.2.0 0.2.0 %s %s\nfoo foo.v0.10.0 0.10.0 %s %s\n",
+					digestA, BundleIdentity(cfg.Bundles[2]),
+					digestA, BundleIdentity(cfg.Bundles[1]),
+					digestB, BundleIdentity(cfg.Bundles[0]),
+				)
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/UnpinnedImages",
+			cfg: DeclarativeConfig{Bundles: []Bundle{
+				newTestBundle("foo", "0.1.0"),
+				newTestBundle("foo", "0.2.0", withImage("")),
+				newTestBundle("foo", "0.3.0", withImage("quay.io/foo/bundle@"+digestA)),
+			}},
+			assertion: hasError(`[package "foo", bundle "foo.v0.1.0": image "foo-bundle:v0.1.0" is not pinned by digest, package "foo", bundle "foo.v0.2.0": image is not set]`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := WriteLock(s.cfg, buf)
+			s.assertion(t, err)
+			if err == nil {
+				require.Equal(t, s.expected(s.cfg), buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package declcfg
+
+import (
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ConflictResolver chooses between two objects with the same identity when
+// declarative configs are merged. Each method is called with the object
+// seen first and the object seen second, and returns the object to keep or
+// an error if the conflict cannot be resolved.
+type ConflictResolver interface {
+	ResolvePackage(left, right Package) (Package, error)
+	ResolveChannel(left, right Channel) (Channel, error)
+	ResolveBundle(left, right Bundle) (Bundle, error)
+	ResolveOther(left, right Meta) (Meta, error)
+}
+
+// PreferLeft returns a ConflictResolver that keeps the object seen first.
+func PreferLeft() ConflictResolver {
+	return preferLeft{}
+}
+
+type preferLeft struct{}
+
+func (preferLeft) ResolvePackage(left, _ Package) (Package, error) { return left, nil }
+func (preferLeft) ResolveChannel(left, _ Channel) (Channel, error) { return left, nil }
+func (preferLeft) ResolveBundle(left, _ Bundle) (Bundle, error)    { return left, nil }
+func (preferLeft) ResolveOther(left, _ Meta) (Meta, error)         { return left, nil }
+
+// PreferRight returns a ConflictResolver that keeps the object seen last.
+func PreferRight() ConflictResolver {
+	return preferRight{}
+}
+
+type preferRight struct{}
+
+func (preferRight) ResolvePackage(_, right Package) (Package, error) { return right, nil }
+func (preferRight) ResolveChannel(_, right Channel) (Channel, error) { return right, nil }
+func (preferRight) ResolveBundle(_, right Bundle) (Bundle, error)    { return right, nil }
+func (preferRight) ResolveOther(_, right Meta) (Meta, error)         { return right, nil }
+
+// PreferHigherVersion returns a ConflictResolver that keeps the bundle with
+// the higher version, or the one seen last if the versions are equal.
+// Conflicts between other objects are resolved by fallback, or by
+// PreferLeft if fallback is nil.
+func PreferHigherVersion(fallback ConflictResolver) ConflictResolver {
+	if fallback == nil {
+		fallback = PreferLeft()
+	}
+	return preferHigherVersion{fallback}
+}
+
+type preferHigherVersion struct {
+	ConflictResolver
+}
+
+func (r preferHigherVersion) ResolveBundle(left, right Bundle) (Bundle, error) {
+	lv, err := parseVersionProperty(&left)
+	if err != nil {
+		return Bundle{}, err
+	}
+	rv, err := parseVersionProperty(&right)
+	if err != nil {
+		return Bundle{}, err
+	}
+	if lv.GT(*rv) {
+		return left, nil
+	}
+	return right, nil
+}
+
+// Merge combines cfgs into a single declarative config. Objects are kept
+// in the order they are first seen. Objects conflict if they have the same
+// identity, as defined by MergeStream; each conflict is passed to resolver,
+// and the chosen object takes the place of the first one. All errors
+// returned by resolver are returned as an aggregate error.
+func Merge(resolver ConflictResolver, cfgs ...DeclarativeConfig) (*DeclarativeConfig, error) {
+	var in DeclarativeConfig
+	for _, cfg := range cfgs {
+		in.Packages = append(in.Packages, cfg.Packages...)
+		in.Channels = append(in.Channels, cfg.Channels...)
+		in.Bundles = append(in.Bundles, cfg.Bundles...)
+		in.Others = append(in.Others, cfg.Others...)
+	}
+
+	var (
+		out  DeclarativeConfig
+		errs []error
+	)
+	out.Packages, errs = mergeObjects(in.Packages, errs, func(p Package) (string, bool) {
+		return fmt.Sprintf("package %q", p.Name), true
+	}, resolver.ResolvePackage)
+	out.Channels, errs = mergeObjects(in.Channels, errs, func(c Channel) (string, bool) {
+		return fmt.Sprintf("package %q, channel %q", c.Package, c.Name), true
+	}, resolver.ResolveChannel)
+	out.Bundles, errs = mergeObjects(in.Bundles, errs, func(b Bundle) (string, bool) {
+		return fmt.Sprintf("package %q, bundle %q", b.Package, b.Name), true
+	}, resolver.ResolveBundle)
+	out.Others, errs = mergeObjects(in.Others, errs, func(m Meta) (string, bool) {
+		return streamIdentity(&m)
+	}, resolver.ResolveOther)
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return &out, nil
+}
+
+func mergeObjects[T any](in []T, errs []error, identity func(T) (string, bool), resolve func(left, right T) (T, error)) ([]T, []error) {
+	var out []T
+	seen := map[string]int{}
+	for _, obj := range in {
+		id, ok := identity(obj)
+		if !ok {
+			out = append(out, obj)
+			continue
+		}
+		i, ok := seen[id]
+		if !ok {
+			seen[id] = len(out)
+			out = append(out, obj)
+			continue
+		}
+		chosen, err := resolve(out[i], obj)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve conflict for %s: %v", id, err))
+			continue
+		}
+		out[i] = chosen
+	}
+	return out, errs
+}
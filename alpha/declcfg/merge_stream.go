@@ -0,0 +1,81 @@
+package declcfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// MergeStream merges the declarative configs read from ins and writes the
+// result to out as a stream of compact JSON objects, one per line, without
+// loading any of the configs fully into memory. Objects are written in the
+// order they are read, so the output is not sorted like that of WriteJSON.
+//
+// Two objects conflict if they have the same identity: the same package
+// name for packages; the same package and name for channels and bundles;
+// and the same schema, package, and name for other objects. Other objects
+// without a name never conflict. Conflicting objects are not written; the
+// first object with a given identity wins.
+//
+// Detecting conflicts requires remembering every identity seen, so memory
+// use grows with the number of objects that have an identity. Only a
+// fixed-size digest of each identity and the index of its input are kept,
+// not the objects themselves, so memory use does not depend on the size of
+// the objects.
+//
+// An error is returned immediately if an input cannot be read or parsed,
+// or if out cannot be written. Otherwise, all conflicts found are returned
+// as an aggregate error after every input has been merged.
+func MergeStream(out io.Writer, ins ...io.Reader) error {
+	seen := map[[sha256.Size224]byte]int{}
+	var conflicts []error
+	for i, in := range ins {
+		err := WalkMetasReader(in, func(meta *Meta, err error) error {
+			if err != nil {
+				return err
+			}
+			if meta.Schema == "" {
+				return fmt.Errorf("object '%s' is missing root schema field", string(meta.Blob))
+			}
+
+			if id, ok := streamIdentity(meta); ok {
+				digest := sha256.Sum224([]byte(id))
+				if first, ok := seen[digest]; ok {
+					conflicts = append(conflicts, fmt.Errorf("duplicate %s in inputs %d and %d", id, first, i))
+					return nil
+				}
+				seen[digest] = i
+			}
+
+			if _, err := out.Write(bytes.TrimRight(meta.Blob, "\n")); err != nil {
+				return err
+			}
+			_, err = io.WriteString(out, "\n")
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+	}
+	return utilerrors.NewAggregate(conflicts)
+}
+
+// streamIdentity returns a human-readable identity for meta, and false if
+// meta has no identity.
+func streamIdentity(meta *Meta) (string, bool) {
+	switch meta.Schema {
+	case SchemaPackage:
+		return fmt.Sprintf("package %q", meta.Name), true
+	case SchemaChannel:
+		return fmt.Sprintf("package %q, channel %q", meta.Package, meta.Name), true
+	case SchemaBundle:
+		return fmt.Sprintf("package %q, bundle %q", meta.Package, meta.Name), true
+	}
+	if meta.Name == "" {
+		return "", false
+	}
+	return fmt.Sprintf("package %q, %s object %q", meta.Package, meta.Schema, meta.Name), true
+}
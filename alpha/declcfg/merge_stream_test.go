@@ -0,0 +1,105 @@
+package declcfg
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeStream(t *testing.T) {
+	type spec struct {
+		name      string
+		ins       []string
+		expected  string
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/NoConflicts",
+			ins: []string{
+				`{"schema":"olm.package","name":"foo","defaultChannel":"stable"}
+{"schema":"olm.channel","package":"foo","name":"stable","entries":[{"name":"foo.v0.1.0"}]}`,
+				`---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/example/foo-bundle:v0.1.0
+---
+schema: custom.1
+package: foo
+value: 1
+---
+schema: custom.1
+package: foo
+value: 2
+`,
+			},
+			expected: `{"defaultChannel":"stable","name":"foo","schema":"olm.package"}
+{"entries":[{"name":"foo.v0.1.0"}],"name":"stable","package":"foo","schema":"olm.channel"}
+{"image":"quay.io/example/foo-bundle:v0.1.0","name":"foo.v0.1.0","package":"foo","schema":"olm.bundle"}
+{"package":"foo","schema":"custom.1","value":1}
+{"package":"foo","schema":"custom.1","value":2}
+`,
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/Conflicts",
+			ins: []string{
+				`{"schema":"olm.package","name":"foo"}
+{"schema":"olm.bundle","package":"foo","name":"foo.v0.1.0","image":"a"}`,
+				`{"schema":"olm.package","name":"bar"}
+{"schema":"olm.bundle","package":"foo","name":"foo.v0.1.0","image":"b"}
+{"schema":"custom.1","package":"foo","name":"x"}`,
+				`{"schema":"olm.package","name":"foo"}
+{"schema":"olm.bundle","package":"bar","name":"foo.v0.1.0","image":"c"}
+{"schema":"custom.1","package":"foo","name":"x"}`,
+			},
+			expected: `{"name":"foo","schema":"olm.package"}
+{"image":"a","name":"foo.v0.1.0","package":"foo","schema":"olm.bundle"}
+{"name":"bar","schema":"olm.package"}
+{"name":"x","package":"foo","schema":"custom.1"}
+{"image":"c","name":"foo.v0.1.0","package":"bar","schema":"olm.bundle"}
+`,
+			assertion: hasError(`[duplicate package "foo", bundle "foo.v0.1.0" in inputs 0 and 1, duplicate package "foo" in inputs 0 and 2, duplicate package "foo", custom.1 object "x" in inputs 1 and 2]`),
+		},
+		{
+			name: "Error/MissingSchema",
+			ins: []string{
+				`{"schema":"olm.package","name":"foo"}`,
+				`{"name":"bar"}`,
+			},
+			expected:  `{"name":"foo","schema":"olm.package"}` + "\n",
+			assertion: hasError(`input 1: object '{"name":"bar"}` + "\n" + `' is missing root schema field`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			ins := make([]io.Reader, 0, len(s.ins))
+			for _, in := range s.ins {
+				ins = append(ins, strings.NewReader(in))
+			}
+			out := &bytes.Buffer{}
+			s.assertion(t, MergeStream(out, ins...))
+			require.Equal(t, s.expected, out.String())
+		})
+	}
+}
+
+func TestMergeStreamOutputLoads(t *testing.T) {
+	in := &bytes.Buffer{}
+	require.NoError(t, WriteJSON(buildValidDeclarativeConfig(true), in))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, MergeStream(out, in))
+
+	cfg, err := LoadReader(out)
+	require.NoError(t, err)
+	expected := buildValidDeclarativeConfig(true)
+	require.Len(t, cfg.Packages, len(expected.Packages))
+	require.Len(t, cfg.Channels, len(expected.Channels))
+	require.Len(t, cfg.Bundles, len(expected.Bundles))
+	require.Len(t, cfg.Others, len(expected.Others))
+}
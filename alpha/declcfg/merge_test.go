@@ -0,0 +1,78 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rejectChannelConflicts struct {
+	ConflictResolver
+}
+
+func (rejectChannelConflicts) ResolveChannel(_, _ Channel) (Channel, error) {
+	return Channel{}, errors.New("channels must not be redefined")
+}
+
+func TestMerge(t *testing.T) {
+	left := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+		Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.2.0")})},
+		Bundles:  []Bundle{newTestBundle("foo", "0.2.0")},
+		Others: []Meta{
+			{Schema: "custom.1", Package: "foo", Name: "a", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo","name":"a","v":1}`)},
+			{Schema: "custom.2", Package: "foo", Blob: json.RawMessage(`{"schema":"custom.2","package":"foo"}`)},
+		},
+	}
+	right := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "fast", svgSmallCircle)},
+		Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0"), newTestBundle("foo", "0.2.0"), newTestBundle("bar", "0.1.0")},
+		Others: []Meta{
+			{Schema: "custom.1", Package: "foo", Name: "a", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo","name":"a","v":2}`)},
+			{Schema: "custom.2", Package: "foo", Blob: json.RawMessage(`{"schema":"custom.2","package":"foo"}`)},
+		},
+	}
+	right.Bundles[1].Image = "quay.io/example/foo-bundle:v0.2.0-rebuilt"
+
+	merged, err := Merge(PreferLeft(), left, right)
+	require.NoError(t, err)
+	require.Equal(t, left.Packages, merged.Packages)
+	require.Equal(t, left.Channels, merged.Channels)
+	require.Equal(t, []Bundle{left.Bundles[0], right.Bundles[0], right.Bundles[2]}, merged.Bundles)
+	require.Equal(t, []Meta{left.Others[0], left.Others[1], right.Others[1]}, merged.Others)
+
+	merged, err = Merge(PreferRight(), left, right)
+	require.NoError(t, err)
+	require.Equal(t, right.Packages, merged.Packages)
+	require.Equal(t, []Bundle{right.Bundles[1], right.Bundles[0], right.Bundles[2]}, merged.Bundles)
+	require.Equal(t, []Meta{right.Others[0], left.Others[1], right.Others[1]}, merged.Others)
+
+	// The higher version wins regardless of order.
+	older := newTestBundle("foo", "0.2.0")
+	newer := newTestBundle("foo", "0.3.0")
+	newer.Name = older.Name
+	for _, order := range [][]Bundle{{older, newer}, {newer, older}} {
+		merged, err = Merge(PreferHigherVersion(PreferLeft()), DeclarativeConfig{Bundles: order[:1]}, DeclarativeConfig{Bundles: order[1:]})
+		require.NoError(t, err)
+		require.Equal(t, []Bundle{newer}, merged.Bundles)
+	}
+
+	// Other conflicts are resolved by the fallback, which defaults to
+	// PreferLeft.
+	merged, err = Merge(PreferHigherVersion(nil), left, right)
+	require.NoError(t, err)
+	require.Equal(t, left.Packages, merged.Packages)
+	require.Equal(t, left.Channels, merged.Channels)
+	require.Equal(t, []Meta{left.Others[0], left.Others[1], right.Others[1]}, merged.Others)
+	merged, err = Merge(PreferHigherVersion(PreferRight()), left, right)
+	require.NoError(t, err)
+	require.Equal(t, right.Packages, merged.Packages)
+	require.Equal(t, right.Channels, merged.Channels)
+	require.Equal(t, []Meta{right.Others[0], left.Others[1], right.Others[1]}, merged.Others)
+
+	_, err = Merge(rejectChannelConflicts{PreferLeft()}, left, right)
+	require.EqualError(t, err, `resolve conflict for package "foo", channel "stable": channels must not be redefined`)
+}
@@ -0,0 +1,160 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const SchemaPackageMigration = "olm.package.migration"
+
+// PackageMigration records that a package was renamed, so that upgrade
+// edges can bridge from bundles of OldPackage to bundles of NewPackage; see
+// CanUpgrade. Migrations are stored in a DeclarativeConfig's Others and
+// therefore round-trip unchanged.
+type PackageMigration struct {
+	Schema     string `json:"schema"`
+	OldPackage string `json:"oldPackage"`
+	NewPackage string `json:"newPackage"`
+}
+
+// PackageMigrations returns the package migrations found in cfg's Others.
+func PackageMigrations(cfg DeclarativeConfig) ([]PackageMigration, error) {
+	var out []PackageMigration
+	for i, o := range cfg.Others {
+		if o.Schema != SchemaPackageMigration {
+			continue
+		}
+		var m PackageMigration
+		if err := json.Unmarshal(o.Blob, &m); err != nil {
+			return nil, fmt.Errorf("parse %s object at index %d: %v", SchemaPackageMigration, i, err)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// CanUpgrade reports whether OLM upgrades an installed bundle named from,
+// of package fromPkg, directly to the bundle named to, of package toPkg. It
+// does if an entry for to in any channel of toPkg replaces or skips from,
+// or has a skipRange that includes the version of from.
+//
+// Upgrade edges follow package renames recorded as olm.package.migration
+// objects: fromPkg may differ from toPkg if fromPkg was renamed to toPkg,
+// directly or through a chain of renames. Bundles of unrelated packages
+// never upgrade to each other.
+//
+// An error is returned if cfg's migrations cannot be parsed or an entry for
+// to has an invalid skipRange. If from is not in cfg with a valid version,
+// only replaces and skips are considered.
+func CanUpgrade(cfg DeclarativeConfig, fromPkg, from, toPkg, to string) (bool, error) {
+	if fromPkg != toPkg {
+		oldPackages, err := packagesMigratedTo(cfg, toPkg)
+		if err != nil {
+			return false, err
+		}
+		if !oldPackages.Has(fromPkg) {
+			return false, nil
+		}
+	}
+
+	var fromVersion *semver.Version
+	for i := range cfg.Bundles {
+		if cfg.Bundles[i].Package == fromPkg && cfg.Bundles[i].Name == from {
+			fromVersion, _ = parseVersionProperty(&cfg.Bundles[i])
+			break
+		}
+	}
+
+	for _, ch := range cfg.Channels {
+		if ch.Package != toPkg {
+			continue
+		}
+		for _, e := range ch.Entries {
+			if e.Name != to {
+				continue
+			}
+			if e.Replaces == from || sets.New[string](e.Skips...).Has(from) {
+				return true, nil
+			}
+			if e.SkipRange == "" || fromVersion == nil {
+				continue
+			}
+			r, err := semver.ParseRange(e.SkipRange)
+			if err != nil {
+				return false, fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", ch.Package, ch.Name, e.Name, e.SkipRange, err)
+			}
+			if r(*fromVersion) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// packagesMigratedTo returns the packages that cfg's migrations rename to
+// pkg, directly or through a chain of renames. pkg itself is not included.
+func packagesMigratedTo(cfg DeclarativeConfig, pkg string) (sets.Set[string], error) {
+	migrations, err := PackageMigrations(cfg)
+	if err != nil {
+		return nil, err
+	}
+	renamedFrom := map[string][]string{}
+	for _, m := range migrations {
+		renamedFrom[m.NewPackage] = append(renamedFrom[m.NewPackage], m.OldPackage)
+	}
+
+	out := sets.New[string]()
+	queue := []string{pkg}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, old := range renamedFrom[cur] {
+			if old != pkg && !out.Has(old) {
+				out.Insert(old)
+				queue = append(queue, old)
+			}
+		}
+	}
+	return out, nil
+}
+
+// validatePackageMigrations reports migrations that cannot be parsed, that
+// reference packages not present in cfg, or that conflict with each other.
+func validatePackageMigrations(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	migrations, err := PackageMigrations(cfg)
+	if err != nil {
+		return []error{err}
+	}
+
+	packages := map[string]struct{}{}
+	for _, p := range cfg.Packages {
+		packages[p.Name] = struct{}{}
+	}
+
+	var errs []error
+	renamedTo := map[string]string{}
+	for _, m := range migrations {
+		if m.OldPackage == "" || m.NewPackage == "" {
+			errs = append(errs, fmt.Errorf("package migration %q -> %q: old and new package must be set", m.OldPackage, m.NewPackage))
+			continue
+		}
+		if m.OldPackage == m.NewPackage {
+			errs = append(errs, fmt.Errorf("package migration %q -> %q: package cannot be migrated to itself", m.OldPackage, m.NewPackage))
+			continue
+		}
+		for _, pkg := range []string{m.OldPackage, m.NewPackage} {
+			if _, ok := packages[pkg]; !ok {
+				errs = append(errs, fmt.Errorf("package migration %q -> %q: unknown package %q", m.OldPackage, m.NewPackage, pkg))
+			}
+		}
+		if prev, ok := renamedTo[m.OldPackage]; ok && prev != m.NewPackage {
+			errs = append(errs, fmt.Errorf("package %q is migrated to both %q and %q", m.OldPackage, prev, m.NewPackage))
+			continue
+		}
+		renamedTo[m.OldPackage] = m.NewPackage
+	}
+	return errs
+}
@@ -0,0 +1,128 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageMigrations(t *testing.T) {
+	migration := func(oldPkg, newPkg string) Meta {
+		blob, err := json.Marshal(PackageMigration{Schema: SchemaPackageMigration, OldPackage: oldPkg, NewPackage: newPkg})
+		require.NoError(t, err)
+		return Meta{Schema: SchemaPackageMigration, Blob: blob}
+	}
+	packages := []Package{
+		newTestPackage("foo", "stable", svgSmallCircle),
+		newTestPackage("bar", "stable", svgSmallCircle),
+		newTestPackage("baz", "stable", svgSmallCircle),
+	}
+
+	type spec struct {
+		name      string
+		others    []Meta
+		expected  []PackageMigration
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:   "Success",
+			others: []Meta{{Schema: "custom.1", Blob: json.RawMessage(`{"schema":"custom.1"}`)}, migration("foo", "bar")},
+			expected: []PackageMigration{
+				{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "bar"},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/UnknownPackage",
+			others:    []Meta{migration("foo", "qux")},
+			expected:  []PackageMigration{{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "qux"}},
+			assertion: hasError(`package migration "foo" -> "qux": unknown package "qux"`),
+		},
+		{
+			name:   "Error/Conflicting",
+			others: []Meta{migration("foo", "bar"), migration("foo", "baz")},
+			expected: []PackageMigration{
+				{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "bar"},
+				{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "baz"},
+			},
+			assertion: hasError(`package "foo" is migrated to both "bar" and "baz"`),
+		},
+		{
+			name:      "Error/Self",
+			others:    []Meta{migration("foo", "foo")},
+			expected:  []PackageMigration{{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "foo"}},
+			assertion: hasError(`package migration "foo" -> "foo": package cannot be migrated to itself`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := DeclarativeConfig{Packages: packages, Others: s.others}
+			actual, err := PackageMigrations(cfg)
+			require.NoError(t, err)
+			require.Equal(t, s.expected, actual)
+			s.assertion(t, Validate(cfg, AllowPackagesWithoutChannels()))
+		})
+	}
+
+	// Migrations round-trip through Write and Load unchanged.
+	cfg := DeclarativeConfig{Packages: packages[:2], Others: []Meta{migration("foo", "bar")}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(cfg, &buf))
+	loaded, err := LoadReader(&buf)
+	require.NoError(t, err)
+	actual, err := PackageMigrations(*loaded)
+	require.NoError(t, err)
+	require.Equal(t, []PackageMigration{{Schema: SchemaPackageMigration, OldPackage: "foo", NewPackage: "bar"}}, actual)
+}
+
+func TestCanUpgrade(t *testing.T) {
+	migration := func(oldPkg, newPkg string) Meta {
+		blob, err := json.Marshal(PackageMigration{Schema: SchemaPackageMigration, OldPackage: oldPkg, NewPackage: newPkg})
+		require.NoError(t, err)
+		return Meta{Schema: SchemaPackageMigration, Blob: blob}
+	}
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}),
+			newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.2.0", Replaces: "foo.v0.1.0"}),
+			newTestChannel("baz", "stable",
+				ChannelEntry{Name: "baz.v1.0.0", Replaces: "bar.v0.2.0", SkipRange: "<0.2.0"},
+				ChannelEntry{Name: "baz.v1.1.0", Replaces: "baz.v1.0.0"},
+			),
+			newTestChannel("qux", "stable", ChannelEntry{Name: "qux.v0.1.5"}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("bar", "0.2.0"),
+			newTestBundle("baz", "1.0.0"),
+			newTestBundle("baz", "1.1.0"),
+			newTestBundle("qux", "0.1.5"),
+		},
+		Others: []Meta{migration("foo", "bar"), migration("bar", "baz")},
+	}
+
+	type spec struct {
+		name          string
+		fromPkg, from string
+		toPkg, to     string
+		expected      bool
+	}
+	specs := []spec{
+		{name: "SamePackage", fromPkg: "baz", from: "baz.v1.0.0", toPkg: "baz", to: "baz.v1.1.0", expected: true},
+		{name: "SamePackage/NoEdge", fromPkg: "baz", from: "baz.v1.1.0", toPkg: "baz", to: "baz.v1.0.0", expected: false},
+		{name: "Migrated/Replaces", fromPkg: "bar", from: "bar.v0.2.0", toPkg: "baz", to: "baz.v1.0.0", expected: true},
+		{name: "Migrated/SkipRangeThroughChain", fromPkg: "foo", from: "foo.v0.1.0", toPkg: "baz", to: "baz.v1.0.0", expected: true},
+		{name: "Unrelated/SkipRange", fromPkg: "qux", from: "qux.v0.1.5", toPkg: "baz", to: "baz.v1.0.0", expected: false},
+		{name: "Reverse", fromPkg: "baz", from: "baz.v1.0.0", toPkg: "bar", to: "bar.v0.2.0", expected: false},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := CanUpgrade(cfg, s.fromPkg, s.from, s.toPkg, s.to)
+			require.NoError(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
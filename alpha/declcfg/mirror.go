@@ -0,0 +1,156 @@
+package declcfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EqualIgnoringRegistry reports whether a and b have the same content when
+// image references that are pinned by digest are compared by digest alone,
+// ignoring their registry host and repository path. This makes it possible
+// to verify that a mirrored catalog faithfully reproduces its source.
+// Image references that are not pinned by digest must match exactly.
+//
+// Bundle images and related images are compared this way; all other fields,
+// including property values, must be equal. If the catalogs differ, a
+// sorted list of human-readable messages describing the differences is
+// returned, grouped by packages, channels, bundles, and other objects, and
+// referring to the catalogs as "a" and "b".
+func EqualIgnoringRegistry(a, b DeclarativeConfig) (bool, []string) {
+	var diffs, section []string
+	endSection := func() {
+		sort.Strings(section)
+		diffs = append(diffs, section...)
+		section = nil
+	}
+
+	type key struct {
+		pkg  string
+		name string
+	}
+
+	aPackages, bPackages := map[string]Package{}, map[string]Package{}
+	for _, p := range a.Packages {
+		aPackages[p.Name] = p
+	}
+	for _, p := range b.Packages {
+		bPackages[p.Name] = p
+	}
+	for name := range unionKeys(aPackages, bPackages) {
+		ap, inA := aPackages[name]
+		bp, inB := bPackages[name]
+		switch {
+		case !inB:
+			section = append(section, fmt.Sprintf("package %q only in a", name))
+		case !inA:
+			section = append(section, fmt.Sprintf("package %q only in b", name))
+		case !jsonEqual(ap, bp):
+			section = append(section, fmt.Sprintf("package %q differs", name))
+		}
+	}
+
+	endSection()
+
+	aChannels, bChannels := map[key]Channel{}, map[key]Channel{}
+	for _, c := range a.Channels {
+		aChannels[key{c.Package, c.Name}] = c
+	}
+	for _, c := range b.Channels {
+		bChannels[key{c.Package, c.Name}] = c
+	}
+	for k := range unionKeys(aChannels, bChannels) {
+		ac, inA := aChannels[k]
+		bc, inB := bChannels[k]
+		switch {
+		case !inB:
+			section = append(section, fmt.Sprintf("package %q, channel %q only in a", k.pkg, k.name))
+		case !inA:
+			section = append(section, fmt.Sprintf("package %q, channel %q only in b", k.pkg, k.name))
+		case !jsonEqual(ac, bc):
+			section = append(section, fmt.Sprintf("package %q, channel %q differs", k.pkg, k.name))
+		}
+	}
+
+	endSection()
+
+	aBundles, bBundles := map[key]Bundle{}, map[key]Bundle{}
+	for _, bd := range a.Bundles {
+		aBundles[key{bd.Package, bd.Name}] = withoutRegistry(bd)
+	}
+	for _, bd := range b.Bundles {
+		bBundles[key{bd.Package, bd.Name}] = withoutRegistry(bd)
+	}
+	// Bundle differences are kept in the order reported by DiffBundles.
+	bundleKeys := unionKeys(aBundles, bBundles).UnsortedList()
+	sort.Slice(bundleKeys, func(i, j int) bool {
+		if bundleKeys[i].pkg != bundleKeys[j].pkg {
+			return bundleKeys[i].pkg < bundleKeys[j].pkg
+		}
+		return bundleKeys[i].name < bundleKeys[j].name
+	})
+	for _, k := range bundleKeys {
+		ab, inA := aBundles[k]
+		bb, inB := bBundles[k]
+		switch {
+		case !inB:
+			diffs = append(diffs, fmt.Sprintf("package %q, bundle %q only in a", k.pkg, k.name))
+		case !inA:
+			diffs = append(diffs, fmt.Sprintf("package %q, bundle %q only in b", k.pkg, k.name))
+		default:
+			for _, d := range DiffBundles(ab, bb) {
+				diffs = append(diffs, fmt.Sprintf("package %q, bundle %q: %s", k.pkg, k.name, d))
+			}
+		}
+	}
+
+	type otherKey struct {
+		schema, pkg, name string
+	}
+	otherBlobs := func(others []Meta) map[otherKey][]string {
+		out := map[otherKey][]string{}
+		for _, o := range others {
+			blob, err := canonicalizeJSON(o.Blob)
+			if err != nil {
+				blob = o.Blob
+			}
+			k := otherKey{o.Schema, o.Package, o.Name}
+			out[k] = append(out[k], string(blob))
+		}
+		for _, blobs := range out {
+			sort.Strings(blobs)
+		}
+		return out
+	}
+	aOthers, bOthers := otherBlobs(a.Others), otherBlobs(b.Others)
+	for k := range unionKeys(aOthers, bOthers) {
+		if strings.Join(aOthers[k], "\n") != strings.Join(bOthers[k], "\n") {
+			section = append(section, fmt.Sprintf("package %q, %s object %q differs", k.pkg, k.schema, k.name))
+		}
+	}
+
+	endSection()
+
+	return len(diffs) == 0, diffs
+}
+
+// withoutRegistry returns a copy of b whose image references are reduced to
+// their digest, if they have one.
+func withoutRegistry(b Bundle) Bundle {
+	b.Image = imageDigest(b.Image)
+	relatedImages := make([]RelatedImage, 0, len(b.RelatedImages))
+	for _, ri := range b.RelatedImages {
+		relatedImages = append(relatedImages, RelatedImage{Name: ri.Name, Image: imageDigest(ri.Image)})
+	}
+	b.RelatedImages = relatedImages
+	return b
+}
+
+// imageDigest returns the digest part of a digest-pinned image reference,
+// including the leading "@", or image unchanged if it is not pinned.
+func imageDigest(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i:]
+	}
+	return image
+}
@@ -0,0 +1,92 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqualIgnoringRegistry(t *testing.T) {
+	const (
+		digestA = "@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		digestB = "@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+	source := func() DeclarativeConfig {
+		return DeclarativeConfig{
+			Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"})},
+			Bundles: []Bundle{{
+				Schema:        SchemaBundle,
+				Package:       "foo",
+				Name:          "foo.v0.1.0",
+				Image:         "quay.io/example/foo-bundle" + digestA,
+				RelatedImages: []RelatedImage{{Name: "operator", Image: "quay.io/example/foo" + digestB}},
+			}},
+			Others: []Meta{{Schema: "custom.1", Package: "foo", Name: "bar", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo","name":"bar"}`)}},
+		}
+	}
+
+	type spec struct {
+		name     string
+		mutate   func(*DeclarativeConfig)
+		expected []string
+	}
+	specs := []spec{
+		{
+			name:   "Equal/Identical",
+			mutate: func(*DeclarativeConfig) {},
+		},
+		{
+			name: "Equal/MirroredDigests",
+			mutate: func(cfg *DeclarativeConfig) {
+				cfg.Bundles[0].Image = "mirror.example.com:5000/team/foo-bundle" + digestA
+				cfg.Bundles[0].RelatedImages[0].Image = "mirror.example.com:5000/team/foo:v0.1.0" + digestB
+				cfg.Others[0].Blob = json.RawMessage(`{"name":"bar","package":"foo","schema":"custom.1"}`)
+			},
+		},
+		{
+			name: "NotEqual/DifferentDigest",
+			mutate: func(cfg *DeclarativeConfig) {
+				cfg.Bundles[0].Image = "mirror.example.com/foo-bundle" + digestB
+			},
+			expected: []string{
+				`package "foo", bundle "foo.v0.1.0": image: "` + digestA + `" -> "` + digestB + `"`,
+			},
+		},
+		{
+			name: "NotEqual/MirroredTag",
+			mutate: func(cfg *DeclarativeConfig) {
+				cfg.Bundles[0].RelatedImages[0].Image = "mirror.example.com/foo:v0.1.0"
+			},
+			expected: []string{
+				`package "foo", bundle "foo.v0.1.0": relatedImages: "operator=` + digestB + `" -> ""`,
+				`package "foo", bundle "foo.v0.1.0": relatedImages: "" -> "operator=mirror.example.com/foo:v0.1.0"`,
+			},
+		},
+		{
+			name: "NotEqual/Objects",
+			mutate: func(cfg *DeclarativeConfig) {
+				cfg.Packages[0].Description = "changed"
+				cfg.Channels = append(cfg.Channels, newTestChannel("foo", "fast", ChannelEntry{Name: "foo.v0.1.0"}))
+				cfg.Bundles = nil
+				cfg.Others[0].Blob = json.RawMessage(`{"schema":"custom.1","package":"foo","name":"bar","x":1}`)
+			},
+			expected: []string{
+				`package "foo" differs`,
+				`package "foo", channel "fast" only in b`,
+				`package "foo", bundle "foo.v0.1.0" only in a`,
+				`package "foo", custom.1 object "bar" differs`,
+			},
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			a, b := source(), source()
+			s.mutate(&b)
+			equal, diffs := EqualIgnoringRegistry(a, b)
+			require.Equal(t, len(s.expected) == 0, equal)
+			require.Equal(t, s.expected, diffs)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// NormalizePackageProperties rewrites the olm.package properties of every
+// bundle in cfg into the canonical form expected by the model:
+//
+//	{"packageName":"foo","version":"1.2.3"}
+//
+// In addition to the canonical form, the version may be given as a nested
+// object, either as {"version":"1.2.3"} or as semver components such as
+// {"major":1,"minor":2,"patch":3,"pre":"rc.0","build":"abc"}. The package
+// name is preserved as-is. An error is returned for any olm.package property
+// whose version cannot be interpreted, in which case cfg is left unmodified.
+func NormalizePackageProperties(cfg *DeclarativeConfig) error {
+	type update struct {
+		bundle, prop int
+		value        json.RawMessage
+	}
+	var (
+		updates []update
+		errs    []error
+	)
+	for i, b := range cfg.Bundles {
+		for j, p := range b.Properties {
+			if p.Type != property.TypePackage {
+				continue
+			}
+			pkg, err := normalizePackageProperty(p.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("package %q, bundle %q: invalid %q property: %v", b.Package, b.Name, property.TypePackage, err))
+				continue
+			}
+			updates = append(updates, update{i, j, property.MustBuild(pkg).Value})
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	for _, u := range updates {
+		cfg.Bundles[u.bundle].Properties[u.prop].Value = u.value
+	}
+	return nil
+}
+
+func normalizePackageProperty(value json.RawMessage) (*property.Package, error) {
+	var raw struct {
+		PackageName string          `json:"packageName"`
+		Version     json.RawMessage `json:"version"`
+	}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw.Version) == 0 {
+		return nil, errors.New("version is not set")
+	}
+	version, err := normalizePackageVersion(raw.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &property.Package{PackageName: raw.PackageName, Version: version}, nil
+}
+
+func normalizePackageVersion(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, nil
+	}
+
+	var nested struct {
+		Version *string `json:"version"`
+		Major   *uint64 `json:"major"`
+		Minor   *uint64 `json:"minor"`
+		Patch   *uint64 `json:"patch"`
+		Pre     string  `json:"pre"`
+		Build   string  `json:"build"`
+	}
+	if err := json.Unmarshal(value, &nested); err != nil {
+		return "", fmt.Errorf("unrecognized version %s", value)
+	}
+	switch {
+	case nested.Version != nil:
+		return *nested.Version, nil
+	case nested.Major != nil && nested.Minor != nil && nested.Patch != nil:
+		v := fmt.Sprintf("%d.%d.%d", *nested.Major, *nested.Minor, *nested.Patch)
+		if nested.Pre != "" {
+			v += "-" + nested.Pre
+		}
+		if nested.Build != "" {
+			v += "+" + nested.Build
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("unrecognized version %s", value)
+}
+
+// InferPackageProperties adds an olm.package property to every bundle in cfg
+// that does not have one, taking the version from the bundle's name. The
+// version is the submatch of pattern named "version" or, if pattern has no
+// such group, its first submatch; for example, `^[^.]+\.v(.+)$` matches
+// names such as "foo.v1.2.3". Bundles that already have an olm.package
+// property are left unchanged.
+//
+// An error is returned if a bundle's name does not match pattern or the
+// version is not valid semver, in which case cfg is left unmodified.
+func InferPackageProperties(cfg *DeclarativeConfig, pattern *regexp.Regexp) error {
+	group := pattern.SubexpIndex("version")
+	if group < 0 {
+		group = 1
+	}
+	if pattern.NumSubexp() < group {
+		return fmt.Errorf("pattern %q has no submatch for the version", pattern)
+	}
+
+	type update struct {
+		bundle int
+		prop   property.Property
+	}
+	var (
+		updates []update
+		errs    []error
+	)
+	for i, b := range cfg.Bundles {
+		if hasPackageProperty(b) {
+			continue
+		}
+		m := pattern.FindStringSubmatch(b.Name)
+		if m == nil {
+			errs = append(errs, fmt.Errorf("package %q, bundle %q: name does not match pattern %q", b.Package, b.Name, pattern))
+			continue
+		}
+		if _, err := semver.Parse(m[group]); err != nil {
+			errs = append(errs, fmt.Errorf("package %q, bundle %q: invalid version %q: %v", b.Package, b.Name, m[group], err))
+			continue
+		}
+		updates = append(updates, update{i, property.MustBuildPackage(b.Package, m[group])})
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	for _, u := range updates {
+		cfg.Bundles[u.bundle].Properties = append(cfg.Bundles[u.bundle].Properties, u.prop)
+	}
+	return nil
+}
+
+func hasPackageProperty(b Bundle) bool {
+	for _, p := range b.Properties {
+		if p.Type == property.TypePackage {
+			return true
+		}
+	}
+	return false
+}
+
+// SortRelatedImages sorts the related images of every bundle in cfg by name
+// and then by image, and removes exact duplicates. Because related images
+// are compared as a set, this does not change whether bundles are equal.
+func SortRelatedImages(cfg *DeclarativeConfig) {
+	for i := range cfg.Bundles {
+		cfg.Bundles[i].RelatedImages = sortedRelatedImages(cfg.Bundles[i].RelatedImages)
+	}
+}
+
+// sortedRelatedImages returns a sorted, de-duplicated copy of in.
+func sortedRelatedImages(in []RelatedImage) []RelatedImage {
+	if in == nil {
+		return nil
+	}
+	out := make([]RelatedImage, len(in))
+	copy(out, in)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Image < out[j].Image
+	})
+	n := 0
+	for i, ri := range out {
+		if i > 0 && ri == out[n-1] {
+			continue
+		}
+		out[n] = ri
+		n++
+	}
+	return out[:n]
+}
+
+// DropEmptyOthers removes the objects in cfg.Others whose blob is empty,
+// whitespace-only, or null, and returns the number of objects removed.
+func DropEmptyOthers(cfg *DeclarativeConfig) int {
+	n := 0
+	for _, o := range cfg.Others {
+		if !isEmptyBlob(o.Blob) {
+			cfg.Others[n] = o
+			n++
+		}
+	}
+	dropped := len(cfg.Others) - n
+	if dropped > 0 {
+		cfg.Others = cfg.Others[:n]
+	}
+	return dropped
+}
+
+func isEmptyBlob(blob []byte) bool {
+	trimmed := bytes.TrimSpace(blob)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
@@ -0,0 +1,156 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestNormalizePackageProperties(t *testing.T) {
+	type spec struct {
+		name      string
+		value     string
+		expected  string
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Canonical",
+			value:     `{"packageName":"foo","version":"1.2.3"}`,
+			expected:  `{"packageName":"foo","version":"1.2.3"}`,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/NestedVersionString",
+			value:     `{"version":{"version":"1.2.3"},"packageName":"foo"}`,
+			expected:  `{"packageName":"foo","version":"1.2.3"}`,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/NestedVersionComponents",
+			value:     `{"packageName":"foo","version":{"major":1,"minor":2,"patch":3,"pre":"rc.0","build":"abc"}}`,
+			expected:  `{"packageName":"foo","version":"1.2.3-rc.0+abc"}`,
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/NumericVersion",
+			value:     `{"packageName":"foo","version":1.2}`,
+			expected:  `{"packageName":"foo","version":1.2}`,
+			assertion: hasError(`package "foo", bundle "foo.v0.1.0": invalid "olm.package" property: unrecognized version 1.2`),
+		},
+		{
+			name:      "Error/IncompleteComponents",
+			value:     `{"packageName":"foo","version":{"major":1}}`,
+			expected:  `{"packageName":"foo","version":{"major":1}}`,
+			assertion: hasError(`package "foo", bundle "foo.v0.1.0": invalid "olm.package" property: unrecognized version {"major":1}`),
+		},
+		{
+			name:      "Error/MissingVersion",
+			value:     `{"packageName":"foo"}`,
+			expected:  `{"packageName":"foo"}`,
+			assertion: hasError(`package "foo", bundle "foo.v0.1.0": invalid "olm.package" property: version is not set`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := DeclarativeConfig{Bundles: []Bundle{{
+				Schema:  SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v0.1.0",
+				Properties: []property.Property{
+					{Type: property.TypePackage, Value: json.RawMessage(s.value)},
+					property.MustBuildGVK("foo.example.com", "v1", "Foo"),
+				},
+			}}}
+			s.assertion(t, NormalizePackageProperties(&cfg))
+			require.JSONEq(t, s.expected, string(cfg.Bundles[0].Properties[0].Value))
+			require.Equal(t, property.MustBuildGVK("foo.example.com", "v1", "Foo"), cfg.Bundles[0].Properties[1])
+		})
+	}
+}
+
+func TestSortRelatedImages(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{
+				Schema:  SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v0.1.0",
+				RelatedImages: []RelatedImage{
+					{Name: "operator", Image: "quay.io/example/operator:v0.1.0"},
+					{Name: "", Image: "quay.io/example/foo:v0.1.0"},
+					{Name: "operator", Image: "quay.io/example/operator:v0.0.1"},
+					{Name: "operator", Image: "quay.io/example/operator:v0.1.0"},
+				},
+			},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0"},
+		},
+	}
+	original := cfg.Bundles[0]
+	original.RelatedImages = append([]RelatedImage{}, cfg.Bundles[0].RelatedImages...)
+
+	SortRelatedImages(&cfg)
+	require.Equal(t, []RelatedImage{
+		{Name: "", Image: "quay.io/example/foo:v0.1.0"},
+		{Name: "operator", Image: "quay.io/example/operator:v0.0.1"},
+		{Name: "operator", Image: "quay.io/example/operator:v0.1.0"},
+	}, cfg.Bundles[0].RelatedImages)
+	require.Nil(t, cfg.Bundles[1].RelatedImages)
+	require.True(t, BundlesEqual(original, cfg.Bundles[0]))
+}
+
+func TestInferPackageProperties(t *testing.T) {
+	pattern := regexp.MustCompile(`^[^.]+\.v(?P<version>.+)$`)
+	withPackage := Bundle{
+		Schema:     SchemaBundle,
+		Package:    "foo",
+		Name:       "foo.v9.9.9",
+		Properties: []property.Property{property.MustBuildPackage("foo", "0.1.0")},
+	}
+
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0"},
+			withPackage,
+		},
+	}
+	require.NoError(t, InferPackageProperties(&cfg, pattern))
+	require.Equal(t, []property.Property{property.MustBuildPackage("foo", "0.2.0")}, cfg.Bundles[0].Properties)
+	require.Equal(t, withPackage, cfg.Bundles[1])
+
+	// The first submatch is used when there is no "version" group.
+	cfg = DeclarativeConfig{Bundles: []Bundle{{Schema: SchemaBundle, Package: "foo", Name: "foo-1.0.0"}}}
+	require.NoError(t, InferPackageProperties(&cfg, regexp.MustCompile(`^foo-(.+)$`)))
+	require.Equal(t, []property.Property{property.MustBuildPackage("foo", "1.0.0")}, cfg.Bundles[0].Properties)
+
+	invalid := DeclarativeConfig{
+		Bundles: []Bundle{
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo-latest"},
+			{Schema: SchemaBundle, Package: "foo", Name: "foo.vlatest"},
+		},
+	}
+	err := InferPackageProperties(&invalid, pattern)
+	require.EqualError(t, err, `[package "foo", bundle "foo-latest": name does not match pattern "^[^.]+\\.v(?P<version>.+)$", package "foo", bundle "foo.vlatest": invalid version "latest": No Major.Minor.Patch elements found]`)
+	require.Nil(t, invalid.Bundles[0].Properties)
+}
+
+func TestDropEmptyOthers(t *testing.T) {
+	cfg := DeclarativeConfig{Others: []Meta{
+		{Schema: "custom.a", Blob: json.RawMessage(`{"schema":"custom.a"}`)},
+		{Schema: "custom.b", Blob: json.RawMessage(" \n\t")},
+		{Schema: "custom.c", Blob: json.RawMessage(" null ")},
+		{Schema: "custom.d"},
+		{Schema: "custom.e", Blob: json.RawMessage(`{"schema":"custom.e"}`)},
+	}}
+	require.Equal(t, 3, DropEmptyOthers(&cfg))
+	require.Equal(t, []Meta{
+		{Schema: "custom.a", Blob: json.RawMessage(`{"schema":"custom.a"}`)},
+		{Schema: "custom.e", Blob: json.RawMessage(`{"schema":"custom.e"}`)},
+	}, cfg.Others)
+	require.Equal(t, 0, DropEmptyOthers(&cfg))
+}
@@ -0,0 +1,93 @@
+package declcfg
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// PromoteBundle adds bundleName, which must be an entry of fromChannel, to
+// toChannel in package pkg. The new entry replaces the current head of
+// toChannel (if toChannel has any entries), skips the given bundles, and
+// keeps the skipRange of the entry in fromChannel. An error is returned if
+// either channel does not exist, the bundle is not in fromChannel or is
+// already in toChannel, or the promotion would create a cycle.
+func PromoteBundle(cfg *DeclarativeConfig, pkg, bundleName, fromChannel, toChannel string, skips ...string) error {
+	if fromChannel == toChannel {
+		return fmt.Errorf("package %q: cannot promote bundle %q from channel %q to itself", pkg, bundleName, fromChannel)
+	}
+
+	fromIdx, toIdx := -1, -1
+	for i, ch := range cfg.Channels {
+		if ch.Package != pkg {
+			continue
+		}
+		switch ch.Name {
+		case fromChannel:
+			fromIdx = i
+		case toChannel:
+			toIdx = i
+		}
+	}
+	if fromIdx < 0 {
+		return fmt.Errorf("package %q: channel %q not found", pkg, fromChannel)
+	}
+	if toIdx < 0 {
+		return fmt.Errorf("package %q: channel %q not found", pkg, toChannel)
+	}
+
+	var fromEntry *ChannelEntry
+	for i, e := range cfg.Channels[fromIdx].Entries {
+		if e.Name == bundleName {
+			fromEntry = &cfg.Channels[fromIdx].Entries[i]
+			break
+		}
+	}
+	if fromEntry == nil {
+		return fmt.Errorf("package %q: bundle %q not found in channel %q", pkg, bundleName, fromChannel)
+	}
+
+	to := cfg.Channels[toIdx]
+	edges := map[string][]string{}
+	for _, e := range to.Entries {
+		if e.Name == bundleName {
+			return fmt.Errorf("package %q: bundle %q is already in channel %q", pkg, bundleName, toChannel)
+		}
+		if e.Replaces != "" {
+			edges[e.Name] = append(edges[e.Name], e.Replaces)
+		}
+		edges[e.Name] = append(edges[e.Name], e.Skips...)
+	}
+
+	entry := ChannelEntry{
+		Name:      bundleName,
+		Skips:     skips,
+		SkipRange: fromEntry.SkipRange,
+	}
+	if len(to.Entries) > 0 {
+		head, err := channelHead(to)
+		if err != nil {
+			return fmt.Errorf("package %q, channel %q: %v", pkg, toChannel, err)
+		}
+		entry.Replaces = head
+	}
+
+	// The new entry creates a cycle if it can reach itself through the
+	// bundles it replaces or skips.
+	queue, seen := append([]string{entry.Replaces}, skips...), sets.New[string]()
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == bundleName {
+			return fmt.Errorf("package %q: promoting bundle %q to channel %q would create a cycle", pkg, bundleName, toChannel)
+		}
+		if cur == "" || seen.Has(cur) {
+			continue
+		}
+		seen.Insert(cur)
+		queue = append(queue, edges[cur]...)
+	}
+
+	cfg.Channels[toIdx].Entries = append(cfg.Channels[toIdx].Entries, entry)
+	return nil
+}
@@ -0,0 +1,117 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoteBundle(t *testing.T) {
+	newCfg := func() DeclarativeConfig {
+		return DeclarativeConfig{
+			Channels: []Channel{
+				newTestChannel("foo", "candidate",
+					ChannelEntry{Name: "foo.v0.1.0"},
+					ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", SkipRange: "<0.2.0"},
+					ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+				),
+				newTestChannel("foo", "stable",
+					ChannelEntry{Name: "foo.v0.1.0"},
+				),
+				newTestChannel("foo", "empty"),
+				newTestChannel("bar", "stable",
+					ChannelEntry{Name: "bar.v0.1.0"},
+				),
+			},
+		}
+	}
+
+	type spec struct {
+		name          string
+		bundle        string
+		from, to      string
+		skips         []string
+		mod           func(*DeclarativeConfig)
+		expectEntries []ChannelEntry
+		assertion     require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:   "Success/ReplacesHead",
+			bundle: "foo.v0.2.0", from: "candidate", to: "stable",
+			expectEntries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+				{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", SkipRange: "<0.2.0"},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:   "Success/WithSkips",
+			bundle: "foo.v0.3.0", from: "candidate", to: "stable", skips: []string{"foo.v0.2.0"},
+			expectEntries: []ChannelEntry{
+				{Name: "foo.v0.1.0"},
+				{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.2.0"}},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:   "Success/EmptyChannel",
+			bundle: "foo.v0.3.0", from: "candidate", to: "empty",
+			expectEntries: []ChannelEntry{
+				{Name: "foo.v0.3.0"},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:   "Error/NotInFromChannel",
+			bundle: "foo.v0.3.0", from: "stable", to: "candidate",
+			assertion: hasError(`package "foo": bundle "foo.v0.3.0" not found in channel "stable"`),
+		},
+		{
+			name:   "Error/AlreadyInToChannel",
+			bundle: "foo.v0.1.0", from: "candidate", to: "stable",
+			assertion: hasError(`package "foo": bundle "foo.v0.1.0" is already in channel "stable"`),
+		},
+		{
+			name:   "Error/UnknownChannel",
+			bundle: "foo.v0.1.0", from: "candidate", to: "fast",
+			assertion: hasError(`package "foo": channel "fast" not found`),
+		},
+		{
+			name:   "Error/SameChannel",
+			bundle: "foo.v0.1.0", from: "candidate", to: "candidate",
+			assertion: hasError(`package "foo": cannot promote bundle "foo.v0.1.0" from channel "candidate" to itself`),
+		},
+		{
+			name:   "Error/Cycle",
+			bundle: "foo.v0.2.0", from: "candidate", to: "stable",
+			mod: func(cfg *DeclarativeConfig) {
+				cfg.Channels[1].Entries[0].Replaces = "foo.v0.2.0"
+			},
+			assertion: hasError(`package "foo": promoting bundle "foo.v0.2.0" to channel "stable" would create a cycle`),
+		},
+		{
+			name:   "Error/SkipsSelf",
+			bundle: "foo.v0.2.0", from: "candidate", to: "stable", skips: []string{"foo.v0.2.0"},
+			assertion: hasError(`package "foo": promoting bundle "foo.v0.2.0" to channel "stable" would create a cycle`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := newCfg()
+			if s.mod != nil {
+				s.mod(&cfg)
+			}
+			err := PromoteBundle(&cfg, "foo", s.bundle, s.from, s.to, s.skips...)
+			s.assertion(t, err)
+			if err != nil {
+				return
+			}
+			for _, ch := range cfg.Channels {
+				if ch.Package == "foo" && ch.Name == s.to {
+					require.Equal(t, s.expectEntries, ch.Entries)
+				}
+			}
+		})
+	}
+}
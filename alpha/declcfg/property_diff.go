@@ -0,0 +1,62 @@
+package declcfg
+
+import (
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// PropertyDiff compares the properties of two bundles, such as the bundles
+// at either end of an upgrade. Properties are compared by type and by their
+// canonicalized JSON value, so formatting and key order are ignored.
+//
+// If each bundle has exactly one property of a given type and their values
+// differ, the property of to is returned in changed. Otherwise, properties
+// of to with no equal property in from are returned in added, and
+// properties of from with no equal property in to are returned in removed.
+// Properties are returned in the order they appear in their bundle.
+func PropertyDiff(from, to Bundle) (added, removed, changed []property.Property) {
+	type key struct {
+		typ   string
+		value string
+	}
+	index := func(props []property.Property) (map[key]int, map[string]int) {
+		values := map[key]int{}
+		counts := map[string]int{}
+		for _, p := range props {
+			values[key{p.Type, canonicalPropertyValue(p)}]++
+			counts[p.Type]++
+		}
+		return values, counts
+	}
+	fromValues, fromCounts := index(from.Properties)
+	toValues, toCounts := index(to.Properties)
+	single := func(typ string) bool {
+		return fromCounts[typ] == 1 && toCounts[typ] == 1
+	}
+
+	for _, p := range to.Properties {
+		if fromValues[key{p.Type, canonicalPropertyValue(p)}] > 0 {
+			continue
+		}
+		if single(p.Type) {
+			changed = append(changed, p)
+		} else {
+			added = append(added, p)
+		}
+	}
+	for _, p := range from.Properties {
+		if toValues[key{p.Type, canonicalPropertyValue(p)}] > 0 || single(p.Type) {
+			continue
+		}
+		removed = append(removed, p)
+	}
+	return added, removed, changed
+}
+
+// canonicalPropertyValue returns the canonical JSON of p's value, or the
+// value as-is if it is not valid JSON.
+func canonicalPropertyValue(p property.Property) string {
+	if v, err := canonicalizeJSON(p.Value); err == nil {
+		return string(v)
+	}
+	return string(p.Value)
+}
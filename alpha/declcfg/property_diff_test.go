@@ -0,0 +1,53 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestPropertyDiff(t *testing.T) {
+	from := Bundle{
+		Schema:  SchemaBundle,
+		Package: "foo",
+		Name:    "foo.v0.1.0",
+		Properties: []property.Property{
+			property.MustBuildPackage("foo", "0.1.0"),
+			property.MustBuildGVKRequired("example.com", "v1", "Bar"),
+			property.MustBuildGVKRequired("example.com", "v1", "Baz"),
+			{Type: "example.com/config", Value: json.RawMessage(`{"a": 1, "b": 2}`)},
+		},
+	}
+	to := Bundle{
+		Schema:  SchemaBundle,
+		Package: "foo",
+		Name:    "foo.v0.2.0",
+		Properties: []property.Property{
+			{Type: "example.com/config", Value: json.RawMessage(`{"b":2,"a":1}`)},
+			property.MustBuildPackage("foo", "0.2.0"),
+			property.MustBuildGVKRequired("example.com", "v1", "Bar"),
+			property.MustBuildGVKRequired("example.com", "v1", "Qux"),
+			property.MustBuildGVK("example.com", "v1", "Foo"),
+		},
+	}
+
+	added, removed, changed := PropertyDiff(from, to)
+	require.Equal(t, []property.Property{
+		property.MustBuildGVKRequired("example.com", "v1", "Qux"),
+		property.MustBuildGVK("example.com", "v1", "Foo"),
+	}, added)
+	require.Equal(t, []property.Property{
+		property.MustBuildGVKRequired("example.com", "v1", "Baz"),
+	}, removed)
+	require.Equal(t, []property.Property{
+		property.MustBuildPackage("foo", "0.2.0"),
+	}, changed)
+
+	added, removed, changed = PropertyDiff(from, from)
+	require.Empty(t, added)
+	require.Empty(t, removed)
+	require.Empty(t, changed)
+}
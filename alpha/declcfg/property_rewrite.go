@@ -0,0 +1,53 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// ReplaceInPropertyValues replaces every occurrence of old with new in the
+// raw value of each package, channel, and bundle property in cfg whose type
+// is typeFilter, or of every property if typeFilter is empty. This is
+// intended for bulk, environment-specific rewrites such as swapping a
+// registry hostname.
+//
+// The replacement is byte-level, so it may produce invalid JSON; such a
+// property is left unchanged. ReplaceInPropertyValues returns the number of
+// properties that were changed. If old is empty, nothing is replaced.
+func ReplaceInPropertyValues(cfg *DeclarativeConfig, typeFilter string, old, new []byte) int {
+	if len(old) == 0 {
+		return 0
+	}
+	replace := func(props []property.Property) int {
+		n := 0
+		for i, p := range props {
+			if typeFilter != "" && p.Type != typeFilter {
+				continue
+			}
+			if !bytes.Contains(p.Value, old) {
+				continue
+			}
+			value := bytes.ReplaceAll(p.Value, old, new)
+			if bytes.Equal(value, p.Value) || !json.Valid(value) {
+				continue
+			}
+			props[i].Value = value
+			n++
+		}
+		return n
+	}
+
+	changed := 0
+	for i := range cfg.Packages {
+		changed += replace(cfg.Packages[i].Properties)
+	}
+	for i := range cfg.Channels {
+		changed += replace(cfg.Channels[i].Properties)
+	}
+	for i := range cfg.Bundles {
+		changed += replace(cfg.Bundles[i].Properties)
+	}
+	return changed
+}
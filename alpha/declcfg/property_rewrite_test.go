@@ -0,0 +1,51 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestReplaceInPropertyValues(t *testing.T) {
+	newCfg := func() DeclarativeConfig {
+		return DeclarativeConfig{
+			Packages: []Package{addPackageProperties(newTestPackage("foo", "stable", svgSmallCircle), []property.Property{
+				{Type: "example.com/mirror", Value: json.RawMessage(`{"host":"registry.example.com"}`)},
+			})},
+			Bundles: []Bundle{{
+				Schema:  SchemaBundle,
+				Package: "foo",
+				Name:    "foo.v0.1.0",
+				Properties: []property.Property{
+					{Type: "example.com/mirror", Value: json.RawMessage(`{"host":"registry.example.com","quoted":"\"registry.example.com\""}`)},
+					{Type: "example.com/other", Value: json.RawMessage(`{"host":"registry.example.com"}`)},
+				},
+			}},
+		}
+	}
+
+	t.Run("TypeFilter", func(t *testing.T) {
+		cfg := newCfg()
+		require.Equal(t, 2, ReplaceInPropertyValues(&cfg, "example.com/mirror", []byte("registry.example.com"), []byte("mirror.local")))
+		require.JSONEq(t, `{"host":"mirror.local"}`, string(cfg.Packages[0].Properties[0].Value))
+		require.JSONEq(t, `{"host":"mirror.local","quoted":"\"mirror.local\""}`, string(cfg.Bundles[0].Properties[0].Value))
+		require.JSONEq(t, `{"host":"registry.example.com"}`, string(cfg.Bundles[0].Properties[1].Value))
+	})
+	t.Run("AllTypes", func(t *testing.T) {
+		cfg := newCfg()
+		require.Equal(t, 3, ReplaceInPropertyValues(&cfg, "", []byte("registry.example.com"), []byte("mirror.local")))
+	})
+	t.Run("InvalidJSONReverted", func(t *testing.T) {
+		cfg := newCfg()
+		require.Equal(t, 0, ReplaceInPropertyValues(&cfg, "", []byte(`"host"`), []byte(`host`)))
+		require.Equal(t, newCfg(), cfg)
+	})
+	t.Run("EmptyOld", func(t *testing.T) {
+		cfg := newCfg()
+		require.Equal(t, 0, ReplaceInPropertyValues(&cfg, "", nil, []byte("x")))
+		require.Equal(t, newCfg(), cfg)
+	})
+}
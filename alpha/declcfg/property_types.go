@@ -0,0 +1,24 @@
+package declcfg
+
+// PropertyTypeHistogram counts the occurrences of each property type across
+// the packages, channels, and bundles of cfg. Property values are not
+// decoded.
+func PropertyTypeHistogram(cfg DeclarativeConfig) map[string]int {
+	counts := map[string]int{}
+	for _, p := range cfg.Packages {
+		for _, prop := range p.Properties {
+			counts[prop.Type]++
+		}
+	}
+	for _, c := range cfg.Channels {
+		for _, prop := range c.Properties {
+			counts[prop.Type]++
+		}
+	}
+	for _, b := range cfg.Bundles {
+		for _, prop := range b.Properties {
+			counts[prop.Type]++
+		}
+	}
+	return counts
+}
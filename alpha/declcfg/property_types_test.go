@@ -0,0 +1,42 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestPropertyTypeHistogram(t *testing.T) {
+	require.Empty(t, PropertyTypeHistogram(DeclarativeConfig{}))
+
+	cfg := DeclarativeConfig{
+		Packages: []Package{addPackageProperties(newTestPackage("foo", "stable", svgSmallCircle), []property.Property{
+			{Type: "example.com/owner", Value: json.RawMessage(`"team-a"`)},
+		})},
+		Channels: []Channel{addChannelProperties(newTestChannel("foo", "stable", ChannelEntry{Name: testBundleName("foo", "0.1.0")}), []property.Property{
+			property.MustBuildRecommended(testBundleName("foo", "0.1.0")),
+		})},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			{
+				Schema:  SchemaBundle,
+				Package: "foo",
+				Name:    testBundleName("foo", "0.2.0"),
+				Properties: []property.Property{
+					property.MustBuildPackage("foo", "0.2.0"),
+					// Values are not decoded, so invalid ones are still counted.
+					{Type: "example.com/owner", Value: json.RawMessage(`{`)},
+				},
+			},
+		},
+	}
+	require.Equal(t, map[string]int{
+		property.TypePackage:      2,
+		property.TypeBundleObject: 2,
+		property.TypeRecommended:  1,
+		"example.com/owner":       2,
+	}, PropertyTypeHistogram(cfg))
+}
@@ -0,0 +1,59 @@
+package declcfg
+
+import (
+	"sort"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// ProvidersOfGVK returns the bundles in cfg that provide the given group,
+// version, and kind through an olm.gvk property. The bundles are sorted by
+// package and then by version; bundles without a valid version are sorted
+// after those with one, and ties are broken by name. Bundles whose
+// properties cannot be parsed are ignored.
+func ProvidersOfGVK(cfg DeclarativeConfig, group, version, kind string) []Bundle {
+	want := property.GVK{Group: group, Version: version, Kind: kind}
+	type provider struct {
+		bundle  Bundle
+		version *semver.Version
+	}
+	var providers []provider
+	for i := range cfg.Bundles {
+		props, err := property.Parse(cfg.Bundles[i].Properties)
+		if err != nil {
+			continue
+		}
+		for _, gvk := range props.GVKs {
+			if gvk == want {
+				v, _ := parseVersionProperty(&cfg.Bundles[i])
+				providers = append(providers, provider{cfg.Bundles[i], v})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(providers, func(i, j int) bool {
+		a, b := providers[i], providers[j]
+		if a.bundle.Package != b.bundle.Package {
+			return a.bundle.Package < b.bundle.Package
+		}
+		switch {
+		case a.version != nil && b.version != nil:
+			if c := a.version.Compare(*b.version); c != 0 {
+				return c < 0
+			}
+		case a.version != nil:
+			return true
+		case b.version != nil:
+			return false
+		}
+		return a.bundle.Name < b.bundle.Name
+	})
+	out := make([]Bundle, 0, len(providers))
+	for _, p := range providers {
+		out = append(out, p.bundle)
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestProvidersOfGVK(t *testing.T) {
+	withGVK := func(kind string) func(*Bundle) {
+		return func(b *Bundle) {
+			b.Properties = append(b.Properties, property.MustBuildGVK("example.com", "v1", kind))
+		}
+	}
+	noVersion := func(b *Bundle) {
+		b.Properties = []property.Property{property.MustBuildGVK("example.com", "v1", "Foo")}
+	}
+	cfg := DeclarativeConfig{Bundles: []Bundle{
+		newTestBundle("foo", "0.10.0", withGVK("Foo")),
+		newTestBundle("foo", "0.2.0", withGVK("Foo")),
+		newTestBundle("foo", "0.3.0", withGVK("Bar")),
+		newTestBundle("foo", "0.4.0", noVersion),
+		newTestBundle("bar", "1.0.0", withGVK("Foo"), withGVK("Bar")),
+	}}
+
+	names := func(bundles []Bundle) []string {
+		var out []string
+		for _, b := range bundles {
+			out = append(out, b.Name)
+		}
+		return out
+	}
+	require.Equal(t, []string{"bar.v1.0.0", "foo.v0.2.0", "foo.v0.10.0", "foo.v0.4.0"}, names(ProvidersOfGVK(cfg, "example.com", "v1", "Foo")))
+	require.Equal(t, []string{"bar.v1.0.0", "foo.v0.3.0"}, names(ProvidersOfGVK(cfg, "example.com", "v1", "Bar")))
+	require.Empty(t, ProvidersOfGVK(cfg, "example.com", "v2", "Foo"))
+}
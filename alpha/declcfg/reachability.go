@@ -0,0 +1,103 @@
+package declcfg
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+)
+
+// Reachability describes how upgrades within a channel lead to a bundle.
+type Reachability string
+
+const (
+	// ReachableViaReplaces means the bundle replaces another entry of the
+	// channel.
+	ReachableViaReplaces Reachability = "replaces"
+
+	// ReachableViaSkips means the bundle replaces no entry of the channel,
+	// but skips at least one.
+	ReachableViaSkips Reachability = "skips"
+
+	// ReachableViaSkipRangeOnly means the bundle neither replaces nor skips
+	// an entry of the channel, but its skipRange includes the version of at
+	// least one other entry.
+	ReachableViaSkipRangeOnly Reachability = "skipRange"
+
+	// Unreachable means no other entry of the channel upgrades to the
+	// bundle. This is expected for the oldest bundle of a channel, which
+	// can only be installed directly.
+	Unreachable Reachability = "unreachable"
+)
+
+// ChannelReachability classifies every entry of every channel in cfg by the
+// strongest kind of upgrade edge that leads to it from another entry of the
+// same channel, in the order replaces, skips, skipRange. The result maps
+// package name to channel name to entry name.
+//
+// Versions used to match skipRanges come from the bundles' olm.package
+// properties. Entries whose bundle has no valid version cannot be matched
+// by a skipRange. An error is returned if an entry has an invalid
+// skipRange.
+func ChannelReachability(cfg DeclarativeConfig) (map[string]map[string]map[string]Reachability, error) {
+	type key struct {
+		pkg  string
+		name string
+	}
+	versions := map[key]semver.Version{}
+	for i := range cfg.Bundles {
+		if v, err := parseVersionProperty(&cfg.Bundles[i]); err == nil {
+			versions[key{cfg.Bundles[i].Package, cfg.Bundles[i].Name}] = *v
+		}
+	}
+
+	out := map[string]map[string]map[string]Reachability{}
+	for _, ch := range cfg.Channels {
+		entries := map[string]struct{}{}
+		for _, e := range ch.Entries {
+			entries[e.Name] = struct{}{}
+		}
+		isOtherEntry := func(e ChannelEntry, name string) bool {
+			_, ok := entries[name]
+			return ok && name != e.Name
+		}
+
+		classes := make(map[string]Reachability, len(ch.Entries))
+		for _, e := range ch.Entries {
+			class := Unreachable
+			switch {
+			case isOtherEntry(e, e.Replaces):
+				class = ReachableViaReplaces
+			case anyOtherEntry(e, e.Skips, isOtherEntry):
+				class = ReachableViaSkips
+			case e.SkipRange != "":
+				r, err := semver.ParseRange(e.SkipRange)
+				if err != nil {
+					return nil, fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", ch.Package, ch.Name, e.Name, e.SkipRange, err)
+				}
+				for _, other := range ch.Entries {
+					v, ok := versions[key{ch.Package, other.Name}]
+					if ok && other.Name != e.Name && r(v) {
+						class = ReachableViaSkipRangeOnly
+						break
+					}
+				}
+			}
+			classes[e.Name] = class
+		}
+
+		if _, ok := out[ch.Package]; !ok {
+			out[ch.Package] = map[string]map[string]Reachability{}
+		}
+		out[ch.Package][ch.Name] = classes
+	}
+	return out, nil
+}
+
+func anyOtherEntry(e ChannelEntry, names []string, isOtherEntry func(ChannelEntry, string) bool) bool {
+	for _, n := range names {
+		if isOtherEntry(e, n) {
+			return true
+		}
+	}
+	return false
+}
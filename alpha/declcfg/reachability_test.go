@@ -0,0 +1,52 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelReachability(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+				ChannelEntry{Name: testBundleName("foo", "0.3.0"), Skips: []string{testBundleName("foo", "0.2.0")}},
+				ChannelEntry{Name: testBundleName("foo", "0.4.0"), SkipRange: "<0.4.0"},
+				ChannelEntry{Name: testBundleName("foo", "0.5.0"), Replaces: testBundleName("foo", "0.0.1"), SkipRange: ">=1.0.0"},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: testBundleName("foo", "0.4.0"), SkipRange: "<0.4.0"},
+			),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.3.0"),
+			newTestBundle("foo", "0.4.0"),
+			newTestBundle("foo", "0.5.0"),
+		},
+	}
+
+	actual, err := ChannelReachability(cfg)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]map[string]Reachability{
+		"foo": {
+			"stable": {
+				testBundleName("foo", "0.1.0"): Unreachable,
+				testBundleName("foo", "0.2.0"): ReachableViaReplaces,
+				testBundleName("foo", "0.3.0"): ReachableViaSkips,
+				testBundleName("foo", "0.4.0"): ReachableViaSkipRangeOnly,
+				testBundleName("foo", "0.5.0"): Unreachable,
+			},
+			"fast": {
+				testBundleName("foo", "0.4.0"): Unreachable,
+			},
+		},
+	}, actual)
+
+	cfg.Channels[1].Entries[0].SkipRange = "not a range"
+	_, err = ChannelReachability(cfg)
+	require.ErrorContains(t, err, `package "foo", channel "fast": entry "foo.v0.4.0" has invalid skipRange "not a range"`)
+}
@@ -0,0 +1,87 @@
+package declcfg
+
+import (
+	"fmt"
+)
+
+// RemoveBundle deletes bundleName from package pkg and rewires every channel
+// that contains it so that the rest of the upgrade graph is preserved:
+// entries that replaced the removed bundle instead replace the bundle it
+// replaced, and the removed bundle is dropped from all skips. If the removed
+// bundle was a channel head, the bundle it replaced becomes the new head.
+//
+// An error is returned, and cfg is left unmodified, if the bundle does not
+// exist, if a channel would be left with no entries, or if a channel would
+// end up with more heads than before, which happens when the removed bundle
+// was the only link between parts of the channel's upgrade graph.
+func RemoveBundle(cfg *DeclarativeConfig, pkg, bundleName string) error {
+	bundleIdx := -1
+	for i, b := range cfg.Bundles {
+		if b.Package == pkg && b.Name == bundleName {
+			bundleIdx = i
+			break
+		}
+	}
+	if bundleIdx < 0 {
+		return fmt.Errorf("package %q: bundle %q not found", pkg, bundleName)
+	}
+
+	rewired := map[int]Channel{}
+	for i, ch := range cfg.Channels {
+		if ch.Package != pkg {
+			continue
+		}
+		removedIdx := -1
+		for j, e := range ch.Entries {
+			if e.Name == bundleName {
+				removedIdx = j
+				break
+			}
+		}
+		if removedIdx < 0 {
+			continue
+		}
+		if len(ch.Entries) == 1 {
+			return fmt.Errorf("package %q, channel %q: removing bundle %q would leave the channel empty", pkg, ch.Name, bundleName)
+		}
+
+		replaces := ch.Entries[removedIdx].Replaces
+		entries := make([]ChannelEntry, 0, len(ch.Entries)-1)
+		for j, e := range ch.Entries {
+			if j == removedIdx {
+				continue
+			}
+			if e.Replaces == bundleName {
+				e.Replaces = replaces
+			}
+			if len(e.Skips) > 0 {
+				skips := make([]string, 0, len(e.Skips))
+				for _, s := range e.Skips {
+					if s != bundleName {
+						skips = append(skips, s)
+					}
+				}
+				if len(skips) == 0 {
+					skips = nil
+				}
+				e.Skips = skips
+			}
+			entries = append(entries, e)
+		}
+		newCh := ch
+		newCh.Entries = entries
+
+		if before, after := channelHeads(ch), channelHeads(newCh); len(after) > len(before) {
+			return fmt.Errorf("package %q, channel %q: removing bundle %q would disconnect the channel, leaving heads %s", pkg, ch.Name, bundleName, quotedList(after))
+		}
+		rewired[i] = newCh
+	}
+
+	for i, ch := range rewired {
+		cfg.Channels[i] = ch
+	}
+	// Limit the capacity so that the remaining bundles are copied rather
+	// than shifted within a backing array that may be shared.
+	cfg.Bundles = append(cfg.Bundles[:bundleIdx:bundleIdx], cfg.Bundles[bundleIdx+1:]...)
+	return nil
+}
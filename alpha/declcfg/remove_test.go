@@ -0,0 +1,148 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveBundle(t *testing.T) {
+	bundles := func(pkg string, names ...string) []Bundle {
+		var out []Bundle
+		for _, n := range names {
+			out = append(out, Bundle{Schema: SchemaBundle, Package: pkg, Name: n})
+		}
+		return out
+	}
+
+	type spec struct {
+		name      string
+		cfg       DeclarativeConfig
+		pkg       string
+		bundle    string
+		expected  DeclarativeConfig
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/MiddleOfChain",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v2", Replaces: "foo.v1"},
+						ChannelEntry{Name: "foo.v3", Replaces: "foo.v2", Skips: []string{"foo.v2"}},
+					),
+					newTestChannel("foo", "fast",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v3", Replaces: "foo.v1"},
+					),
+					newTestChannel("bar", "stable", ChannelEntry{Name: "foo.v2"}),
+				},
+				Bundles: append(bundles("foo", "foo.v1", "foo.v2", "foo.v3"), bundles("bar", "foo.v2")...),
+			},
+			pkg:    "foo",
+			bundle: "foo.v2",
+			expected: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v3", Replaces: "foo.v1"},
+					),
+					newTestChannel("foo", "fast",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v3", Replaces: "foo.v1"},
+					),
+					newTestChannel("bar", "stable", ChannelEntry{Name: "foo.v2"}),
+				},
+				Bundles: append(bundles("foo", "foo.v1", "foo.v3"), bundles("bar", "foo.v2")...),
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/Head",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v2", Replaces: "foo.v1"},
+					),
+				},
+				Bundles: bundles("foo", "foo.v1", "foo.v2"),
+			},
+			pkg:    "foo",
+			bundle: "foo.v2",
+			expected: DeclarativeConfig{
+				Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v1"})},
+				Bundles:  bundles("foo", "foo.v1"),
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/Tail",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v2", Replaces: "foo.v1"},
+					),
+				},
+				Bundles: bundles("foo", "foo.v1", "foo.v2"),
+			},
+			pkg:    "foo",
+			bundle: "foo.v1",
+			expected: DeclarativeConfig{
+				Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v2"})},
+				Bundles:  bundles("foo", "foo.v2"),
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/NotFound",
+			cfg: DeclarativeConfig{
+				Bundles: bundles("bar", "foo.v1"),
+			},
+			pkg:       "foo",
+			bundle:    "foo.v1",
+			assertion: hasError(`package "foo": bundle "foo.v1" not found`),
+		},
+		{
+			name: "Error/EmptyChannel",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v1"})},
+				Bundles:  bundles("foo", "foo.v1"),
+			},
+			pkg:       "foo",
+			bundle:    "foo.v1",
+			assertion: hasError(`package "foo", channel "stable": removing bundle "foo.v1" would leave the channel empty`),
+		},
+		{
+			name: "Error/Disconnect",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v1"},
+						ChannelEntry{Name: "foo.v2", Replaces: "foo.v1"},
+						ChannelEntry{Name: "foo.v3", Skips: []string{"foo.v2"}},
+					),
+				},
+				Bundles: bundles("foo", "foo.v1", "foo.v2", "foo.v3"),
+			},
+			pkg:       "foo",
+			bundle:    "foo.v2",
+			assertion: hasError(`package "foo", channel "stable": removing bundle "foo.v2" would disconnect the channel, leaving heads "foo.v1", "foo.v3"`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := s.cfg
+			err := RemoveBundle(&cfg, s.pkg, s.bundle)
+			s.assertion(t, err)
+			if err != nil {
+				require.Equal(t, s.cfg, cfg)
+				return
+			}
+			require.Equal(t, s.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package declcfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// RetentionPolicy describes which bundles ApplyRetention keeps. When more
+// than one field is set, a bundle must satisfy all of them to be kept.
+type RetentionPolicy struct {
+	// KeepLatest, if greater than zero, keeps only the KeepLatest highest
+	// versions of each channel.
+	KeepLatest int
+
+	// KeepNewerThan, if set, keeps only bundles whose version is greater
+	// than KeepNewerThan.
+	KeepNewerThan *semver.Version
+}
+
+// ApplyRetention prunes cfg according to policy and returns the bundles that
+// were removed, in the order they appeared in cfg.
+//
+// The policy is evaluated separately for each channel. The head of every
+// channel, and therefore the head of each package's default channel, is
+// always kept. A bundle is only removed from cfg if no channel retains it;
+// otherwise it is just removed from the entries of the channels that do not
+// retain it.
+//
+// Channels are rewired so the retained entries stay connected: replaces
+// edges that pointed at pruned entries are redirected to the nearest retained
+// entry further down the replaces chain, skips of pruned entries are dropped,
+// and retained entries that were only reachable through pruned entries are
+// added to the skips of the nearest retained entry above them.
+func ApplyRetention(cfg *DeclarativeConfig, policy RetentionPolicy) ([]Bundle, error) {
+	type bundleKey struct {
+		pkg  string
+		name string
+	}
+	versions := map[bundleKey]semver.Version{}
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		v, err := parseVersionProperty(b)
+		if err != nil {
+			return nil, fmt.Errorf("package %q: %v", b.Package, err)
+		}
+		versions[bundleKey{b.Package, b.Name}] = *v
+	}
+
+	retained := map[string]sets.Set[string]{}
+	inChannels := map[string]sets.Set[string]{}
+	newChannels := make([]Channel, len(cfg.Channels))
+	for i, ch := range cfg.Channels {
+		keep, err := retainChannelEntries(ch, policy, func(name string) (semver.Version, bool) {
+			v, ok := versions[bundleKey{ch.Package, name}]
+			return v, ok
+		})
+		if err != nil {
+			return nil, fmt.Errorf("package %q, channel %q: %v", ch.Package, ch.Name, err)
+		}
+		newChannels[i] = rewireChannel(ch, keep)
+
+		if _, ok := retained[ch.Package]; !ok {
+			retained[ch.Package] = sets.New[string]()
+			inChannels[ch.Package] = sets.New[string]()
+		}
+		retained[ch.Package].Insert(sets.List(keep)...)
+		for _, e := range ch.Entries {
+			inChannels[ch.Package].Insert(e.Name)
+		}
+	}
+
+	var (
+		removed []Bundle
+		kept    []Bundle
+	)
+	for _, b := range cfg.Bundles {
+		if inChannels[b.Package].Has(b.Name) && !retained[b.Package].Has(b.Name) {
+			removed = append(removed, b)
+			continue
+		}
+		kept = append(kept, b)
+	}
+	cfg.Channels = newChannels
+	cfg.Bundles = kept
+	return removed, nil
+}
+
+// retainChannelEntries returns the names of the entries of ch that policy
+// retains, always including the channel head.
+func retainChannelEntries(ch Channel, policy RetentionPolicy, versionOf func(string) (semver.Version, bool)) (sets.Set[string], error) {
+	head, err := channelHead(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	type entryVersion struct {
+		name    string
+		version semver.Version
+	}
+	var entries []entryVersion
+	for _, e := range ch.Entries {
+		v, ok := versionOf(e.Name)
+		if !ok {
+			return nil, fmt.Errorf("no bundle found for entry %q", e.Name)
+		}
+		entries = append(entries, entryVersion{e.Name, v})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].version.GT(entries[j].version)
+	})
+
+	keep := sets.New[string](head)
+	for i, e := range entries {
+		if policy.KeepLatest > 0 && i >= policy.KeepLatest {
+			break
+		}
+		if policy.KeepNewerThan != nil && !e.version.GT(*policy.KeepNewerThan) {
+			continue
+		}
+		keep.Insert(e.name)
+	}
+	return keep, nil
+}
+
+// rewireChannel returns a copy of ch containing only the entries in keep,
+// with upgrade edges rewired around the entries that were dropped.
+func rewireChannel(ch Channel, keep sets.Set[string]) Channel {
+	entries := map[string]ChannelEntry{}
+	replacedBy := map[string][]string{}
+	for _, e := range ch.Entries {
+		entries[e.Name] = e
+		if e.Replaces != "" {
+			replacedBy[e.Replaces] = append(replacedBy[e.Replaces], e.Name)
+		}
+		for _, s := range e.Skips {
+			replacedBy[s] = append(replacedBy[s], e.Name)
+		}
+	}
+	pruned := func(name string) bool {
+		_, inChannel := entries[name]
+		return inChannel && !keep.Has(name)
+	}
+
+	out := ch
+	out.Entries = nil
+	for _, e := range ch.Entries {
+		if !keep.Has(e.Name) {
+			continue
+		}
+		ne := ChannelEntry{Name: e.Name, SkipRange: e.SkipRange}
+
+		// Follow the replaces chain through pruned entries. If the chain
+		// ends at a pruned entry that replaces nothing, drop the edge.
+		replaces, seen := e.Replaces, sets.New[string]()
+		for pruned(replaces) && !seen.Has(replaces) {
+			seen.Insert(replaces)
+			replaces = entries[replaces].Replaces
+		}
+		if !pruned(replaces) {
+			ne.Replaces = replaces
+		}
+		for _, s := range e.Skips {
+			if !pruned(s) {
+				ne.Skips = append(ne.Skips, s)
+			}
+		}
+		out.Entries = append(out.Entries, ne)
+	}
+
+	newEntries := map[string]int{}
+	for i, e := range out.Entries {
+		newEntries[e.Name] = i
+	}
+
+	incoming := sets.New[string]()
+	for _, e := range out.Entries {
+		incoming.Insert(e.Replaces)
+		incoming.Insert(e.Skips...)
+	}
+	for _, e := range out.Entries {
+		if incoming.Has(e.Name) || len(replacedBy[e.Name]) == 0 {
+			continue
+		}
+		// e was only reachable through pruned entries. Find the nearest
+		// retained entries above it and have them skip e instead.
+		queue, seen := append([]string(nil), replacedBy[e.Name]...), sets.New[string]()
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			if seen.Has(cur) {
+				continue
+			}
+			seen.Insert(cur)
+			if i, ok := newEntries[cur]; ok {
+				out.Entries[i].Skips = append(out.Entries[i].Skips, e.Name)
+				continue
+			}
+			queue = append(queue, replacedBy[cur]...)
+		}
+	}
+	return out
+}
+
+// channelHead returns the name of the single entry of ch that no other entry
+// replaces or skips.
+func channelHead(ch Channel) (string, error) {
+	heads := channelHeads(ch)
+	if len(heads) == 0 {
+		return "", fmt.Errorf("no channel head found in graph")
+	}
+	if len(heads) > 1 {
+		sort.Strings(heads)
+		return "", fmt.Errorf("multiple channel heads found in graph: %s", strings.Join(heads, ", "))
+	}
+	return heads[0], nil
+}
+
+// channelHeads returns the entries of ch that are not replaced or skipped by
+// any other entry, in the order they appear in ch.
+func channelHeads(ch Channel) []string {
+	incoming := sets.New[string]()
+	for _, e := range ch.Entries {
+		incoming.Insert(e.Replaces)
+		incoming.Insert(e.Skips...)
+	}
+	var heads []string
+	for _, e := range ch.Entries {
+		if !incoming.Has(e.Name) {
+			heads = append(heads, e.Name)
+		}
+	}
+	return heads
+}
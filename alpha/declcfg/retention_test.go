@@ -0,0 +1,112 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRetention(t *testing.T) {
+	newCfg := func() DeclarativeConfig {
+		return DeclarativeConfig{
+			Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			Channels: []Channel{
+				newTestChannel("foo", "stable",
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.3.0"), Replaces: testBundleName("foo", "0.2.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.4.0"), Replaces: testBundleName("foo", "0.3.0")},
+				),
+				newTestChannel("foo", "candidate",
+					ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+					ChannelEntry{Name: testBundleName("foo", "0.5.0"), Replaces: testBundleName("foo", "0.1.0")},
+				),
+			},
+			Bundles: []Bundle{
+				newTestBundle("foo", "0.1.0"),
+				newTestBundle("foo", "0.2.0"),
+				newTestBundle("foo", "0.3.0"),
+				newTestBundle("foo", "0.4.0"),
+				newTestBundle("foo", "0.5.0"),
+			},
+		}
+	}
+	removedNames := func(bundles []Bundle) []string {
+		var names []string
+		for _, b := range bundles {
+			names = append(names, b.Name)
+		}
+		return names
+	}
+
+	t.Run("KeepLatest", func(t *testing.T) {
+		cfg := newCfg()
+		removed, err := ApplyRetention(&cfg, RetentionPolicy{KeepLatest: 2})
+		require.NoError(t, err)
+
+		// foo.v0.1.0 is pruned from stable, but candidate retains it.
+		require.Equal(t, []string{testBundleName("foo", "0.2.0")}, removedNames(removed))
+		require.Len(t, cfg.Bundles, 4)
+		require.Equal(t, []ChannelEntry{
+			{Name: testBundleName("foo", "0.3.0")},
+			{Name: testBundleName("foo", "0.4.0"), Replaces: testBundleName("foo", "0.3.0")},
+		}, cfg.Channels[0].Entries)
+		require.Equal(t, newCfg().Channels[1].Entries, cfg.Channels[1].Entries)
+	})
+
+	t.Run("KeepNewerThan", func(t *testing.T) {
+		cfg := newCfg()
+		v := semver.MustParse("0.3.0")
+		removed, err := ApplyRetention(&cfg, RetentionPolicy{KeepNewerThan: &v})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			testBundleName("foo", "0.1.0"),
+			testBundleName("foo", "0.2.0"),
+			testBundleName("foo", "0.3.0"),
+		}, removedNames(removed))
+		require.Equal(t, []ChannelEntry{{Name: testBundleName("foo", "0.4.0")}}, cfg.Channels[0].Entries)
+		require.Equal(t, []ChannelEntry{{Name: testBundleName("foo", "0.5.0")}}, cfg.Channels[1].Entries)
+	})
+
+	t.Run("HeadAlwaysKept", func(t *testing.T) {
+		cfg := newCfg()
+		v := semver.MustParse("1.0.0")
+		_, err := ApplyRetention(&cfg, RetentionPolicy{KeepNewerThan: &v})
+		require.NoError(t, err)
+		require.Equal(t, []ChannelEntry{{Name: testBundleName("foo", "0.4.0")}}, cfg.Channels[0].Entries)
+		require.Equal(t, []ChannelEntry{{Name: testBundleName("foo", "0.5.0")}}, cfg.Channels[1].Entries)
+	})
+
+	t.Run("RewireThroughSkips", func(t *testing.T) {
+		// 0.4.0 is only reachable from the head through 0.2.0, which is pruned.
+		cfg := newCfg()
+		cfg.Channels = []Channel{newTestChannel("foo", "stable",
+			ChannelEntry{Name: testBundleName("foo", "0.4.0")},
+			ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.4.0")},
+			ChannelEntry{Name: testBundleName("foo", "0.5.0"), Skips: []string{testBundleName("foo", "0.2.0")}},
+		)}
+		cfg.Bundles = []Bundle{newTestBundle("foo", "0.2.0"), newTestBundle("foo", "0.4.0"), newTestBundle("foo", "0.5.0")}
+		removed, err := ApplyRetention(&cfg, RetentionPolicy{KeepLatest: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{testBundleName("foo", "0.2.0")}, removedNames(removed))
+		require.Equal(t, []ChannelEntry{
+			{Name: testBundleName("foo", "0.4.0")},
+			{Name: testBundleName("foo", "0.5.0"), Skips: []string{testBundleName("foo", "0.4.0")}},
+		}, cfg.Channels[0].Entries)
+	})
+
+	t.Run("Error/MultipleHeads", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.Channels[0].Entries = append(cfg.Channels[0].Entries, ChannelEntry{Name: testBundleName("foo", "0.5.0")})
+		_, err := ApplyRetention(&cfg, RetentionPolicy{KeepLatest: 1})
+		require.EqualError(t, err, `package "foo", channel "stable": multiple channel heads found in graph: foo.v0.4.0, foo.v0.5.0`)
+	})
+
+	t.Run("Error/MissingBundle", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.Bundles = cfg.Bundles[1:]
+		_, err := ApplyRetention(&cfg, RetentionPolicy{KeepLatest: 1})
+		require.EqualError(t, err, `package "foo", channel "stable": no bundle found for entry "foo.v0.1.0"`)
+	})
+}
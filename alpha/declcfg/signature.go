@@ -0,0 +1,255 @@
+package declcfg
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// SchemaSignature is the schema of objects in Others that hold a signature
+// of the rest of the catalog.
+const SchemaSignature = "olm.signature"
+
+// Signature algorithms, named after the type of key that creates them.
+// ECDSA and RSA (PKCS #1 v1.5) signatures are computed over the SHA-256
+// digest of the canonical catalog bytes, and Ed25519 signatures over the
+// bytes themselves.
+const (
+	SignatureAlgorithmECDSA   = "ecdsa-sha256"
+	SignatureAlgorithmRSA     = "rsa-pkcs1v15-sha256"
+	SignatureAlgorithmEd25519 = "ed25519"
+)
+
+// ErrNoSignature is returned by VerifySignature when the catalog has no
+// olm.signature objects.
+var ErrNoSignature = errors.New("catalog has no signature")
+
+// CatalogSignature is the content of an olm.signature object.
+type CatalogSignature struct {
+	Schema    string `json:"schema"`
+	Algorithm string `json:"algorithm"`
+	Signature []byte `json:"signature"`
+}
+
+// CanonicalCatalogBytes returns the bytes that a catalog signature covers:
+// cfg, without its olm.signature objects, written as compact JSON with
+// property values and Others blobs in canonical JSON form, properties
+// sorted by type and value, related images sorted, and objects in the
+// order used by WriteJSON. Objects in Others with the same package and
+// schema are ordered by their canonical blobs. The result does not depend
+// on formatting, key order, or the order of properties, related images,
+// and objects, so it survives reserialization. The order of channel entries
+// and skips is significant.
+//
+// An error is returned if cfg has a package without a name, or a channel or
+// bundle without a package. The writers omit such objects, so they could
+// not be covered by a signature.
+func CanonicalCatalogBytes(cfg DeclarativeConfig) ([]byte, error) {
+	if err := checkSignable(cfg); err != nil {
+		return nil, err
+	}
+
+	out := cfg
+	out.Others = nil
+	for _, o := range cfg.Others {
+		if o.Schema == SchemaSignature {
+			continue
+		}
+		blob, err := canonicalizeJSON(o.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("canonicalize %s object %q: %v", o.Schema, o.Name, err)
+		}
+		o.Blob = blob
+		out.Others = append(out.Others, o)
+	}
+	sort.SliceStable(out.Others, func(i, j int) bool {
+		return bytes.Compare(out.Others[i].Blob, out.Others[j].Blob) < 0
+	})
+
+	out.Packages = make([]Package, len(cfg.Packages))
+	for i, p := range cfg.Packages {
+		p.Properties = sortedProperties(p.Properties)
+		out.Packages[i] = p
+	}
+	out.Channels = make([]Channel, len(cfg.Channels))
+	for i, c := range cfg.Channels {
+		c.Properties = sortedProperties(c.Properties)
+		out.Channels[i] = c
+	}
+	out.Bundles = make([]Bundle, len(cfg.Bundles))
+	for i, b := range cfg.Bundles {
+		b.Properties = sortedProperties(b.Properties)
+		out.Bundles[i] = b
+	}
+
+	var buf bytes.Buffer
+	if err := WithCanonicalProperties(WithSortedRelatedImages(WriteCompactJSON))(out, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkSignable reports the objects of cfg that writeToEncoder omits.
+func checkSignable(cfg DeclarativeConfig) error {
+	var errs []error
+	for i, p := range cfg.Packages {
+		if p.Name == "" {
+			errs = append(errs, fmt.Errorf("package %d has no name", i))
+		}
+	}
+	for _, c := range cfg.Channels {
+		if c.Package == "" {
+			errs = append(errs, fmt.Errorf("channel %q has no package", c.Name))
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if b.Package == "" {
+			errs = append(errs, fmt.Errorf("bundle %q has no package", b.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot compute canonical catalog bytes: %v", utilerrors.NewAggregate(errs))
+	}
+	return nil
+}
+
+// sortedProperties returns a copy of in sorted by type and then by
+// canonical value.
+func sortedProperties(in []property.Property) []property.Property {
+	if in == nil {
+		return nil
+	}
+	type keyed struct {
+		p     property.Property
+		value []byte
+	}
+	ks := make([]keyed, 0, len(in))
+	for _, p := range in {
+		v, err := canonicalizeJSON(p.Value)
+		if err != nil {
+			v = p.Value
+		}
+		ks = append(ks, keyed{p, v})
+	}
+	sort.SliceStable(ks, func(i, j int) bool {
+		if ks[i].p.Type != ks[j].p.Type {
+			return ks[i].p.Type < ks[j].p.Type
+		}
+		return bytes.Compare(ks[i].value, ks[j].value) < 0
+	})
+	out := make([]property.Property, 0, len(in))
+	for _, k := range ks {
+		out = append(out, k.p)
+	}
+	return out
+}
+
+// SignCatalog signs the canonical bytes of cfg, as returned by
+// CanonicalCatalogBytes, with signer and returns an olm.signature object
+// that can be added to cfg.Others. Existing signatures are not covered, so
+// a catalog may carry several independent signatures. The signer's key must
+// be an ECDSA, RSA, or Ed25519 key.
+func SignCatalog(cfg DeclarativeConfig, signer crypto.Signer) (*Meta, error) {
+	algorithm, err := signatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	data, err := CanonicalCatalogBytes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var sig []byte
+	if algorithm == SignatureAlgorithmEd25519 {
+		sig, err = signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(data)
+		sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sign catalog: %v", err)
+	}
+	blob, err := json.Marshal(CatalogSignature{Schema: SchemaSignature, Algorithm: algorithm, Signature: sig})
+	if err != nil {
+		return nil, err
+	}
+	return &Meta{Schema: SchemaSignature, Blob: blob}, nil
+}
+
+// VerifySignature checks that at least one olm.signature object in cfg is a
+// valid signature of the canonical bytes of cfg by the private key of pub,
+// which must be an *ecdsa.PublicKey, *rsa.PublicKey, or ed25519.PublicKey.
+// The returned error wraps ErrNoSignature if cfg has no signatures;
+// otherwise, if no signature is valid, it describes why each one failed.
+func VerifySignature(cfg DeclarativeConfig, pub crypto.PublicKey) error {
+	algorithm, err := signatureAlgorithm(pub)
+	if err != nil {
+		return err
+	}
+
+	var sigs []CatalogSignature
+	for i, o := range cfg.Others {
+		if o.Schema != SchemaSignature {
+			continue
+		}
+		var sig CatalogSignature
+		if err := json.Unmarshal(o.Blob, &sig); err != nil {
+			return fmt.Errorf("parse %s object %d: %v", SchemaSignature, i, err)
+		}
+		sigs = append(sigs, sig)
+	}
+	if len(sigs) == 0 {
+		return ErrNoSignature
+	}
+
+	data, err := CanonicalCatalogBytes(cfg)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(data)
+
+	var errs []error
+	for i, sig := range sigs {
+		if sig.Algorithm != algorithm {
+			errs = append(errs, fmt.Errorf("signature %d: algorithm %q does not match %q key", i, sig.Algorithm, algorithm))
+			continue
+		}
+		var valid bool
+		switch key := pub.(type) {
+		case *ecdsa.PublicKey:
+			valid = ecdsa.VerifyASN1(key, digest[:], sig.Signature)
+		case *rsa.PublicKey:
+			valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig.Signature) == nil
+		case ed25519.PublicKey:
+			valid = ed25519.Verify(key, data, sig.Signature)
+		}
+		if valid {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("signature %d: verification failed", i))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func signatureAlgorithm(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case *ecdsa.PublicKey:
+		return SignatureAlgorithmECDSA, nil
+	case *rsa.PublicKey:
+		return SignatureAlgorithmRSA, nil
+	case ed25519.PublicKey:
+		return SignatureAlgorithmEd25519, nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", pub)
+}
@@ -0,0 +1,60 @@
+package declcfg
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, signer := range []crypto.Signer{edKey, ecKey} {
+		cfg := buildValidDeclarativeConfig(true)
+		require.ErrorIs(t, VerifySignature(cfg, signer.Public()), ErrNoSignature)
+
+		sig, err := SignCatalog(cfg, signer)
+		require.NoError(t, err)
+		cfg.Others = append(cfg.Others, *sig)
+		require.NoError(t, VerifySignature(cfg, signer.Public()))
+
+		// The signature survives reserialization and reordering.
+		var buf bytes.Buffer
+		require.NoError(t, WriteYAML(cfg, &buf))
+		reloaded, err := LoadReader(&buf)
+		require.NoError(t, err)
+		b := &reloaded.Bundles[0]
+		b.Properties[0], b.Properties[len(b.Properties)-1] = b.Properties[len(b.Properties)-1], b.Properties[0]
+		reloaded.Others[0], reloaded.Others[len(reloaded.Others)-1] = reloaded.Others[len(reloaded.Others)-1], reloaded.Others[0]
+		require.NoError(t, VerifySignature(*reloaded, signer.Public()))
+
+		// Changing the content invalidates the signature.
+		reloaded.Bundles[0].Image = "quay.io/example/tampered:latest"
+		require.EqualError(t, VerifySignature(*reloaded, signer.Public()), "signature 0: verification failed")
+	}
+
+	cfg := buildValidDeclarativeConfig(true)
+	sig, err := SignCatalog(cfg, edKey)
+	require.NoError(t, err)
+	cfg.Others = append(cfg.Others, *sig)
+	require.EqualError(t, VerifySignature(cfg, otherKey.Public()), "signature 0: verification failed")
+	require.EqualError(t, VerifySignature(cfg, ecKey.Public()), `signature 0: algorithm "ed25519" does not match "ecdsa-sha256" key`)
+
+	// Objects the writers omit would not be covered by the signature.
+	unsigned := newTestBundle("", "0.1.0")
+	cfg.Bundles = append(cfg.Bundles, unsigned)
+	require.EqualError(t, VerifySignature(cfg, edKey.Public()), `cannot compute canonical catalog bytes: bundle ".v0.1.0" has no package`)
+	_, err = SignCatalog(cfg, edKey)
+	require.EqualError(t, err, `cannot compute canonical catalog bytes: bundle ".v0.1.0" has no package`)
+}
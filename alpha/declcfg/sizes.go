@@ -0,0 +1,42 @@
+package declcfg
+
+import (
+	"encoding/json"
+)
+
+// Identity identifies a single object in a DeclarativeConfig. For packages,
+// Package and Name are both the package name.
+type Identity struct {
+	Schema  string
+	Package string
+	Name    string
+}
+
+// ObjectSizes returns the size in bytes of the compact JSON serialization of
+// each package, channel, bundle, and other object in cfg. Sizes of objects
+// that share an identity (for example, multiple unnamed objects of the same
+// schema in a package) are summed. Objects that cannot be serialized are
+// omitted.
+func ObjectSizes(cfg DeclarativeConfig) map[Identity]int {
+	sizes := map[Identity]int{}
+	add := func(id Identity, v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		sizes[id] += len(data)
+	}
+	for _, p := range cfg.Packages {
+		add(Identity{Schema: p.Schema, Package: p.Name, Name: p.Name}, p)
+	}
+	for _, c := range cfg.Channels {
+		add(Identity{Schema: c.Schema, Package: c.Package, Name: c.Name}, c)
+	}
+	for _, b := range cfg.Bundles {
+		add(Identity{Schema: b.Schema, Package: b.Package, Name: b.Name}, b)
+	}
+	for _, o := range cfg.Others {
+		add(Identity{Schema: o.Schema, Package: o.Package, Name: o.Name}, o)
+	}
+	return sizes
+}
@@ -0,0 +1,34 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectSizes(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Packages: []Package{newTestPackage("foo", "alpha", svgSmallCircle)},
+		Channels: []Channel{newTestChannel("foo", "alpha", ChannelEntry{Name: testBundleName("foo", "0.1.0")})},
+		Bundles:  []Bundle{newTestBundle("foo", "0.1.0")},
+		Others: []Meta{
+			{Schema: "custom.1", Package: "foo", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo"}`)},
+			{Schema: "custom.1", Package: "foo", Blob: json.RawMessage(`{"schema":"custom.1","package":"foo","a":1}`)},
+		},
+	}
+	size := func(v interface{}) int {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return len(data)
+	}
+
+	sizes := ObjectSizes(cfg)
+	require.Equal(t, map[Identity]int{
+		{Schema: SchemaPackage, Package: "foo", Name: "foo"}:                         size(cfg.Packages[0]),
+		{Schema: SchemaChannel, Package: "foo", Name: "alpha"}:                       size(cfg.Channels[0]),
+		{Schema: SchemaBundle, Package: "foo", Name: testBundleName("foo", "0.1.0")}: size(cfg.Bundles[0]),
+		{Schema: "custom.1", Package: "foo"}:                                         len(cfg.Others[0].Blob) + len(cfg.Others[1].Blob),
+	}, sizes)
+	require.Greater(t, sizes[Identity{Schema: SchemaBundle, Package: "foo", Name: testBundleName("foo", "0.1.0")}], sizes[Identity{Schema: SchemaChannel, Package: "foo", Name: "alpha"}])
+}
@@ -0,0 +1,146 @@
+package declcfg
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver/v4"
+)
+
+// ToSkipRangeChannel collapses ch into a channel with a single entry, its
+// head, whose skipRange covers the versions of all other entries in ch. The
+// resulting channel has no replaces or skips edges, which is the upgrade
+// style preferred by some operators.
+//
+// bundles must include the bundles of ch's package, so that their versions
+// can be determined. An error is returned if ch does not have exactly one
+// head, if an entry's bundle cannot be found or has no valid version, if an
+// entry's version is not lower than the head's, or if the versions are not
+// contiguous: a single range would also cover a bundle of the package that
+// is not in ch.
+func ToSkipRangeChannel(ch Channel, bundles []Bundle) (Channel, error) {
+	head, err := channelHead(ch)
+	if err != nil {
+		return Channel{}, fmt.Errorf("package %q, channel %q: %v", ch.Package, ch.Name, err)
+	}
+
+	versions := map[string]semver.Version{}
+	for i := range bundles {
+		if bundles[i].Package != ch.Package {
+			continue
+		}
+		v, err := parseVersionProperty(&bundles[i])
+		if err != nil {
+			return Channel{}, fmt.Errorf("package %q: %v", ch.Package, err)
+		}
+		versions[bundles[i].Name] = *v
+	}
+
+	headVersion, ok := versions[head]
+	if !ok {
+		return Channel{}, fmt.Errorf("package %q, channel %q: bundle %q not found", ch.Package, ch.Name, head)
+	}
+	out := ch
+	out.Entries = []ChannelEntry{{Name: head}}
+	if len(ch.Entries) == 1 {
+		return out, nil
+	}
+
+	var minVersion *semver.Version
+	inChannel := map[string]struct{}{}
+	for _, e := range ch.Entries {
+		inChannel[e.Name] = struct{}{}
+		v, ok := versions[e.Name]
+		if !ok {
+			return Channel{}, fmt.Errorf("package %q, channel %q: bundle %q not found", ch.Package, ch.Name, e.Name)
+		}
+		if e.Name == head {
+			continue
+		}
+		if v.GTE(headVersion) {
+			return Channel{}, fmt.Errorf("package %q, channel %q: entry %q version %s is not lower than head %q version %s", ch.Package, ch.Name, e.Name, v, head, headVersion)
+		}
+		if minVersion == nil || v.LT(*minVersion) {
+			vv := v
+			minVersion = &vv
+		}
+	}
+
+	for _, b := range bundles {
+		if _, ok := inChannel[b.Name]; ok || b.Package != ch.Package {
+			continue
+		}
+		if v := versions[b.Name]; v.GTE(*minVersion) && v.LT(headVersion) {
+			return Channel{}, fmt.Errorf("package %q, channel %q: versions are not contiguous: skipRange %q would also cover bundle %q version %s, which is not in the channel", ch.Package, ch.Name, skipRangeFor(*minVersion, headVersion), b.Name, v)
+		}
+	}
+
+	out.Entries[0].SkipRange = skipRangeFor(*minVersion, headVersion)
+	return out, nil
+}
+
+func skipRangeFor(minVersion, headVersion semver.Version) string {
+	return fmt.Sprintf(">=%s <%s", minVersion, headVersion)
+}
+
+// ExpandSkipRanges replaces the skipRange of each entry of ch with explicit
+// skips of the bundles whose versions are in the range, for consumers that
+// cannot interpret skipRanges. Skips are added after any existing skips, in
+// ascending version order, and bundles that are already skipped or are the
+// entry itself are not added again.
+//
+// bundles must include the bundles of ch's package; as in OLM, a skipRange
+// covers matching bundles whether or not they are in ch. An error is
+// returned if a bundle of the package has no valid version or an entry has
+// an invalid skipRange, in which case ch is left unmodified.
+func ExpandSkipRanges(ch *Channel, bundles []Bundle) error {
+	type versioned struct {
+		name    string
+		version semver.Version
+	}
+	var pkgBundles []versioned
+	for i := range bundles {
+		if bundles[i].Package != ch.Package {
+			continue
+		}
+		v, err := parseVersionProperty(&bundles[i])
+		if err != nil {
+			return fmt.Errorf("package %q: %v", ch.Package, err)
+		}
+		pkgBundles = append(pkgBundles, versioned{bundles[i].Name, *v})
+	}
+	sort.SliceStable(pkgBundles, func(i, j int) bool {
+		return pkgBundles[i].version.LT(pkgBundles[j].version)
+	})
+
+	entries := make([]ChannelEntry, len(ch.Entries))
+	copy(entries, ch.Entries)
+	for i, e := range entries {
+		if e.SkipRange == "" {
+			continue
+		}
+		r, err := semver.ParseRange(e.SkipRange)
+		if err != nil {
+			return fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", ch.Package, ch.Name, e.Name, e.SkipRange, err)
+		}
+		skipped := map[string]struct{}{e.Name: {}}
+		skips := append([]string{}, e.Skips...)
+		for _, s := range skips {
+			skipped[s] = struct{}{}
+		}
+		for _, b := range pkgBundles {
+			if _, ok := skipped[b.name]; ok || !r(b.version) {
+				continue
+			}
+			skipped[b.name] = struct{}{}
+			skips = append(skips, b.name)
+		}
+		if len(skips) == 0 {
+			skips = nil
+		}
+		entries[i].Skips = skips
+		entries[i].SkipRange = ""
+	}
+	ch.Entries = entries
+	return nil
+}
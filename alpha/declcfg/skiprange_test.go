@@ -0,0 +1,154 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestToSkipRangeChannel(t *testing.T) {
+	bundle := func(pkg, version string) Bundle {
+		return Bundle{
+			Schema:     SchemaBundle,
+			Package:    pkg,
+			Name:       testBundleName(pkg, version),
+			Properties: []property.Property{property.MustBuildPackage(pkg, version)},
+		}
+	}
+	name := func(version string) string { return testBundleName("foo", version) }
+
+	type spec struct {
+		name      string
+		ch        Channel
+		bundles   []Bundle
+		expected  Channel
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/ReplacesChain",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: name("0.1.0")},
+				ChannelEntry{Name: name("0.2.0"), Replaces: name("0.1.0")},
+				ChannelEntry{Name: name("0.3.0"), Replaces: name("0.2.0"), Skips: []string{name("0.1.0")}},
+			),
+			bundles: []Bundle{
+				bundle("foo", "0.1.0"), bundle("foo", "0.2.0"), bundle("foo", "0.3.0"),
+				bundle("foo", "0.4.0"), bundle("foo", "0.0.9"), bundle("bar", "0.2.5"),
+			},
+			expected:  newTestChannel("foo", "stable", ChannelEntry{Name: name("0.3.0"), SkipRange: ">=0.1.0 <0.3.0"}),
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/SingleEntry",
+			ch:        newTestChannel("foo", "stable", ChannelEntry{Name: name("0.1.0"), SkipRange: "<0.1.0"}),
+			bundles:   []Bundle{bundle("foo", "0.1.0")},
+			expected:  newTestChannel("foo", "stable", ChannelEntry{Name: name("0.1.0")}),
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/NotContiguous",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: name("0.1.0")},
+				ChannelEntry{Name: name("0.3.0"), Replaces: name("0.1.0")},
+			),
+			bundles:   []Bundle{bundle("foo", "0.1.0"), bundle("foo", "0.2.0"), bundle("foo", "0.3.0")},
+			assertion: hasError(`package "foo", channel "stable": versions are not contiguous: skipRange ">=0.1.0 <0.3.0" would also cover bundle "foo.v0.2.0" version 0.2.0, which is not in the channel`),
+		},
+		{
+			name: "Error/MultipleHeads",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: name("0.1.0")},
+				ChannelEntry{Name: name("0.2.0")},
+			),
+			bundles:   []Bundle{bundle("foo", "0.1.0"), bundle("foo", "0.2.0")},
+			assertion: hasError(`package "foo", channel "stable": multiple channel heads found in graph: foo.v0.1.0, foo.v0.2.0`),
+		},
+		{
+			name: "Error/HeadNotNewest",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: name("0.2.0")},
+				ChannelEntry{Name: name("0.1.0"), Replaces: name("0.2.0")},
+			),
+			bundles:   []Bundle{bundle("foo", "0.1.0"), bundle("foo", "0.2.0")},
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.2.0" version 0.2.0 is not lower than head "foo.v0.1.0" version 0.1.0`),
+		},
+		{
+			name: "Error/MissingBundle",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: name("0.1.0")},
+				ChannelEntry{Name: name("0.2.0"), Replaces: name("0.1.0")},
+			),
+			bundles:   []Bundle{bundle("foo", "0.2.0")},
+			assertion: hasError(`package "foo", channel "stable": bundle "foo.v0.1.0" not found`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := ToSkipRangeChannel(s.ch, s.bundles)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
+
+func TestExpandSkipRanges(t *testing.T) {
+	bundles := []Bundle{
+		newTestBundle("foo", "0.1.0"),
+		newTestBundle("foo", "0.2.0"),
+		newTestBundle("foo", "0.1.1"),
+		newTestBundle("foo", "0.3.0"),
+		newTestBundle("bar", "0.1.0"),
+	}
+	type spec struct {
+		name      string
+		ch        Channel
+		expected  Channel
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.2.0", "foo.v0.0.9"}, SkipRange: ">=0.1.0 <=0.3.0"},
+			),
+			expected: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.2.0", "foo.v0.0.9", "foo.v0.1.0", "foo.v0.1.1"}},
+			),
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/NoMatches",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0", SkipRange: "<0.1.0"},
+			),
+			expected: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+			),
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/InvalidSkipRange",
+			ch: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.2.0", SkipRange: "<0.2.0"},
+				ChannelEntry{Name: "foo.v0.3.0", SkipRange: "not-a-range"},
+			),
+			expected: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.2.0", SkipRange: "<0.2.0"},
+				ChannelEntry{Name: "foo.v0.3.0", SkipRange: "not-a-range"},
+			),
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.3.0" has invalid skipRange "not-a-range": Could not get version from string: "not-a-range"`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			ch := s.ch
+			s.assertion(t, ExpandSkipRanges(&ch, bundles))
+			require.Equal(t, s.expected, ch)
+		})
+	}
+}
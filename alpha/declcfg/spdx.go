@@ -0,0 +1,139 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// SPDXOptions configure the document written by WriteSPDX.
+type SPDXOptions struct {
+	// Name is the name of the SPDX document.
+	Name string
+
+	// Namespace is the unique URI identifying the SPDX document.
+	Namespace string
+
+	// Created is the document's creation time. If zero, the current time
+	// is used.
+	Created time.Time
+}
+
+// SPDXDocument is a minimal SPDX 2.3 document listing catalog bundles as
+// packages.
+type SPDXDocument struct {
+	SPDXVersion       string           `json:"spdxVersion"`
+	DataLicense       string           `json:"dataLicense"`
+	SPDXID            string           `json:"SPDXID"`
+	Name              string           `json:"name"`
+	DocumentNamespace string           `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo `json:"creationInfo"`
+	Packages          []SPDXPackage    `json:"packages"`
+}
+
+// SPDXCreationInfo records when and by what an SPDX document was created.
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage describes a single bundle in an SPDX document.
+type SPDXPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+// SPDXExternalRef refers to a resource outside an SPDX document, such as
+// a bundle image.
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+var spdxIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// NewSPDXDocument returns an SPDX document with one package per bundle in
+// cfg. Each package is named after the bundle's package, takes its version
+// from the bundle's olm.package property, and refers to the bundle's image.
+// Bundles whose version is missing or is not valid semver are still listed,
+// with the anomaly described in the package's comment.
+func NewSPDXDocument(cfg DeclarativeConfig, opts SPDXOptions) SPDXDocument {
+	created := opts.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	doc := SPDXDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              opts.Name,
+		DocumentNamespace: opts.Namespace,
+		CreationInfo: SPDXCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: operator-registry"},
+		},
+		Packages: make([]SPDXPackage, 0, len(cfg.Bundles)),
+	}
+
+	ids := map[string]int{}
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		id := "SPDXRef-Package-" + spdxIDInvalidChars.ReplaceAllString(b.Package+"-"+b.Name, "-")
+		ids[id]++
+		if n := ids[id]; n > 1 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+
+		pkg := SPDXPackage{
+			Name:             b.Package,
+			SPDXID:           id,
+			DownloadLocation: "NOASSERTION",
+		}
+		pkg.VersionInfo, pkg.Comment = spdxVersion(b)
+		if b.Image != "" {
+			pkg.ExternalRefs = []SPDXExternalRef{{
+				ReferenceCategory: "OTHER",
+				ReferenceType:     "container-image",
+				ReferenceLocator:  b.Image,
+			}}
+		}
+		doc.Packages = append(doc.Packages, pkg)
+	}
+	return doc
+}
+
+// spdxVersion returns the version of b and, if the version is missing or
+// invalid, a note describing the anomaly.
+func spdxVersion(b *Bundle) (string, string) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return "", fmt.Sprintf("bundle %q: version unknown: parse properties: %v", b.Name, err)
+	}
+	if len(props.Packages) != 1 {
+		return "", fmt.Sprintf("bundle %q: version unknown: found %d %q properties, expected exactly 1", b.Name, len(props.Packages), property.TypePackage)
+	}
+	v := props.Packages[0].Version
+	if _, err := semver.Parse(v); err != nil {
+		return v, fmt.Sprintf("bundle %q: version %q is not valid semver: %v", b.Name, v, err)
+	}
+	return v, ""
+}
+
+// WriteSPDX writes the SPDX document for cfg to w as JSON.
+func WriteSPDX(cfg DeclarativeConfig, opts SPDXOptions, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(NewSPDXDocument(cfg, opts))
+}
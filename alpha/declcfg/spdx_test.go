@@ -0,0 +1,64 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestWriteSPDX(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			{
+				Schema:     SchemaBundle,
+				Package:    "foo",
+				Name:       "foo.vbad",
+				Image:      "quay.io/example/foo:bad",
+				Properties: []property.Property{property.MustBuildPackage("foo", "bad")},
+			},
+			{Schema: SchemaBundle, Package: "bar", Name: "bar.v0.1.0"},
+		},
+	}
+	opts := SPDXOptions{
+		Name:      "catalog",
+		Namespace: "https://example.com/spdx/catalog",
+		Created:   time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteSPDX(cfg, opts, buf))
+
+	var doc SPDXDocument
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	require.Equal(t, "2023-01-02T03:04:05Z", doc.CreationInfo.Created)
+	require.Equal(t, []SPDXPackage{
+		{
+			Name:             "foo",
+			SPDXID:           "SPDXRef-Package-foo-foo.v0.1.0",
+			VersionInfo:      "0.1.0",
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs:     []SPDXExternalRef{{ReferenceCategory: "OTHER", ReferenceType: "container-image", ReferenceLocator: cfg.Bundles[0].Image}},
+		},
+		{
+			Name:             "foo",
+			SPDXID:           "SPDXRef-Package-foo-foo.vbad",
+			VersionInfo:      "bad",
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs:     []SPDXExternalRef{{ReferenceCategory: "OTHER", ReferenceType: "container-image", ReferenceLocator: "quay.io/example/foo:bad"}},
+			Comment:          `bundle "foo.vbad": version "bad" is not valid semver: No Major.Minor.Patch elements found`,
+		},
+		{
+			Name:             "bar",
+			SPDXID:           "SPDXRef-Package-bar-bar.v0.1.0",
+			DownloadLocation: "NOASSERTION",
+			Comment:          `bundle "bar.v0.1.0": version unknown: found 0 "olm.package" properties, expected exactly 1`,
+		},
+	}, doc.Packages)
+}
@@ -0,0 +1,54 @@
+package declcfg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// BundleStub is the basic metadata needed to describe a bundle without
+// having to hand-write its properties.
+type BundleStub struct {
+	Package string
+	Version string
+	Image   string
+
+	// ProvidedAPIs are the APIs (typically CRDs) owned by the bundle.
+	ProvidedAPIs []property.GVK
+}
+
+// NewBundleFromStub returns a minimal bundle for stub, named
+// "<package>.v<version>", with the olm.package property and one olm.gvk
+// property per provided API. The returned bundle passes validation once it
+// is added to a package and channel.
+func NewBundleFromStub(stub BundleStub) (*Bundle, error) {
+	if stub.Package == "" {
+		return nil, errors.New("package must be set")
+	}
+	if stub.Image == "" {
+		return nil, errors.New("bundle image must be set")
+	}
+	v, err := semver.Parse(stub.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %v", stub.Version, err)
+	}
+
+	props := []property.Property{property.MustBuildPackage(stub.Package, v.String())}
+	for i, gvk := range stub.ProvidedAPIs {
+		if gvk.Group == "" || gvk.Version == "" || gvk.Kind == "" {
+			return nil, fmt.Errorf("provided API[%d]: group, version, and kind must be set", i)
+		}
+		props = append(props, property.MustBuildGVK(gvk.Group, gvk.Version, gvk.Kind))
+	}
+
+	return &Bundle{
+		Schema:     SchemaBundle,
+		Name:       fmt.Sprintf("%s.v%s", stub.Package, v),
+		Package:    stub.Package,
+		Image:      stub.Image,
+		Properties: props,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestNewBundleFromStub(t *testing.T) {
+	type spec struct {
+		name      string
+		stub      BundleStub
+		assertion require.ErrorAssertionFunc
+		expected  *Bundle
+	}
+	specs := []spec{
+		{
+			name: "Success",
+			stub: BundleStub{
+				Package: "foo",
+				Version: "0.1.0",
+				Image:   "quay.io/example/foo-bundle:v0.1.0",
+				ProvidedAPIs: []property.GVK{
+					{Group: "foo.example.com", Version: "v1", Kind: "Foo"},
+				},
+			},
+			assertion: require.NoError,
+			expected: &Bundle{
+				Schema:  SchemaBundle,
+				Name:    "foo.v0.1.0",
+				Package: "foo",
+				Image:   "quay.io/example/foo-bundle:v0.1.0",
+				Properties: []property.Property{
+					property.MustBuildPackage("foo", "0.1.0"),
+					property.MustBuildGVK("foo.example.com", "v1", "Foo"),
+				},
+			},
+		},
+		{
+			name:      "Error/NoPackage",
+			stub:      BundleStub{Version: "0.1.0", Image: "quay.io/example/foo-bundle:v0.1.0"},
+			assertion: hasError(`package must be set`),
+		},
+		{
+			name:      "Error/NoImage",
+			stub:      BundleStub{Package: "foo", Version: "0.1.0"},
+			assertion: hasError(`bundle image must be set`),
+		},
+		{
+			name:      "Error/InvalidVersion",
+			stub:      BundleStub{Package: "foo", Version: "v1", Image: "quay.io/example/foo-bundle:v1"},
+			assertion: hasError(`invalid version "v1": No Major.Minor.Patch elements found`),
+		},
+		{
+			name: "Error/IncompleteGVK",
+			stub: BundleStub{
+				Package:      "foo",
+				Version:      "0.1.0",
+				Image:        "quay.io/example/foo-bundle:v0.1.0",
+				ProvidedAPIs: []property.GVK{{Group: "foo.example.com", Kind: "Foo"}},
+			},
+			assertion: hasError(`provided API[0]: group, version, and kind must be set`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := NewBundleFromStub(s.stub)
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+			if actual == nil {
+				return
+			}
+
+			cfg := DeclarativeConfig{
+				Packages: []Package{{Schema: SchemaPackage, Name: actual.Package, DefaultChannel: "stable"}},
+				Channels: []Channel{{Schema: SchemaChannel, Package: actual.Package, Name: "stable", Entries: []ChannelEntry{{Name: actual.Name}}}},
+				Bundles:  []Bundle{*actual},
+			}
+			m, err := ConvertToModel(cfg)
+			require.NoError(t, err)
+			require.NoError(t, m.Validate())
+		})
+	}
+}
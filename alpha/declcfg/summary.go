@@ -0,0 +1,107 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// PackageSummary is an overview of a package, with the information a
+// catalog front-end shows for it without the rest of the catalog.
+type PackageSummary struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	DefaultChannel string   `json:"defaultChannel,omitempty"`
+	LatestVersion  string   `json:"latestVersion,omitempty"`
+	Channels       []string `json:"channels,omitempty"`
+	IconMediaType  string   `json:"iconMediaType,omitempty"`
+}
+
+// PackageSummaries returns a summary of every package in cfg, sorted by
+// name. Channels are sorted by name. LatestVersion is the highest version
+// of the bundles in any of the package's channels, as found by
+// LatestVersion, and is empty if the package has no such bundles.
+//
+// Only the olm.package property of each bundle is decoded, so this is cheap
+// even for catalogs with large bundle objects. An error is returned if a
+// bundle in a channel does not have exactly one olm.package property with a
+// valid version.
+func PackageSummaries(cfg DeclarativeConfig) ([]PackageSummary, error) {
+	channels := map[string]sets.Set[string]{}
+	entries := map[string]sets.Set[string]{}
+	for _, ch := range cfg.Channels {
+		if channels[ch.Package] == nil {
+			channels[ch.Package] = sets.New[string]()
+			entries[ch.Package] = sets.New[string]()
+		}
+		channels[ch.Package].Insert(ch.Name)
+		for _, e := range ch.Entries {
+			entries[ch.Package].Insert(e.Name)
+		}
+	}
+
+	latest := map[string]semver.Version{}
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		if !entries[b.Package].Has(b.Name) {
+			continue
+		}
+		v, err := packagePropertyVersion(b)
+		if err != nil {
+			return nil, fmt.Errorf("package %q, bundle %q: %v", b.Package, b.Name, err)
+		}
+		if cur, ok := latest[b.Package]; !ok || v.GT(cur) {
+			latest[b.Package] = v
+		}
+	}
+
+	summaries := make([]PackageSummary, 0, len(cfg.Packages))
+	for _, p := range cfg.Packages {
+		s := PackageSummary{
+			Name:           p.Name,
+			Description:    p.Description,
+			DefaultChannel: p.DefaultChannel,
+		}
+		if v, ok := latest[p.Name]; ok {
+			s.LatestVersion = v.String()
+		}
+		if channels[p.Name].Len() > 0 {
+			s.Channels = sets.List(channels[p.Name])
+		}
+		if p.Icon != nil {
+			s.IconMediaType = p.Icon.MediaType
+		}
+		summaries = append(summaries, s)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries, nil
+}
+
+// packagePropertyVersion returns the version of b's olm.package property,
+// without decoding any of its other properties.
+func packagePropertyVersion(b *Bundle) (semver.Version, error) {
+	var versions []string
+	for _, p := range b.Properties {
+		if p.Type != property.TypePackage {
+			continue
+		}
+		var pkg property.Package
+		if err := json.Unmarshal(p.Value, &pkg); err != nil {
+			return semver.Version{}, fmt.Errorf("parse %q property: %v", property.TypePackage, err)
+		}
+		versions = append(versions, pkg.Version)
+	}
+	if len(versions) != 1 {
+		return semver.Version{}, fmt.Errorf("expected exactly 1 %q property, found %d", property.TypePackage, len(versions))
+	}
+	v, err := semver.Parse(versions[0])
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid version %q: %v", versions[0], err)
+	}
+	return v, nil
+}
@@ -0,0 +1,42 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageSummaries(t *testing.T) {
+	cfg := DeclarativeConfig{
+		Packages: []Package{
+			newTestPackage("foo", "stable", svgSmallCircle),
+			{Schema: SchemaPackage, Name: "bar", DefaultChannel: "alpha"},
+		},
+		Channels: []Channel{
+			newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.2.0"}),
+			newTestChannel("foo", "fast", ChannelEntry{Name: "foo.v0.10.0"}, ChannelEntry{Name: "foo.v0.2.0"}),
+		},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.2.0"),
+			newTestBundle("foo", "0.10.0"),
+			newTestBundle("foo", "1.0.0"),
+		},
+	}
+	summaries, err := PackageSummaries(cfg)
+	require.NoError(t, err)
+	require.Equal(t, []PackageSummary{
+		{Name: "bar", DefaultChannel: "alpha"},
+		{
+			Name:           "foo",
+			Description:    testPackageDescription("foo"),
+			DefaultChannel: "stable",
+			LatestVersion:  "0.10.0",
+			Channels:       []string{"fast", "stable"},
+			IconMediaType:  "image/svg+xml",
+		},
+	}, summaries)
+
+	cfg.Bundles[1].Properties = nil
+	_, err = PackageSummaries(cfg)
+	require.EqualError(t, err, `package "foo", bundle "foo.v0.10.0": expected exactly 1 "olm.package" property, found 0`)
+}
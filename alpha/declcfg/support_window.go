@@ -0,0 +1,77 @@
+package declcfg
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// SupportWindow describes which versions of a package can still upgrade to
+// the head of a channel.
+type SupportWindow struct {
+	// Head is the version of the channel head.
+	Head string `json:"head"`
+
+	// MinimumVersion is the lowest version from which the head can be
+	// reached through one or more upgrades. It is the head's version if no
+	// other version can upgrade to it.
+	MinimumVersion string `json:"minimumVersion"`
+
+	// Unsupported lists, in ascending order, the versions of the channel's
+	// entries that cannot upgrade to the head.
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// ChannelSupportWindow returns the SupportWindow of channel in package pkg,
+// whose head is the entry with the highest version. Upgrades are resolved as
+// in ChannelSpec and followed backwards from the head, so a version is
+// supported if it is in the upgradable-from set of the head, or of any
+// version that is itself supported. Versions of bundles that are not in the
+// channel, but that the channel upgrades from, count towards
+// MinimumVersion.
+//
+// The errors returned are those of ChannelSpec, and an error if the channel
+// has no entries.
+func ChannelSupportWindow(cfg DeclarativeConfig, pkg, channel string) (*SupportWindow, error) {
+	spec, err := ChannelSpec(cfg, pkg, channel)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Versions) == 0 {
+		return nil, fmt.Errorf("package %q, channel %q: channel has no entries", pkg, channel)
+	}
+
+	upgradableFrom := map[string][]string{}
+	for _, v := range spec.Versions {
+		upgradableFrom[v.Version] = v.UpgradableFrom
+	}
+	head := spec.Versions[len(spec.Versions)-1].Version
+
+	supported := sets.New[string](head)
+	queue := []string{head}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, from := range upgradableFrom[cur] {
+			if !supported.Has(from) {
+				supported.Insert(from)
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	minimum := semver.MustParse(head)
+	for v := range supported {
+		if sv := semver.MustParse(v); sv.LT(minimum) {
+			minimum = sv
+		}
+	}
+	window := &SupportWindow{Head: head, MinimumVersion: minimum.String()}
+	for _, v := range spec.Versions {
+		if !supported.Has(v.Version) {
+			window.Unsupported = append(window.Unsupported, v.Version)
+		}
+	}
+	return window, nil
+}
@@ -0,0 +1,72 @@
+package declcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSupportWindow(t *testing.T) {
+	bundles := []Bundle{
+		newTestBundle("foo", "0.1.0"),
+		newTestBundle("foo", "0.2.0"),
+		newTestBundle("foo", "0.3.0"),
+		newTestBundle("foo", "0.4.0"),
+		newTestBundle("foo", "0.5.0"),
+	}
+	type spec struct {
+		name      string
+		channel   Channel
+		expected  *SupportWindow
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success/ReplacesAndSkipRange",
+			channel: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.5.0", Replaces: "foo.v0.3.0", SkipRange: ">=0.4.0 <0.5.0"},
+			),
+			expected: &SupportWindow{
+				Head:           "0.5.0",
+				MinimumVersion: "0.2.0",
+				Unsupported:    []string{"0.1.0"},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Success/SingleEntry",
+			channel:   newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.3.0"}),
+			expected:  &SupportWindow{Head: "0.3.0", MinimumVersion: "0.3.0"},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/OrphanedEntries",
+			channel: newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0"},
+				ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.3.0"},
+			),
+			expected: &SupportWindow{
+				Head:           "0.4.0",
+				MinimumVersion: "0.3.0",
+				Unsupported:    []string{"0.1.0", "0.2.0"},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/NoEntries",
+			channel:   newTestChannel("foo", "stable"),
+			assertion: hasError(`package "foo", channel "stable": channel has no entries`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := ChannelSupportWindow(DeclarativeConfig{Channels: []Channel{s.channel}, Bundles: bundles}, "foo", "stable")
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package declcfg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ErrChannelNotFound is returned by ChannelSpec when the requested channel
+// does not exist.
+var ErrChannelNotFound = errors.New("channel not found")
+
+// UpgradeSpec is a channel's upgrade graph with OLM's upgrade semantics
+// already applied, in a form that can be serialized and consumed without
+// knowledge of replaces, skips, or skipRange.
+type UpgradeSpec struct {
+	Package  string               `json:"package"`
+	Channel  string               `json:"channel"`
+	Versions []UpgradeSpecVersion `json:"versions"`
+}
+
+// UpgradeSpecVersion is a single version of an UpgradeSpec. UpgradableFrom
+// lists the versions, in ascending order, from which OLM upgrades directly
+// to this version. UpgradableFromPackages lists the same for the bundles of
+// packages that were renamed to the spec's package, keyed by the old
+// package name.
+type UpgradeSpecVersion struct {
+	Version                string              `json:"version"`
+	Bundle                 string              `json:"bundle"`
+	UpgradableFrom         []string            `json:"upgradableFrom,omitempty"`
+	UpgradableFromPackages map[string][]string `json:"upgradableFromPackages,omitempty"`
+}
+
+// ChannelSpec returns the UpgradeSpec of channel in package pkg, with one
+// version per channel entry in ascending version order.
+//
+// As in OLM, an installed bundle of the package upgrades directly to an
+// entry if the entry replaces or skips it, or if its version is in the
+// entry's skipRange. The installed bundle does not have to be in the
+// channel, but it must be in cfg with a valid version; replaces and skips
+// of bundles that are not in cfg are omitted.
+//
+// Upgrade edges follow package renames recorded as olm.package.migration
+// objects: bundles of packages that were renamed to pkg, directly or
+// through a chain of renames, are upgrade sources in the same way, and are
+// reported in UpgradableFromPackages.
+//
+// The returned error wraps ErrChannelNotFound if the channel does not
+// exist. An error is also returned if an entry's bundle is missing or has
+// no valid version, if two entries have the same version, or if an entry
+// has an invalid skipRange.
+func ChannelSpec(cfg DeclarativeConfig, pkg, channel string) (*UpgradeSpec, error) {
+	var ch *Channel
+	for i := range cfg.Channels {
+		if cfg.Channels[i].Package == pkg && cfg.Channels[i].Name == channel {
+			ch = &cfg.Channels[i]
+			break
+		}
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("package %q, channel %q: %w", pkg, channel, ErrChannelNotFound)
+	}
+
+	oldPackages, err := packagesMigratedTo(cfg, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	type migratedBundle struct {
+		pkg     string
+		version semver.Version
+	}
+	versions := map[string]semver.Version{}
+	migrated := map[string]migratedBundle{}
+	for i := range cfg.Bundles {
+		b := &cfg.Bundles[i]
+		if b.Package != pkg && !oldPackages.Has(b.Package) {
+			continue
+		}
+		v, err := parseVersionProperty(b)
+		if err != nil {
+			continue
+		}
+		if b.Package == pkg {
+			versions[b.Name] = *v
+		} else {
+			migrated[b.Name] = migratedBundle{b.Package, *v}
+		}
+	}
+
+	entries := make([]ChannelEntry, len(ch.Entries))
+	copy(entries, ch.Entries)
+	for _, e := range entries {
+		if _, ok := versions[e.Name]; !ok {
+			return nil, fmt.Errorf("package %q, channel %q: entry %q has no bundle with a valid version", pkg, channel, e.Name)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return versions[entries[i].Name].LT(versions[entries[j].Name])
+	})
+
+	spec := &UpgradeSpec{Package: pkg, Channel: channel, Versions: make([]UpgradeSpecVersion, 0, len(entries))}
+	for i, e := range entries {
+		v := versions[e.Name]
+		if i > 0 && versions[entries[i-1].Name].EQ(v) {
+			return nil, fmt.Errorf("package %q, channel %q: entries %q and %q have the same version %q", pkg, channel, entries[i-1].Name, e.Name, v)
+		}
+
+		from := sets.New[string]()
+		if e.Replaces != "" {
+			from.Insert(e.Replaces)
+		}
+		from.Insert(e.Skips...)
+		if e.SkipRange != "" {
+			r, err := semver.ParseRange(e.SkipRange)
+			if err != nil {
+				return nil, fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", pkg, channel, e.Name, e.SkipRange, err)
+			}
+			for name, other := range versions {
+				if r(other) {
+					from.Insert(name)
+				}
+			}
+			for name, other := range migrated {
+				if r(other.version) {
+					from.Insert(name)
+				}
+			}
+		}
+		from.Delete(e.Name)
+
+		var fromVersions []semver.Version
+		fromPackageVersions := map[string][]semver.Version{}
+		for name := range from {
+			if other, ok := versions[name]; ok {
+				fromVersions = append(fromVersions, other)
+			} else if other, ok := migrated[name]; ok {
+				fromPackageVersions[other.pkg] = append(fromPackageVersions[other.pkg], other.version)
+			}
+		}
+		sv := UpgradeSpecVersion{Version: v.String(), Bundle: e.Name, UpgradableFrom: sortedVersionStrings(fromVersions)}
+		for oldPkg, oldVersions := range fromPackageVersions {
+			if sv.UpgradableFromPackages == nil {
+				sv.UpgradableFromPackages = map[string][]string{}
+			}
+			sv.UpgradableFromPackages[oldPkg] = sortedVersionStrings(oldVersions)
+		}
+		spec.Versions = append(spec.Versions, sv)
+	}
+	return spec, nil
+}
+
+// sortedVersionStrings returns the distinct versions in vs, in ascending
+// order. It returns nil if vs is empty.
+func sortedVersionStrings(vs []semver.Version) []string {
+	semver.Sort(vs)
+	var out []string
+	for i, v := range vs {
+		if i > 0 && v.EQ(vs[i-1]) {
+			continue
+		}
+		out = append(out, v.String())
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package declcfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSpec(t *testing.T) {
+	bundles := []Bundle{
+		newTestBundle("foo", "0.1.0"),
+		newTestBundle("foo", "0.1.1"),
+		newTestBundle("foo", "0.2.0"),
+		newTestBundle("foo", "0.3.0"),
+		newTestBundle("foo", "0.4.0"),
+		newTestBundle("bar", "0.1.0"),
+	}
+	type spec struct {
+		name      string
+		channels  []Channel
+		expected  *UpgradeSpec
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name: "Success",
+			channels: []Channel{newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.4.0", Replaces: "foo.v0.3.0", SkipRange: ">=0.1.0 <0.4.0"},
+				ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.0.1"},
+				ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.2.0", "foo.v0.1.1"}},
+			)},
+			expected: &UpgradeSpec{
+				Package: "foo",
+				Channel: "stable",
+				Versions: []UpgradeSpecVersion{
+					{Version: "0.1.0", Bundle: "foo.v0.1.0"},
+					{Version: "0.3.0", Bundle: "foo.v0.3.0", UpgradableFrom: []string{"0.1.0", "0.1.1", "0.2.0"}},
+					{Version: "0.4.0", Bundle: "foo.v0.4.0", UpgradableFrom: []string{"0.1.0", "0.1.1", "0.2.0", "0.3.0"}},
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name:      "Error/ChannelNotFound",
+			channels:  []Channel{newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.1.0"})},
+			assertion: hasError(`package "foo", channel "stable": channel not found`),
+		},
+		{
+			name:      "Error/MissingBundle",
+			channels:  []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v9.0.0"})},
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v9.0.0" has no bundle with a valid version`),
+		},
+		{
+			name:      "Error/InvalidSkipRange",
+			channels:  []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0", SkipRange: "not-a-range"})},
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.1.0" has invalid skipRange "not-a-range": Could not get version from string: "not-a-range"`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			actual, err := ChannelSpec(DeclarativeConfig{Channels: s.channels, Bundles: bundles}, "foo", "stable")
+			s.assertion(t, err)
+			require.Equal(t, s.expected, actual)
+		})
+	}
+}
+
+func TestChannelSpecPackageMigration(t *testing.T) {
+	migration := func(oldPkg, newPkg string) Meta {
+		blob, err := json.Marshal(PackageMigration{Schema: SchemaPackageMigration, OldPackage: oldPkg, NewPackage: newPkg})
+		require.NoError(t, err)
+		return Meta{Schema: SchemaPackageMigration, Blob: blob}
+	}
+	cfg := DeclarativeConfig{
+		Channels: []Channel{newTestChannel("baz", "stable",
+			ChannelEntry{Name: "baz.v1.0.0", Replaces: "bar.v0.2.0", SkipRange: "<0.2.0"},
+			ChannelEntry{Name: "baz.v1.1.0", Replaces: "baz.v1.0.0"},
+		)},
+		Bundles: []Bundle{
+			newTestBundle("foo", "0.1.0"),
+			newTestBundle("bar", "0.2.0"),
+			newTestBundle("qux", "0.1.5"),
+			newTestBundle("baz", "1.0.0"),
+			newTestBundle("baz", "1.1.0"),
+		},
+		Others: []Meta{migration("foo", "bar"), migration("bar", "baz")},
+	}
+
+	actual, err := ChannelSpec(cfg, "baz", "stable")
+	require.NoError(t, err)
+	require.Equal(t, &UpgradeSpec{
+		Package: "baz",
+		Channel: "stable",
+		Versions: []UpgradeSpecVersion{
+			{Version: "1.0.0", Bundle: "baz.v1.0.0", UpgradableFromPackages: map[string][]string{"foo": {"0.1.0"}, "bar": {"0.2.0"}}},
+			{Version: "1.1.0", Bundle: "baz.v1.1.0", UpgradableFrom: []string{"1.0.0"}},
+		},
+	}, actual)
+}
@@ -0,0 +1,815 @@
+package declcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// ValidateOptions configures the checks performed by Validate.
+type ValidateOptions struct {
+	maxIconSize           int
+	allowedIconMediaTypes []string
+	allowEmptyChannels    bool
+	allowEmptyImages      bool
+
+	allowPackagesWithoutChannels bool
+	distinguishBuildMetadata     bool
+	requireConvergentReplaces    bool
+	checkSkipRangeConflicts      bool
+}
+
+type ValidateOption func(*ValidateOptions)
+
+// WithMaxIconSize causes Validate to report package icons whose decoded data
+// is larger than size bytes. By default, icon size is not checked.
+func WithMaxIconSize(size int) ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.maxIconSize = size
+	}
+}
+
+// DefaultIconMediaTypes are the icon media types that
+// WithAllowedIconMediaTypes allows when no types are provided. They are the
+// types that consoles are known to render.
+var DefaultIconMediaTypes = []string{"image/png", "image/jpeg", "image/svg+xml"}
+
+// WithAllowedIconMediaTypes causes Validate to report package icons whose
+// media type is not one of mediaTypes, or of DefaultIconMediaTypes if none
+// are provided. Media types are compared case-insensitively and without
+// parameters such as charset. By default, icon media types are not checked.
+func WithAllowedIconMediaTypes(mediaTypes ...string) ValidateOption {
+	if len(mediaTypes) == 0 {
+		mediaTypes = DefaultIconMediaTypes
+	}
+	return func(opts *ValidateOptions) {
+		opts.allowedIconMediaTypes = mediaTypes
+	}
+}
+
+// AllowEmptyChannels causes Validate to accept channels with no entries,
+// for example when they are intentional placeholders that will be populated
+// later. By default, empty channels are reported.
+func AllowEmptyChannels() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.allowEmptyChannels = true
+	}
+}
+
+// AllowEmptyImages causes ValidateImageRefs to accept empty bundle and
+// related image references, as used by bundles that are not served from a
+// bundle image. By default, empty references are reported.
+func AllowEmptyImages() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.allowEmptyImages = true
+	}
+}
+
+// AllowPackagesWithoutChannels causes Validate to accept packages that have
+// no channels, as found in staging catalogs whose channels are added later.
+// By default, such packages are reported because they cannot be installed.
+func AllowPackagesWithoutChannels() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.allowPackagesWithoutChannels = true
+	}
+}
+
+// DistinguishBuildMetadata causes Validate to treat bundle versions that
+// differ only in build metadata, such as 1.0.0+1 and 1.0.0+2, as distinct.
+// By default, build metadata is ignored when checking that versions are
+// unique, as it is when versions are ordered.
+func DistinguishBuildMetadata() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.distinguishBuildMetadata = true
+	}
+}
+
+// RequireConvergentReplaces causes Validate to report channel entries whose
+// replaces chain does not lead to the channel head: following the entries
+// that replace them, newer and newer, ends only at other bundles, leaving a
+// dead branch. Skips are not followed, so a branch that only rejoins the
+// channel through skips is reported too. By default, this is not checked,
+// and channels that do not have exactly one head are never checked.
+func RequireConvergentReplaces() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.requireConvergentReplaces = true
+	}
+}
+
+// CheckSkipRangeConflicts causes Validate to report channel entries whose
+// skipRange contradicts the rest of the entry: the range includes the
+// entry's own version, or it includes the version of the bundle the entry
+// replaces while that bundle is not older than the entry. A range that
+// includes an older replaced bundle, as in OLM's recommended
+// "replaces: foo.v0.1.0, skipRange: <0.2.0" pattern, is not reported. By
+// default, only skipRanges that cannot be parsed are reported.
+func CheckSkipRangeConflicts() ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.checkSkipRangeConflicts = true
+	}
+}
+
+type validateFunc func(cfg DeclarativeConfig, opts ValidateOptions) []error
+
+// validators are run, in order, by Validate.
+var validators = []validateFunc{
+	validateChannelEntryPackages,
+	validateChannelReferencePackages,
+	validatePackageIcons,
+	validatePackageMigrations,
+	validateSelfReferencingEntries,
+	validateUniqueBundleImages,
+	validateChannelPriorities,
+	validateNonEmptyChannels,
+	validateSkipRangeReplaces,
+	validateUniqueChannels,
+	validateBundlePackageProperties,
+	validateDeprecationReferences,
+	validatePackagesHaveChannels,
+	validateUniqueBundleVersions,
+	validateCrossChannelReplaces,
+	validateDependencyCycles,
+	validateNonEmptyOthers,
+	validateConvergentReplaces,
+}
+
+// Validate checks cfg for inconsistencies between its objects that are not
+// caught when converting it to a model, such as references that cross
+// package boundaries. All problems found are returned as an aggregate error.
+func Validate(cfg DeclarativeConfig, opts ...ValidateOption) error {
+	options := ValidateOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var errs []error
+	for _, v := range validators {
+		errs = append(errs, v(cfg, options)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateChannelEntryPackages reports channel entries that do not match a
+// bundle in the channel's package, but do match a bundle in another package.
+func validateChannelEntryPackages(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	bundlePackages := bundlePackagesByName(cfg.Bundles)
+
+	var errs []error
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			pkgs, ok := bundlePackages[e.Name]
+			if !ok || pkgs.Has(ch.Package) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q references bundle in package %s, not %q",
+				ch.Package, ch.Name, e.Name, quotedList(sets.List(pkgs)), ch.Package))
+		}
+	}
+	return errs
+}
+
+// validateChannelReferencePackages reports channel entries whose replaces or
+// skips do not match a bundle in the channel's package, but do match a
+// bundle in another package. References that match no bundle at all are
+// allowed, since they may name bundles that were removed from the catalog,
+// as are references to bundles of packages that were renamed to the
+// channel's package.
+func validateChannelReferencePackages(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	bundlePackages := bundlePackagesByName(cfg.Bundles)
+	migratedTo := map[string]sets.Set[string]{}
+	crossPackage := func(name, pkg string) (sets.Set[string], bool) {
+		pkgs, ok := bundlePackages[name]
+		if !ok || pkgs.Has(pkg) {
+			return nil, false
+		}
+		if _, ok := migratedTo[pkg]; !ok {
+			// Invalid migrations are reported by validatePackageMigrations.
+			migratedTo[pkg], _ = packagesMigratedTo(cfg, pkg)
+		}
+		return pkgs, !migratedTo[pkg].HasAny(sets.List(pkgs)...)
+	}
+
+	var errs []error
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			if pkgs, ok := crossPackage(e.Replaces, ch.Package); ok {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q replaces bundle %q in package %s, not %q",
+					ch.Package, ch.Name, e.Name, e.Replaces, quotedList(sets.List(pkgs)), ch.Package))
+			}
+			for _, skip := range e.Skips {
+				if pkgs, ok := crossPackage(skip, ch.Package); ok {
+					errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q skips bundle %q in package %s, not %q",
+						ch.Package, ch.Name, e.Name, skip, quotedList(sets.List(pkgs)), ch.Package))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// bundlePackagesByName returns the packages of the bundles with each name.
+func bundlePackagesByName(bundles []Bundle) map[string]sets.Set[string] {
+	bundlePackages := map[string]sets.Set[string]{}
+	for _, b := range bundles {
+		if _, ok := bundlePackages[b.Name]; !ok {
+			bundlePackages[b.Name] = sets.New[string]()
+		}
+		bundlePackages[b.Name].Insert(b.Package)
+	}
+	return bundlePackages
+}
+
+// validateSelfReferencingEntries reports channel entries that replace or
+// skip themselves.
+func validateSelfReferencingEntries(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	var errs []error
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			if e.Replaces == e.Name {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q replaces itself", ch.Package, ch.Name, e.Name))
+			}
+			for _, s := range e.Skips {
+				if s == e.Name {
+					errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q skips itself", ch.Package, ch.Name, e.Name))
+					break
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateUniqueBundleImages reports bundle images that are used by more than
+// one bundle in the same package. Bundles without an image are ignored.
+func validateUniqueBundleImages(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	type key struct {
+		pkg   string
+		image string
+	}
+	var keys []key
+	bundleNames := map[key][]string{}
+	for _, b := range cfg.Bundles {
+		if b.Image == "" {
+			continue
+		}
+		k := key{b.Package, b.Image}
+		if _, ok := bundleNames[k]; !ok {
+			keys = append(keys, k)
+		}
+		bundleNames[k] = append(bundleNames[k], b.Name)
+	}
+
+	var errs []error
+	for _, k := range keys {
+		if names := bundleNames[k]; len(names) > 1 {
+			errs = append(errs, fmt.Errorf("package %q: image %q is used by multiple bundles: %s", k.pkg, k.image, quotedList(names)))
+		}
+	}
+	return errs
+}
+
+// validateChannelPriorities reports channels with a negative priority.
+func validateChannelPriorities(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	var errs []error
+	for _, ch := range cfg.Channels {
+		if priority, ok := ch.Priority(); ok && priority < 0 {
+			errs = append(errs, fmt.Errorf("package %q, channel %q: priority %d must not be negative", ch.Package, ch.Name, priority))
+		}
+	}
+	return errs
+}
+
+// validateNonEmptyChannels reports channels with no entries, unless they are
+// allowed by AllowEmptyChannels.
+func validateNonEmptyChannels(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	if opts.allowEmptyChannels {
+		return nil
+	}
+	var errs []error
+	for _, ch := range cfg.Channels {
+		if len(ch.Entries) == 0 {
+			errs = append(errs, fmt.Errorf("package %q, channel %q: channel has no entries", ch.Package, ch.Name))
+		}
+	}
+	return errs
+}
+
+// validateSkipRangeReplaces reports entries with a skipRange that cannot be
+// parsed and, with CheckSkipRangeConflicts, entries whose skipRange
+// contradicts their own version or the version of the bundle they replace.
+// Versions of bundles that are not found or have no valid version are not
+// checked.
+func validateSkipRangeReplaces(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	type key struct {
+		pkg  string
+		name string
+	}
+	versions := map[key]semver.Version{}
+	for i := range cfg.Bundles {
+		if v, err := parseVersionProperty(&cfg.Bundles[i]); err == nil {
+			versions[key{cfg.Bundles[i].Package, cfg.Bundles[i].Name}] = *v
+		}
+	}
+
+	var errs []error
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			if e.SkipRange == "" {
+				continue
+			}
+			skipRange, err := semver.ParseRange(e.SkipRange)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", ch.Package, ch.Name, e.Name, e.SkipRange, err))
+				continue
+			}
+			if !opts.checkSkipRangeConflicts {
+				continue
+			}
+			own, ok := versions[key{ch.Package, e.Name}]
+			if !ok {
+				continue
+			}
+			if skipRange(own) {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q skipRange %q includes the entry's own version %s", ch.Package, ch.Name, e.Name, e.SkipRange, own))
+				continue
+			}
+			if v, ok := versions[key{ch.Package, e.Replaces}]; ok && e.Replaces != "" && skipRange(v) && v.GTE(own) {
+				errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q skipRange %q includes version %s of replaced bundle %q, which is not older than the entry's version %s", ch.Package, ch.Name, e.Name, e.SkipRange, v, e.Replaces, own))
+			}
+		}
+	}
+	return errs
+}
+
+func quotedList(in []string) string {
+	quoted := make([]string, 0, len(in))
+	for _, s := range in {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// validatePackageIcons reports icons that exceed the configured size limit
+// or have a media type that is not allowed, and SVG icons that contain
+// scripts.
+func validatePackageIcons(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	var errs []error
+	for _, p := range cfg.Packages {
+		if p.Icon == nil {
+			continue
+		}
+		if opts.maxIconSize > 0 && len(p.Icon.Data) > opts.maxIconSize {
+			errs = append(errs, fmt.Errorf("package %q: icon size %d bytes exceeds maximum of %d bytes", p.Name, len(p.Icon.Data), opts.maxIconSize))
+		}
+		if p.Icon.MediaType == "image/svg+xml" {
+			if err := checkSVGScripts(p.Icon.Data); err != nil {
+				errs = append(errs, fmt.Errorf("package %q: svg icon %v", p.Name, err))
+			}
+		}
+		if opts.allowedIconMediaTypes != nil && !iconMediaTypeAllowed(p.Icon.MediaType, opts.allowedIconMediaTypes) {
+			errs = append(errs, fmt.Errorf("package %q: icon media type %q is not one of %s", p.Name, p.Icon.MediaType, quotedList(opts.allowedIconMediaTypes)))
+		}
+	}
+	return errs
+}
+
+func iconMediaTypeAllowed(mediaType string, allowed []string) bool {
+	base, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(base, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSVGScripts returns an error if data contains a script element, an
+// event handler attribute, a foreignObject element, which can embed HTML,
+// or a link to a javascript: URL. Data that cannot be parsed is rejected
+// too, since it cannot be shown to be free of scripts.
+func checkSVGScripts(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not be parsed: %v", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(se.Name.Local, "script") || strings.EqualFold(se.Name.Local, "foreignObject") {
+			return errors.New("must not contain scripts")
+		}
+		for _, attr := range se.Attr {
+			if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") {
+				return errors.New("must not contain scripts")
+			}
+			if strings.EqualFold(attr.Name.Local, "href") && isJavaScriptURL(attr.Value) {
+				return errors.New("must not contain scripts")
+			}
+		}
+	}
+}
+
+// isJavaScriptURL reports whether url uses the javascript: scheme. Like
+// browsers, it ignores case and whitespace and control characters.
+func isJavaScriptURL(url string) bool {
+	stripped := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, url)
+	return strings.HasPrefix(strings.ToLower(stripped), "javascript:")
+}
+
+// validateUniqueChannels reports channels that are defined more than once
+// in the same package. Such channels can be combined with
+// MergeDuplicateChannels.
+func validateUniqueChannels(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	type key struct {
+		pkg  string
+		name string
+	}
+	counts := map[key]int{}
+	var order []key
+	for _, ch := range cfg.Channels {
+		k := key{ch.Package, ch.Name}
+		if counts[k] == 0 {
+			order = append(order, k)
+		}
+		counts[k]++
+	}
+
+	var errs []error
+	for _, k := range order {
+		if counts[k] > 1 {
+			errs = append(errs, fmt.Errorf("package %q, channel %q: channel is defined %d times", k.pkg, k.name, counts[k]))
+		}
+	}
+	return errs
+}
+
+// validateBundlePackageProperties reports bundles with an olm.package
+// property that names a different package than the bundle itself. Property
+// values that cannot be parsed are left for model conversion to report.
+func validateBundlePackageProperties(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	var errs []error
+	for _, b := range cfg.Bundles {
+		for _, p := range b.Properties {
+			if p.Type != property.TypePackage {
+				continue
+			}
+			var pkg property.Package
+			if err := json.Unmarshal(p.Value, &pkg); err != nil {
+				continue
+			}
+			if pkg.PackageName != b.Package {
+				errs = append(errs, fmt.Errorf("package %q, bundle %q: %q property names package %q", b.Package, b.Name, property.TypePackage, pkg.PackageName))
+			}
+		}
+	}
+	return errs
+}
+
+// schemaDeprecations is the schema of objects that mark packages, channels,
+// and bundles as deprecated. Such objects are loaded into Others.
+const schemaDeprecations = "olm.deprecations"
+
+// deprecations is the subset of an olm.deprecations object needed to check
+// its references.
+type deprecations struct {
+	Package string `json:"package"`
+	Entries []struct {
+		Reference struct {
+			Schema string `json:"schema"`
+			Name   string `json:"name"`
+		} `json:"reference"`
+	} `json:"entries"`
+}
+
+// validateDeprecationReferences reports olm.deprecations objects that
+// reference a package, channel, or bundle that does not exist.
+func validateDeprecationReferences(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	type key struct {
+		schema string
+		pkg    string
+		name   string
+	}
+	exists := sets.New[key]()
+	for _, p := range cfg.Packages {
+		exists.Insert(key{SchemaPackage, p.Name, ""})
+	}
+	for _, c := range cfg.Channels {
+		exists.Insert(key{SchemaChannel, c.Package, c.Name})
+	}
+	for _, b := range cfg.Bundles {
+		exists.Insert(key{SchemaBundle, b.Package, b.Name})
+	}
+
+	var errs []error
+	for _, o := range cfg.Others {
+		if o.Schema != schemaDeprecations {
+			continue
+		}
+		var d deprecations
+		if err := json.Unmarshal(o.Blob, &d); err != nil {
+			errs = append(errs, fmt.Errorf("package %q: invalid %q object: %v", o.Package, schemaDeprecations, err))
+			continue
+		}
+		for _, e := range d.Entries {
+			ref := e.Reference
+			switch ref.Schema {
+			case SchemaPackage:
+				if !exists.Has(key{SchemaPackage, d.Package, ""}) {
+					errs = append(errs, fmt.Errorf("package %q: deprecation references package that does not exist", d.Package))
+				}
+			case SchemaChannel, SchemaBundle:
+				if !exists.Has(key{ref.Schema, d.Package, ref.Name}) {
+					errs = append(errs, fmt.Errorf("package %q: deprecation references %s %q that does not exist", d.Package, strings.TrimPrefix(ref.Schema, "olm."), ref.Name))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("package %q: deprecation references unsupported schema %q", d.Package, ref.Schema))
+			}
+		}
+	}
+	return errs
+}
+
+// validatePackagesHaveChannels reports packages that have no channels.
+func validatePackagesHaveChannels(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	if opts.allowPackagesWithoutChannels {
+		return nil
+	}
+	withChannels := sets.New[string]()
+	for _, ch := range cfg.Channels {
+		withChannels.Insert(ch.Package)
+	}
+	var errs []error
+	for _, p := range cfg.Packages {
+		if !withChannels.Has(p.Name) {
+			errs = append(errs, fmt.Errorf("package %q: package has no channels", p.Name))
+		}
+	}
+	return errs
+}
+
+// validateUniqueBundleVersions reports bundles in the same package that have
+// the same version. Bundles without a valid version are ignored.
+func validateUniqueBundleVersions(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	type key struct {
+		pkg     string
+		version string
+	}
+	var keys []key
+	bundleNames := map[key][]string{}
+	for i := range cfg.Bundles {
+		v, err := parseVersionProperty(&cfg.Bundles[i])
+		if err != nil {
+			continue
+		}
+		if !opts.distinguishBuildMetadata {
+			v.Build = nil
+		}
+		k := key{cfg.Bundles[i].Package, v.String()}
+		if _, ok := bundleNames[k]; !ok {
+			keys = append(keys, k)
+		}
+		bundleNames[k] = append(bundleNames[k], cfg.Bundles[i].Name)
+	}
+
+	var errs []error
+	for _, k := range keys {
+		if names := bundleNames[k]; len(names) > 1 {
+			errs = append(errs, fmt.Errorf("package %q: version %q is shared by bundles %s", k.pkg, k.version, quotedList(names)))
+		}
+	}
+	return errs
+}
+
+// validateCrossChannelReplaces reports entries that replace a bundle that
+// is not an entry of their own channel, but is an entry of another channel
+// of the same package. Replaced bundles that are in no channel are allowed,
+// since a channel's oldest entry may replace a bundle that was removed.
+func validateCrossChannelReplaces(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	type key struct {
+		pkg  string
+		name string
+	}
+	channelsByEntry := map[key][]string{}
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			k := key{ch.Package, e.Name}
+			channelsByEntry[k] = append(channelsByEntry[k], ch.Name)
+		}
+	}
+
+	var errs []error
+	for _, ch := range cfg.Channels {
+		for _, e := range ch.Entries {
+			if e.Replaces == "" {
+				continue
+			}
+			channels := channelsByEntry[key{ch.Package, e.Replaces}]
+			if len(channels) == 0 || sets.New[string](channels...).Has(ch.Name) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("package %q, channel %q: entry %q replaces %q, which is not in this channel, only in other channels: %s",
+				ch.Package, ch.Name, e.Name, e.Replaces, quotedList(sets.List(sets.New[string](channels...)))))
+		}
+	}
+	return errs
+}
+
+// validateNonEmptyOthers reports objects in Others whose blob is empty,
+// whitespace-only, or null. Such objects can be removed with
+// DropEmptyOthers.
+func validateNonEmptyOthers(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	var errs []error
+	for i, o := range cfg.Others {
+		if isEmptyBlob(o.Blob) {
+			errs = append(errs, fmt.Errorf("other object %d (schema %q, package %q, name %q) has an empty blob", i, o.Schema, o.Package, o.Name))
+		}
+	}
+	return errs
+}
+
+// validateDependencyCycles reports packages that depend on each other in a
+// cycle, which OLM can never resolve. A package depends on another if any of
+// its bundles has an olm.package.required property for it, or an
+// olm.gvk.required property for a GVK that the other package provides and
+// the package itself does not. Bundles whose properties cannot be parsed are
+// ignored.
+func validateDependencyCycles(cfg DeclarativeConfig, _ ValidateOptions) []error {
+	type bundleProps struct {
+		b     *Bundle
+		props *property.Properties
+	}
+	var (
+		bundles     []bundleProps
+		providers   = map[property.GVK]sets.Set[string]{}
+		constraints = map[string]map[string]sets.Set[string]{}
+	)
+	for i := range cfg.Bundles {
+		props, err := property.Parse(cfg.Bundles[i].Properties)
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, bundleProps{&cfg.Bundles[i], props})
+		for _, gvk := range props.GVKs {
+			if providers[gvk] == nil {
+				providers[gvk] = sets.New[string]()
+			}
+			providers[gvk].Insert(cfg.Bundles[i].Package)
+		}
+	}
+	addConstraint := func(from, to, constraint string) {
+		if constraints[from] == nil {
+			constraints[from] = map[string]sets.Set[string]{}
+		}
+		if constraints[from][to] == nil {
+			constraints[from][to] = sets.New[string]()
+		}
+		constraints[from][to].Insert(constraint)
+	}
+	for _, bp := range bundles {
+		pkg := bp.b.Package
+		for _, req := range bp.props.PackagesRequired {
+			if req.PackageName != pkg {
+				addConstraint(pkg, req.PackageName, fmt.Sprintf("bundle %q requires package %q in range %q", bp.b.Name, req.PackageName, req.VersionRange))
+			}
+		}
+		for _, req := range bp.props.GVKsRequired {
+			gvk := property.GVK{Group: req.Group, Version: req.Version, Kind: req.Kind}
+			if providers[gvk].Has(pkg) {
+				continue
+			}
+			for _, provider := range sets.List(providers[gvk]) {
+				addConstraint(pkg, provider, fmt.Sprintf("bundle %q requires GVK %s/%s, Kind=%s provided by package %q", bp.b.Name, req.Group, req.Version, req.Kind, provider))
+			}
+		}
+	}
+
+	nodes := make([]string, 0, len(constraints))
+	edges := map[string][]string{}
+	for from, tos := range constraints {
+		nodes = append(nodes, from)
+		for to := range tos {
+			edges[from] = append(edges[from], to)
+		}
+		sort.Strings(edges[from])
+	}
+	sort.Strings(nodes)
+
+	var errs []error
+	for _, scc := range cycles(nodes, edges) {
+		path := shortestCycle(scc[0], sets.New[string](scc...), edges)
+		var reasons []string
+		for i := 0; i+1 < len(path); i++ {
+			reasons = append(reasons, sets.List(constraints[path[i]][path[i+1]])...)
+		}
+		errs = append(errs, fmt.Errorf("packages %s form a dependency cycle %s: %s", quotedList(scc), strings.Join(path, " -> "), strings.Join(reasons, "; ")))
+	}
+	return errs
+}
+
+// shortestCycle returns the shortest path from start back to itself that
+// only visits nodes in members, beginning and ending with start.
+func shortestCycle(start string, members sets.Set[string], edges map[string][]string) []string {
+	prev := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range edges[n] {
+			if m == start {
+				path := []string{start}
+				for c := n; c != start; c = prev[c] {
+					path = append(path, c)
+				}
+				path = append(path, start)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, seen := prev[m]; !seen && members.Has(m) {
+				prev[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil
+}
+
+// validateConvergentReplaces reports channel entries whose replaces chain
+// ends at a bundle other than the channel head, if enabled by
+// RequireConvergentReplaces.
+func validateConvergentReplaces(cfg DeclarativeConfig, opts ValidateOptions) []error {
+	if !opts.requireConvergentReplaces {
+		return nil
+	}
+	var errs []error
+	for _, ch := range cfg.Channels {
+		head, err := channelHead(ch)
+		if err != nil {
+			continue
+		}
+		entries := sets.New[string]()
+		for _, e := range ch.Entries {
+			entries.Insert(e.Name)
+		}
+		replacedBy := map[string][]string{}
+		for _, e := range ch.Entries {
+			if e.Replaces != e.Name && entries.Has(e.Replaces) {
+				replacedBy[e.Replaces] = append(replacedBy[e.Replaces], e.Name)
+			}
+		}
+
+		for _, e := range ch.Entries {
+			visited := sets.New[string](e.Name)
+			stack := []string{e.Name}
+			var terminals []string
+			for len(stack) > 0 {
+				cur := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if len(replacedBy[cur]) == 0 {
+					terminals = append(terminals, cur)
+				}
+				for _, next := range replacedBy[cur] {
+					if !visited.Has(next) {
+						visited.Insert(next)
+						stack = append(stack, next)
+					}
+				}
+			}
+			if visited.Has(head) {
+				continue
+			}
+			sort.Strings(terminals)
+			errs = append(errs, fmt.Errorf("package %q, channel %q: replaces chain of entry %q ends at %s instead of channel head %q",
+				ch.Package, ch.Name, e.Name, quotedList(terminals), head))
+		}
+	}
+	return errs
+}
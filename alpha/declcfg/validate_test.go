@@ -0,0 +1,515 @@
+package declcfg
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func TestValidate(t *testing.T) {
+	type spec struct {
+		name      string
+		cfg       DeclarativeConfig
+		opts      []ValidateOption
+		assertion require.ErrorAssertionFunc
+	}
+	specs := []spec{
+		{
+			name:      "Success/Valid",
+			cfg:       buildValidDeclarativeConfig(true),
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/ChannelEntryFromOtherPackage",
+			cfg: DeclarativeConfig{
+				Packages: []Package{
+					newTestPackage("foo", "stable", svgSmallCircle),
+					newTestPackage("bar", "stable", svgSmallCircle),
+				},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+						ChannelEntry{Name: testBundleName("bar", "0.1.0"), Replaces: testBundleName("foo", "0.1.0")},
+					),
+					newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "0.1.0")}),
+				},
+				Bundles: []Bundle{
+					newTestBundle("foo", "0.1.0"),
+					newTestBundle("bar", "0.1.0"),
+				},
+			},
+			assertion: hasError(`package "foo", channel "stable": entry "bar.v0.1.0" references bundle in package "bar", not "foo"`),
+		},
+		{
+			name: "Error/ChannelReferenceFromOtherPackage",
+			cfg: DeclarativeConfig{
+				Packages: []Package{
+					newTestPackage("foo", "stable", svgSmallCircle),
+					newTestPackage("bar", "stable", svgSmallCircle),
+				},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("bar", "0.1.0"), Skips: []string{testBundleName("bar", "0.1.0")}},
+					),
+					newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "0.1.0")}),
+				},
+				Bundles: []Bundle{
+					newTestBundle("foo", "0.2.0"),
+					newTestBundle("bar", "0.1.0"),
+				},
+			},
+			assertion: hasError(`[package "foo", channel "stable": entry "foo.v0.2.0" replaces bundle "bar.v0.1.0" in package "bar", not "foo", package "foo", channel "stable": entry "foo.v0.2.0" skips bundle "bar.v0.1.0" in package "bar", not "foo"]`),
+		},
+		{
+			name: "Success/ChannelReferenceToMigratedPackage",
+			cfg: DeclarativeConfig{
+				Packages: []Package{
+					newTestPackage("foo", "stable", svgSmallCircle),
+					newTestPackage("bar", "stable", svgSmallCircle),
+				},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("bar", "0.1.0")},
+					),
+					newTestChannel("bar", "stable", ChannelEntry{Name: testBundleName("bar", "0.1.0")}),
+				},
+				Bundles: []Bundle{
+					newTestBundle("foo", "0.2.0"),
+					newTestBundle("bar", "0.1.0"),
+				},
+				Others: []Meta{{Schema: SchemaPackageMigration, Blob: json.RawMessage(`{"schema":"olm.package.migration","oldPackage":"bar","newPackage":"foo"}`)}},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/ChannelReferenceToMissingBundle",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0"), Skips: []string{testBundleName("foo", "0.0.1")}},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.2.0")},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/IconWithinLimit",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			},
+			opts:      []ValidateOption{WithMaxIconSize(len(svgSmallCircle)), AllowPackagesWithoutChannels()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/IconTooLarge",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			},
+			opts:      []ValidateOption{WithMaxIconSize(16), AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": icon size 65 bytes exceeds maximum of 16 bytes`),
+		},
+		{
+			name: "Error/IconScriptElement",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", `<svg viewBox="0 0 100 100"><SCRIPT>alert(1)</SCRIPT></svg>`)},
+			},
+			opts:      []ValidateOption{AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": svg icon must not contain scripts`),
+		},
+		{
+			name: "Error/IconEventHandler",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", `<svg viewBox="0 0 100 100" onload="alert(1)"></svg>`)},
+			},
+			opts:      []ValidateOption{AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": svg icon must not contain scripts`),
+		},
+		{
+			name: "Error/IconJavaScriptLink",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href=" Java&#x09;Script:alert(1)"><circle r="1"/></a></svg>`)},
+			},
+			opts:      []ValidateOption{AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": svg icon must not contain scripts`),
+		},
+		{
+			name: "Error/IconForeignObject",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", `<svg><foreignObject><div xmlns="http://www.w3.org/1999/xhtml"></div></foreignObject></svg>`)},
+			},
+			opts:      []ValidateOption{AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": svg icon must not contain scripts`),
+		},
+		{
+			name: "Error/IconUnparseable",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", `<svg><circle r="1"/></svg`)},
+			},
+			opts:      []ValidateOption{AllowPackagesWithoutChannels()},
+			assertion: hasError(`package "foo": svg icon could not be parsed: XML syntax error on line 1: unexpected EOF`),
+		},
+		{
+			name: "Success/IconMediaTypeAllowed",
+			cfg: DeclarativeConfig{
+				Packages: []Package{
+					newTestPackage("foo", "stable", svgSmallCircle),
+					{Schema: SchemaPackage, Name: "bar", Icon: &Icon{Data: []byte{0x89, 'P', 'N', 'G'}, MediaType: "IMAGE/PNG"}},
+					{Schema: SchemaPackage, Name: "baz"},
+				},
+			},
+			opts:      []ValidateOption{WithAllowedIconMediaTypes(), AllowPackagesWithoutChannels()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/IconMediaTypeNotAllowed",
+			cfg: DeclarativeConfig{
+				Packages: []Package{
+					newTestPackage("foo", "stable", svgSmallCircle),
+					{Schema: SchemaPackage, Name: "bar", Icon: &Icon{Data: []byte("GIF89a"), MediaType: "image/gif"}},
+				},
+			},
+			opts: []ValidateOption{WithAllowedIconMediaTypes("image/png"), AllowPackagesWithoutChannels()},
+			assertion: hasError(`[package "foo": icon media type "image/svg+xml" is not one of "image/png", ` +
+				`package "bar": icon media type "image/gif" is not one of "image/png"]`),
+		},
+		{
+			name: "Error/SelfReferencingEntries",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v0.1.0", Replaces: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.2.0", Skips: []string{"foo.v0.1.0", "foo.v0.2.0"}},
+					),
+				},
+			},
+			assertion: hasError(`[package "foo", channel "stable": entry "foo.v0.1.0" replaces itself, package "foo", channel "stable": entry "foo.v0.2.0" skips itself]`),
+		},
+		{
+			name: "Success/SameImageInDifferentPackages",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/bundle:v0.1.0"},
+					{Schema: SchemaBundle, Package: "bar", Name: "bar.v0.1.0", Image: "quay.io/example/bundle:v0.1.0"},
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/DuplicateImageInPackage",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Image: "quay.io/example/foo:v0.1.0"},
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.2.0", Image: "quay.io/example/foo:v0.1.0"},
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.3.0"},
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.4.0"},
+				},
+			},
+			assertion: hasError(`package "foo": image "quay.io/example/foo:v0.1.0" is used by multiple bundles: "foo.v0.1.0", "foo.v0.2.0"`),
+		},
+		{
+			name: "Success/NonNegativeChannelPriority",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					addChannelProperties(newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}), []property.Property{property.MustBuildChannelPriority("stable", 0)}),
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/NegativeChannelPriority",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					addChannelProperties(newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}), []property.Property{property.MustBuildChannelPriority("stable", -1)}),
+				},
+			},
+			assertion: hasError(`package "foo", channel "stable": priority -1 must not be negative`),
+		},
+		{
+			name: "Error/EmptyChannel",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{newTestChannel("foo", "stable")},
+			},
+			assertion: hasError(`package "foo", channel "stable": channel has no entries`),
+		},
+		{
+			name: "Success/AllowEmptyChannels",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{newTestChannel("foo", "stable")},
+			},
+			opts:      []ValidateOption{AllowEmptyChannels()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/DuplicateChannel",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"}),
+					newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.1.0"}),
+					newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"}),
+				},
+			},
+			assertion: hasError(`package "foo", channel "stable": channel is defined 2 times`),
+		},
+		{
+			name: "Error/BundlePackagePropertyMismatch",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "bar", Name: "foo.v0.1.0", Properties: []property.Property{property.MustBuildPackage("foo", "0.1.0")}},
+				},
+			},
+			assertion: hasError(`package "bar", bundle "foo.v0.1.0": "olm.package" property names package "foo"`),
+		},
+		{
+			name: "Success/DeprecationReferencesExist",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+				Channels: []Channel{newTestChannel("foo", "stable", ChannelEntry{Name: "foo.v0.1.0"})},
+				Bundles:  []Bundle{{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0"}},
+				Others: []Meta{{Schema: "olm.deprecations", Package: "foo", Blob: json.RawMessage(`{
+					"schema": "olm.deprecations",
+					"package": "foo",
+					"entries": [
+						{"reference": {"schema": "olm.package"}, "message": "foo is deprecated"},
+						{"reference": {"schema": "olm.channel", "name": "stable"}, "message": "stable is deprecated"},
+						{"reference": {"schema": "olm.bundle", "name": "foo.v0.1.0"}, "message": "foo.v0.1.0 is deprecated"}
+					]
+				}`)}},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/DanglingDeprecationReferences",
+			cfg: DeclarativeConfig{
+				Others: []Meta{{Schema: "olm.deprecations", Package: "foo", Blob: json.RawMessage(`{
+					"schema": "olm.deprecations",
+					"package": "foo",
+					"entries": [
+						{"reference": {"schema": "olm.package"}, "message": "foo is deprecated"},
+						{"reference": {"schema": "olm.channel", "name": "stable"}, "message": "stable is deprecated"},
+						{"reference": {"schema": "olm.bundle", "name": "foo.v0.1.0"}, "message": "foo.v0.1.0 is deprecated"}
+					]
+				}`)}},
+			},
+			assertion: hasError(`[package "foo": deprecation references package that does not exist, package "foo": deprecation references channel "stable" that does not exist, package "foo": deprecation references bundle "foo.v0.1.0" that does not exist]`),
+		},
+		{
+			name: "Error/PackageWithoutChannels",
+			cfg: DeclarativeConfig{
+				Packages: []Package{newTestPackage("foo", "stable", svgSmallCircle)},
+			},
+			assertion: hasError(`package "foo": package has no channels`),
+		},
+		{
+			name: "Error/DuplicateBundleVersion",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v1.0.0", Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0+1")}},
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v1.0.0-rebuild", Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0+2")}},
+					{Schema: SchemaBundle, Package: "bar", Name: "bar.v1.0.0", Properties: []property.Property{property.MustBuildPackage("bar", "1.0.0")}},
+				},
+			},
+			assertion: hasError(`package "foo": version "1.0.0" is shared by bundles "foo.v1.0.0", "foo.v1.0.0-rebuild"`),
+		},
+		{
+			name: "Success/DistinguishBuildMetadata",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v1.0.0", Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0+1")}},
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v1.0.0-rebuild", Properties: []property.Property{property.MustBuildPackage("foo", "1.0.0+2")}},
+				},
+			},
+			opts:      []ValidateOption{DistinguishBuildMetadata()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/CrossChannelReplaces",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.3.0", Replaces: "foo.v0.2.0"},
+					),
+					newTestChannel("foo", "fast", ChannelEntry{Name: "foo.v0.1.0"}),
+					newTestChannel("foo", "beta", ChannelEntry{Name: "foo.v0.1.0"}),
+				},
+			},
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.2.0" replaces "foo.v0.1.0", which is not in this channel, only in other channels: "beta", "fast"`),
+		},
+		{
+			name: "Success/SkipRangeExcludesReplaces",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0"), SkipRange: ">0.1.0 <0.2.0"},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0"), newTestBundle("foo", "0.2.0")},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/SkipRangeIncludesOlderReplaces",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0")},
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), Replaces: testBundleName("foo", "0.1.0"), SkipRange: "<0.2.0"},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0"), newTestBundle("foo", "0.2.0")},
+			},
+			opts:      []ValidateOption{CheckSkipRangeConflicts()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Success/SkipRangeConflictsNotChecked",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), SkipRange: "<=0.2.0"},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.2.0")},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/InvalidSkipRange",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0"), SkipRange: "not-a-range"},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0")},
+			},
+			assertion: hasError(`package "foo", channel "stable": entry "foo.v0.1.0" has invalid skipRange "not-a-range": Could not get version from string: "not-a-range"`),
+		},
+		{
+			name: "Error/SkipRangeConflicts",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: testBundleName("foo", "0.1.0"), Replaces: testBundleName("foo", "0.2.0"), SkipRange: "<0.3.0 >0.1.0"},
+						ChannelEntry{Name: testBundleName("foo", "0.2.0"), SkipRange: "<=0.2.0"},
+					),
+				},
+				Bundles: []Bundle{newTestBundle("foo", "0.1.0"), newTestBundle("foo", "0.2.0")},
+			},
+			opts: []ValidateOption{CheckSkipRangeConflicts()},
+			assertion: hasError(`[package "foo", channel "stable": entry "foo.v0.1.0" skipRange "<0.3.0 >0.1.0" includes version 0.2.0 of replaced bundle "foo.v0.2.0", which is not older than the entry's version 0.1.0, ` +
+				`package "foo", channel "stable": entry "foo.v0.2.0" skipRange "<=0.2.0" includes the entry's own version 0.2.0]`),
+		},
+		{
+			name: "Success/AcyclicDependencies",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Properties: []property.Property{
+						property.MustBuildPackageRequired("bar", ">=0.1.0"),
+						property.MustBuildGVKRequired("example.com", "v1", "Foo"),
+						property.MustBuildGVK("example.com", "v1", "Foo"),
+					}},
+					{Schema: SchemaBundle, Package: "bar", Name: "bar.v0.1.0", Properties: []property.Property{
+						property.MustBuildGVK("example.com", "v1", "Foo"),
+					}},
+				},
+			},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/DependencyCycle",
+			cfg: DeclarativeConfig{
+				Bundles: []Bundle{
+					{Schema: SchemaBundle, Package: "foo", Name: "foo.v0.1.0", Properties: []property.Property{
+						property.MustBuildPackageRequired("bar", ">=0.1.0"),
+					}},
+					{Schema: SchemaBundle, Package: "bar", Name: "bar.v0.1.0", Properties: []property.Property{
+						property.MustBuildGVKRequired("example.com", "v1", "Baz"),
+					}},
+					{Schema: SchemaBundle, Package: "baz", Name: "baz.v0.1.0", Properties: []property.Property{
+						property.MustBuildGVK("example.com", "v1", "Baz"),
+						property.MustBuildPackageRequired("foo", "<1.0.0"),
+					}},
+				},
+			},
+			assertion: hasError(`packages "bar", "baz", "foo" form a dependency cycle bar -> baz -> foo -> bar: ` +
+				`bundle "bar.v0.1.0" requires GVK example.com/v1, Kind=Baz provided by package "baz"; ` +
+				`bundle "baz.v0.1.0" requires package "foo" in range "<1.0.0"; ` +
+				`bundle "foo.v0.1.0" requires package "bar" in range ">=0.1.0"`),
+		},
+		{
+			name: "Error/EmptyOthers",
+			cfg: DeclarativeConfig{
+				Others: []Meta{
+					{Schema: "custom.example.com", Package: "foo", Name: "a", Blob: json.RawMessage(`{"schema":"custom.example.com"}`)},
+					{Schema: "custom.example.com", Package: "foo", Name: "b", Blob: json.RawMessage("  ")},
+					{Schema: "custom.example.com", Blob: json.RawMessage("null")},
+				},
+			},
+			assertion: hasError(`[other object 1 (schema "custom.example.com", package "foo", name "b") has an empty blob, ` +
+				`other object 2 (schema "custom.example.com", package "", name "") has an empty blob]`),
+		},
+		{
+			name: "Success/ConvergentReplaces",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.1.1", Replaces: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.1"},
+					),
+				},
+			},
+			opts:      []ValidateOption{RequireConvergentReplaces()},
+			assertion: require.NoError,
+		},
+		{
+			name: "Error/DeadReplacesBranch",
+			cfg: DeclarativeConfig{
+				Channels: []Channel{
+					newTestChannel("foo", "stable",
+						ChannelEntry{Name: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.1.1", Replaces: "foo.v0.1.0"},
+						ChannelEntry{Name: "foo.v0.1.2", Replaces: "foo.v0.1.1"},
+						ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0", Skips: []string{"foo.v0.1.2"}},
+					),
+				},
+			},
+			opts: []ValidateOption{RequireConvergentReplaces()},
+			assertion: hasError(`[package "foo", channel "stable": replaces chain of entry "foo.v0.1.1" ends at "foo.v0.1.2" instead of channel head "foo.v0.2.0", ` +
+				`package "foo", channel "stable": replaces chain of entry "foo.v0.1.2" ends at "foo.v0.1.2" instead of channel head "foo.v0.2.0"]`),
+		},
+	}
+	for _, s := range specs {
+		t.Run(s.name, func(t *testing.T) {
+			s.assertion(t, Validate(s.cfg, s.opts...))
+		})
+	}
+}
+
+func TestValidateFixtureCatalogs(t *testing.T) {
+	const root = "../action/testdata/index-declcfgs"
+	subdirs, err := os.ReadDir(root)
+	require.NoError(t, err)
+	var dirs []string
+	for _, d := range subdirs {
+		dirs = append(dirs, filepath.Join(root, d.Name()))
+	}
+	for _, dir := range dirs {
+		t.Run(dir, func(t *testing.T) {
+			cfg, err := LoadFS(context.Background(), os.DirFS(dir))
+			require.NoError(t, err)
+			require.NoError(t, Validate(*cfg))
+			require.NoError(t, Validate(*cfg, CheckSkipRangeConflicts()))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package declcfg
+
+// ObjectFunc is called by Walk and WalkMutable for each object in a
+// DeclarativeConfig. For packages, channels, and bundles, schema is the
+// corresponding Schema* constant; for other objects, it is the object's own
+// schema.
+type ObjectFunc func(schema string, obj any) error
+
+// Walk calls fn for every package, channel, bundle, and other object in cfg,
+// in that order, and in the order they appear within each kind. fn receives
+// each object by value (Package, Channel, Bundle, or Meta), so changes made
+// by fn are not reflected in cfg. Walk stops and returns the first error
+// returned by fn.
+func Walk(cfg DeclarativeConfig, fn ObjectFunc) error {
+	for _, p := range cfg.Packages {
+		if err := fn(SchemaPackage, p); err != nil {
+			return err
+		}
+	}
+	for _, c := range cfg.Channels {
+		if err := fn(SchemaChannel, c); err != nil {
+			return err
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if err := fn(SchemaBundle, b); err != nil {
+			return err
+		}
+	}
+	for _, o := range cfg.Others {
+		if err := fn(o.Schema, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkMutable is like Walk, but fn receives a pointer to each object in cfg
+// (*Package, *Channel, *Bundle, or *Meta), so that it can modify the object
+// in place. fn must not add or remove objects from cfg.
+func WalkMutable(cfg *DeclarativeConfig, fn ObjectFunc) error {
+	for i := range cfg.Packages {
+		if err := fn(SchemaPackage, &cfg.Packages[i]); err != nil {
+			return err
+		}
+	}
+	for i := range cfg.Channels {
+		if err := fn(SchemaChannel, &cfg.Channels[i]); err != nil {
+			return err
+		}
+	}
+	for i := range cfg.Bundles {
+		if err := fn(SchemaBundle, &cfg.Bundles[i]); err != nil {
+			return err
+		}
+	}
+	for i := range cfg.Others {
+		if err := fn(cfg.Others[i].Schema, &cfg.Others[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
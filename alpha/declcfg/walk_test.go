@@ -0,0 +1,108 @@
+package declcfg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+
+	var visited []string
+	require.NoError(t, Walk(cfg, func(schema string, obj any) error {
+		switch o := obj.(type) {
+		case Package:
+			visited = append(visited, fmt.Sprintf("%s/%s", schema, o.Name))
+		case Channel:
+			visited = append(visited, fmt.Sprintf("%s/%s/%s", schema, o.Package, o.Name))
+		case Bundle:
+			visited = append(visited, fmt.Sprintf("%s/%s", schema, o.Name))
+			o.Name = "changed"
+		case Meta:
+			visited = append(visited, fmt.Sprintf("%s/%s", schema, o.Package))
+		default:
+			t.Fatalf("unexpected type %T", obj)
+		}
+		return nil
+	}))
+
+	expected := []string{}
+	for _, p := range cfg.Packages {
+		expected = append(expected, SchemaPackage+"/"+p.Name)
+	}
+	for _, c := range cfg.Channels {
+		expected = append(expected, SchemaChannel+"/"+c.Package+"/"+c.Name)
+	}
+	for _, b := range cfg.Bundles {
+		expected = append(expected, SchemaBundle+"/"+b.Name)
+		require.NotEqual(t, "changed", b.Name)
+	}
+	for _, o := range cfg.Others {
+		expected = append(expected, o.Schema+"/"+o.Package)
+	}
+	require.Equal(t, expected, visited)
+}
+
+func TestWalkStopsOnError(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := Walk(cfg, func(schema string, _ any) error {
+		calls++
+		if schema == SchemaChannel {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, len(cfg.Packages)+1, calls)
+
+	calls = 0
+	err = WalkMutable(&cfg, func(schema string, _ any) error {
+		calls++
+		if schema == SchemaBundle {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, len(cfg.Packages)+len(cfg.Channels)+1, calls)
+}
+
+func TestWalkMutable(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+
+	require.NoError(t, WalkMutable(&cfg, func(schema string, obj any) error {
+		switch o := obj.(type) {
+		case *Package:
+			o.Description = "walked"
+		case *Channel:
+			o.Name += "-walked"
+		case *Bundle:
+			o.Image += "-walked"
+		case *Meta:
+			o.Name = "walked"
+		default:
+			t.Fatalf("unexpected type %T", obj)
+		}
+		return nil
+	}))
+
+	for _, p := range cfg.Packages {
+		require.Equal(t, "walked", p.Description)
+	}
+	for _, c := range cfg.Channels {
+		require.Contains(t, c.Name, "-walked")
+	}
+	for _, b := range cfg.Bundles {
+		require.Contains(t, b.Image, "-walked")
+	}
+	require.NotEmpty(t, cfg.Others)
+	for _, o := range cfg.Others {
+		require.Equal(t, "walked", o.Name)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -257,6 +259,95 @@ func (writer *MermaidWriter) filterChannel(c *Channel, versionMap map[string]sem
 	}
 }
 
+// WriteDOT writes the upgrade graph of package pkg in GraphViz DOT format.
+// Each channel is drawn as a cluster subgraph, sorted by channel name, that
+// contains the channel's edges. Bundles are declared once at the top level,
+// sorted by name, so a bundle that appears in several channels is a single
+// node shared by their subgraphs. Edges point from the older bundle to the
+// newer one, and their style indicates how the upgrade is declared: solid
+// for replaces, dashed for skips, and dotted for skipRange.
+//
+// Example output:
+//
+//	digraph "foo" {
+//	  rankdir=LR;
+//	  node [shape=box];
+//	  "foo.v0.1.0";
+//	  "foo.v0.2.0";
+//	  subgraph "cluster_stable" {
+//	    label="stable";
+//	    "foo.v0.1.0" -> "foo.v0.2.0" [label="replaces", style=solid];
+//	  }
+//	}
+func WriteDOT(cfg DeclarativeConfig, pkg string, w io.Writer) error {
+	var channels []Channel
+	for _, c := range cfg.Channels {
+		if c.Package == pkg {
+			channels = append(channels, c)
+		}
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("package %q not found", pkg)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+
+	versions := map[string]semver.Version{}
+	for i := range cfg.Bundles {
+		if cfg.Bundles[i].Package != pkg {
+			continue
+		}
+		v, err := parseVersionProperty(&cfg.Bundles[i])
+		if err != nil {
+			return fmt.Errorf("package %q: %v", pkg, err)
+		}
+		versions[cfg.Bundles[i].Name] = *v
+	}
+
+	nodes := sets.New[string]()
+	clusters := &strings.Builder{}
+	for _, c := range channels {
+		fmt.Fprintf(clusters, "  subgraph %q {\n", "cluster_"+c.Name)
+		fmt.Fprintf(clusters, "    label=%q;\n", c.Name)
+		for _, ce := range c.Entries {
+			nodes.Insert(ce.Name)
+			if ce.Replaces != "" {
+				nodes.Insert(ce.Replaces)
+				fmt.Fprintf(clusters, "    %q -> %q [label=%q, style=solid];\n", ce.Replaces, ce.Name, "replaces")
+			}
+			for _, s := range ce.Skips {
+				nodes.Insert(s)
+				fmt.Fprintf(clusters, "    %q -> %q [label=%q, style=dashed];\n", s, ce.Name, "skips")
+			}
+			if ce.SkipRange != "" {
+				skipRange, err := semver.ParseRange(ce.SkipRange)
+				if err != nil {
+					return fmt.Errorf("package %q, channel %q: entry %q has invalid skipRange %q: %v", pkg, c.Name, ce.Name, ce.SkipRange, err)
+				}
+				for _, e := range c.Entries {
+					if v, ok := versions[e.Name]; ok && e.Name != ce.Name && skipRange(v) {
+						fmt.Fprintf(clusters, "    %q -> %q [label=%q, style=dotted];\n", e.Name, ce.Name, "skipRange "+ce.SkipRange)
+					}
+				}
+			}
+		}
+		clusters.WriteString("  }\n")
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "digraph %q {\n", pkg)
+	out.WriteString("  rankdir=LR;\n")
+	out.WriteString("  node [shape=box];\n")
+	for _, n := range sets.List(nodes) {
+		fmt.Fprintf(out, "  %q;\n", n)
+	}
+	out.WriteString(clusters.String())
+	out.WriteString("}\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
 func parseVersionProperty(b *Bundle) (*semver.Version, error) {
 	props, err := property.Parse(b.Properties)
 	if err != nil {
@@ -311,6 +402,49 @@ func WriteJSON(cfg DeclarativeConfig, w io.Writer) error {
 	return writeToEncoder(cfg, enc)
 }
 
+// WriteCompactJSON writes cfg as a stream of compact JSON objects, one per
+// line. The output is smaller than that of WriteJSON, and can be read by
+// LoadReader just the same.
+func WriteCompactJSON(cfg DeclarativeConfig, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return writeToEncoder(cfg, enc)
+}
+
+// WriteJSONVerbatimOthers is like WriteJSON, but writes the blob of each
+// object in Others exactly as it is, followed by a newline, instead of
+// re-encoding it. Together with WithOthersBlobFormat(OthersBlobOriginal),
+// this reproduces the bytes of the objects in Others that were loaded,
+// which matters when those bytes are signed.
+func WriteJSONVerbatimOthers(cfg DeclarativeConfig, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	enc.SetEscapeHTML(false)
+	return writeToEncoder(cfg, &verbatimOthersEncoder{w: w, enc: enc})
+}
+
+// verbatimOthersEncoder writes Meta values as their raw blobs, and encodes
+// all other values with enc.
+type verbatimOthersEncoder struct {
+	w   io.Writer
+	enc encoder
+}
+
+func (e *verbatimOthersEncoder) Encode(v interface{}) error {
+	m, ok := v.(Meta)
+	if !ok {
+		return e.enc.Encode(v)
+	}
+	if _, err := e.w.Write(m.Blob); err != nil {
+		return err
+	}
+	if len(m.Blob) > 0 && m.Blob[len(m.Blob)-1] == '\n' {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
 func WriteYAML(cfg DeclarativeConfig, w io.Writer) error {
 	enc := newYAMLEncoder(w)
 	enc.SetEscapeHTML(false)
@@ -430,6 +564,104 @@ func writeToEncoder(cfg DeclarativeConfig, enc encoder) error {
 
 type WriteFunc func(config DeclarativeConfig, w io.Writer) error
 
+// WithCanonicalProperties returns a WriteFunc that canonicalizes the value of
+// every package, channel, and bundle property (sorting object keys and
+// removing insignificant whitespace) before calling writeFunc. This makes the
+// output byte-stable regardless of how property values were originally
+// formatted. cfg itself is not modified.
+func WithCanonicalProperties(writeFunc WriteFunc) WriteFunc {
+	return func(cfg DeclarativeConfig, w io.Writer) error {
+		out := cfg
+		out.Packages = make([]Package, len(cfg.Packages))
+		for i, p := range cfg.Packages {
+			props, err := canonicalizeProperties(p.Properties)
+			if err != nil {
+				return fmt.Errorf("package %q: %v", p.Name, err)
+			}
+			p.Properties = props
+			out.Packages[i] = p
+		}
+		out.Channels = make([]Channel, len(cfg.Channels))
+		for i, c := range cfg.Channels {
+			props, err := canonicalizeProperties(c.Properties)
+			if err != nil {
+				return fmt.Errorf("package %q, channel %q: %v", c.Package, c.Name, err)
+			}
+			c.Properties = props
+			out.Channels[i] = c
+		}
+		out.Bundles = make([]Bundle, len(cfg.Bundles))
+		for i, b := range cfg.Bundles {
+			props, err := canonicalizeProperties(b.Properties)
+			if err != nil {
+				return fmt.Errorf("package %q, bundle %q: %v", b.Package, b.Name, err)
+			}
+			b.Properties = props
+			out.Bundles[i] = b
+		}
+		return writeFunc(out, w)
+	}
+}
+
+// Redactor rewrites the JSON blob of an object with the given schema, for
+// example to remove or mask fields that must not be shared. Returning a nil
+// blob omits the object.
+type Redactor func(schema string, blob json.RawMessage) (json.RawMessage, error)
+
+// WithRedactedOthers returns a WriteFunc that passes the blob of every
+// object in cfg.Others through redact before calling writeFunc. Packages,
+// channels, and bundles are written unchanged, and each redacted object is
+// written in the same place as the original. cfg itself is not modified.
+func WithRedactedOthers(redact Redactor, writeFunc WriteFunc) WriteFunc {
+	return func(cfg DeclarativeConfig, w io.Writer) error {
+		out := cfg
+		out.Others = make([]Meta, 0, len(cfg.Others))
+		for _, o := range cfg.Others {
+			blob, err := redact(o.Schema, append(json.RawMessage{}, o.Blob...))
+			if err != nil {
+				return fmt.Errorf("redact %s object %q in package %q: %v", o.Schema, o.Name, o.Package, err)
+			}
+			if blob == nil {
+				continue
+			}
+			if !json.Valid(blob) {
+				return fmt.Errorf("redact %s object %q in package %q: redacted blob is not valid JSON", o.Schema, o.Name, o.Package)
+			}
+			o.Blob = blob
+			out.Others = append(out.Others, o)
+		}
+		return writeFunc(out, w)
+	}
+}
+
+// WithSortedRelatedImages returns a WriteFunc that sorts and de-duplicates
+// the related images of every bundle, as SortRelatedImages does, before
+// calling writeFunc. cfg itself is not modified.
+func WithSortedRelatedImages(writeFunc WriteFunc) WriteFunc {
+	return func(cfg DeclarativeConfig, w io.Writer) error {
+		out := cfg
+		out.Bundles = make([]Bundle, len(cfg.Bundles))
+		copy(out.Bundles, cfg.Bundles)
+		SortRelatedImages(&out)
+		return writeFunc(out, w)
+	}
+}
+
+func canonicalizeProperties(in []property.Property) ([]property.Property, error) {
+	if in == nil {
+		return nil, nil
+	}
+	out := make([]property.Property, len(in))
+	for i, p := range in {
+		v, err := canonicalizeJSON(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("property[%d] of type %q: %v", i, p.Type, err)
+		}
+		out[i] = property.Property{Type: p.Type, Value: v}
+	}
+	return out, nil
+}
+
 func WriteFS(cfg DeclarativeConfig, rootDir string, writeFunc WriteFunc, fileExt string) error {
 	channelsByPackage := map[string][]Channel{}
 	for _, c := range cfg.Channels {
@@ -462,6 +694,89 @@ func WriteFS(cfg DeclarativeConfig, rootDir string, writeFunc WriteFunc, fileExt
 	return nil
 }
 
+// Layout returns the slash-separated path, relative to the output root
+// directory, of the file that an object with the given schema and package
+// is written to by WriteFSLayout. Objects that are not part of a package
+// have an empty package name.
+type Layout func(schema, pkg string) string
+
+// PerPackageSchemaLayout is a Layout that writes each package to its own
+// directory, with separate files for the package, its channels, its
+// bundles, and any other objects:
+//
+//	<package>/package<ext>
+//	<package>/channels<ext>
+//	<package>/bundles<ext>
+//	<package>/others<ext>
+//
+// Objects that are not part of a package are written to others<ext> in the
+// root directory.
+func PerPackageSchemaLayout(fileExt string) Layout {
+	return func(schema, pkg string) string {
+		name := "others"
+		switch schema {
+		case SchemaPackage:
+			name = "package"
+		case SchemaChannel:
+			name = "channels"
+		case SchemaBundle:
+			name = "bundles"
+		}
+		return pathpkg.Join(pkg, name+fileExt)
+	}
+}
+
+// WriteFSLayout writes cfg to files under rootDir, using layout to decide
+// which file each object is written to and writeFunc to write the objects
+// of each file. Unlike WriteFS, which writes a single file per package,
+// this supports layouts that split a package across several files. Loading
+// rootDir with LoadFS returns the objects of cfg.
+func WriteFSLayout(cfg DeclarativeConfig, rootDir string, layout Layout, writeFunc WriteFunc) error {
+	files := map[string]*DeclarativeConfig{}
+	fileFor := func(schema, pkg string) *DeclarativeConfig {
+		path := layout(schema, pkg)
+		if _, ok := files[path]; !ok {
+			files[path] = &DeclarativeConfig{}
+		}
+		return files[path]
+	}
+	for _, p := range cfg.Packages {
+		f := fileFor(SchemaPackage, p.Name)
+		f.Packages = append(f.Packages, p)
+	}
+	for _, c := range cfg.Channels {
+		f := fileFor(SchemaChannel, c.Package)
+		f.Channels = append(f.Channels, c)
+	}
+	for _, b := range cfg.Bundles {
+		f := fileFor(SchemaBundle, b.Package)
+		f.Bundles = append(f.Bundles, b)
+	}
+	for _, o := range cfg.Others {
+		f := fileFor(o.Schema, o.Package)
+		f.Others = append(f.Others, o)
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !fs.ValidPath(path) || path == "." {
+			return fmt.Errorf("layout returned invalid path %q", path)
+		}
+		filename := filepath.Join(rootDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			return err
+		}
+		if err := writeFile(*files[path], filename, writeFunc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func writeFile(cfg DeclarativeConfig, filename string, writeFunc WriteFunc) error {
 	buf := &bytes.Buffer{}
 	if err := writeFunc(cfg, buf); err != nil {
@@ -2,10 +2,18 @@ package declcfg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
 )
 
 func TestWriteJSON(t *testing.T) {
@@ -563,3 +571,253 @@ func TestWriteMermaidChannels(t *testing.T) {
 		})
 	}
 }
+
+func TestWithCanonicalProperties(t *testing.T) {
+	withValue := func(value string) DeclarativeConfig {
+		cfg := buildValidDeclarativeConfig(true)
+		for i := range cfg.Bundles {
+			cfg.Bundles[i].Properties = append(cfg.Bundles[i].Properties, property.Property{
+				Type:  "example.com/config",
+				Value: json.RawMessage(value),
+			})
+		}
+		return cfg
+	}
+	a := withValue(`{"b": {"y": 1, "x": [2, 1]}, "a": "<tag>"}`)
+	b := withValue("{\n  \"a\": \"<tag>\",\n  \"b\": {\"x\": [2, 1], \"y\": 1}\n}")
+
+	// Without canonicalization, JSON output preserves the original key order.
+	var rawA, rawB bytes.Buffer
+	require.NoError(t, WriteJSON(a, &rawA))
+	require.NoError(t, WriteJSON(b, &rawB))
+	require.NotEqual(t, rawA.String(), rawB.String())
+
+	for _, writeFunc := range []WriteFunc{WriteJSON, WriteYAML} {
+		canonical := WithCanonicalProperties(writeFunc)
+		var first string
+		for i := 0; i < 3; i++ {
+			for _, cfg := range []DeclarativeConfig{a, b} {
+				var buf bytes.Buffer
+				require.NoError(t, canonical(cfg, &buf))
+				if first == "" {
+					first = buf.String()
+				}
+				require.Equal(t, first, buf.String())
+			}
+		}
+	}
+
+	// The input config must not be modified.
+	require.Equal(t, withValue(`{"b": {"y": 1, "x": [2, 1]}, "a": "<tag>"}`), a)
+
+	invalid := withValue(`{"a": }`)
+	err := WithCanonicalProperties(WriteJSON)(invalid, &bytes.Buffer{})
+	require.ErrorContains(t, err, `package "anakin", bundle "anakin.v0.0.1": property[`)
+}
+
+func TestWithSortedRelatedImages(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	reversed := buildValidDeclarativeConfig(true)
+	for i := range reversed.Bundles {
+		ris := reversed.Bundles[i].RelatedImages
+		for l, r := 0, len(ris)-1; l < r; l, r = l+1, r-1 {
+			ris[l], ris[r] = ris[r], ris[l]
+		}
+		reversed.Bundles[i].RelatedImages = append(ris, ris...)
+	}
+	unmodified := buildValidDeclarativeConfig(true)
+	for i := range unmodified.Bundles {
+		unmodified.Bundles[i].RelatedImages = append([]RelatedImage{}, reversed.Bundles[i].RelatedImages...)
+	}
+
+	var a, b bytes.Buffer
+	require.NoError(t, WithSortedRelatedImages(WriteJSON)(cfg, &a))
+	require.NoError(t, WithSortedRelatedImages(WriteJSON)(reversed, &b))
+	require.Equal(t, a.String(), b.String())
+
+	// The input config must not be modified.
+	require.Equal(t, unmodified, reversed)
+}
+
+func TestWithRedactedOthers(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	cfg.Others = []Meta{
+		{Schema: "custom.1", Package: "anakin", Blob: json.RawMessage(`{"schema":"custom.1","package":"anakin","internal":"secret","public":"ok"}`)},
+		{Schema: "custom.2", Package: "anakin", Blob: json.RawMessage(`{"schema":"custom.2","package":"anakin"}`)},
+	}
+	original := append([]Meta{}, cfg.Others...)
+
+	redact := func(schema string, blob json.RawMessage) (json.RawMessage, error) {
+		if schema == "custom.2" {
+			return nil, nil
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(blob, &m); err != nil {
+			return nil, err
+		}
+		delete(m, "internal")
+		return json.Marshal(m)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WithRedactedOthers(redact, WriteJSON)(cfg, &buf))
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "custom.2")
+	require.Contains(t, buf.String(), `"public": "ok"`)
+
+	written, err := LoadReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Packages, written.Packages)
+	require.Len(t, written.Others, 1)
+
+	// The input config must not be modified.
+	require.Equal(t, original, cfg.Others)
+
+	invalid := func(string, json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`{"a": }`), nil
+	}
+	err = WithRedactedOthers(invalid, WriteJSON)(cfg, &bytes.Buffer{})
+	require.EqualError(t, err, `redact custom.1 object "" in package "anakin": redacted blob is not valid JSON`)
+}
+
+func TestWriteFSLayout(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	// Bundle objects are not relevant to the layout, and those referenced
+	// by file would not be found after writing.
+	for i, b := range cfg.Bundles {
+		var props []property.Property
+		for _, p := range b.Properties {
+			if p.Type != property.TypeBundleObject {
+				props = append(props, p)
+			}
+		}
+		cfg.Bundles[i].Properties = props
+		cfg.Bundles[i].CsvJSON, cfg.Bundles[i].Objects = "", nil
+	}
+	dir := t.TempDir()
+	require.NoError(t, WriteFSLayout(cfg, dir, PerPackageSchemaLayout(".json"), WriteJSON))
+
+	var files []string
+	require.NoError(t, fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	}))
+	require.ElementsMatch(t, []string{
+		"anakin/package.json", "anakin/channels.json", "anakin/bundles.json", "anakin/others.json",
+		"boba-fett/package.json", "boba-fett/channels.json", "boba-fett/bundles.json", "boba-fett/others.json",
+		"others.json",
+	}, files)
+
+	loaded, err := LoadFS(context.Background(), os.DirFS(dir))
+	require.NoError(t, err)
+	for _, c := range []*DeclarativeConfig{&cfg, loaded} {
+		sort.Slice(c.Channels, func(i, j int) bool {
+			if c.Channels[i].Package != c.Channels[j].Package {
+				return c.Channels[i].Package < c.Channels[j].Package
+			}
+			return c.Channels[i].Name < c.Channels[j].Name
+		})
+	}
+	equalsDeclarativeConfig(t, cfg, *loaded)
+
+	invalid := func(string, string) string { return "../outside.json" }
+	require.EqualError(t, WriteFSLayout(cfg, dir, invalid, WriteJSON), `layout returned invalid path "../outside.json"`)
+}
+
+func TestWriteCompactJSON(t *testing.T) {
+	cfg := buildValidDeclarativeConfig(true)
+	cfg.Packages[0].Description = "anakin <operator> & friends"
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCompactJSON(cfg, &buf))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, len(cfg.Packages)+len(cfg.Channels)+len(cfg.Bundles)+len(cfg.Others))
+	for _, line := range lines {
+		require.True(t, json.Valid([]byte(line)), line)
+	}
+	require.Equal(t, `{"schema":"olm.package","name":"anakin","defaultChannel":"dark","icon":{"base64data":"PHN2ZyB2aWV3Qm94PSIwIDAgMTAwIDEwMCI+PGNpcmNsZSBjeD0iMjUiIGN5PSIyNSIgcj0iMjUiLz48L3N2Zz4=","mediatype":"image/svg+xml"},"description":"anakin <operator> & friends"}`, lines[0])
+
+	// Compact output is loadable and equivalent to indented output.
+	var indented bytes.Buffer
+	require.NoError(t, WriteJSON(cfg, &indented))
+	fromCompact, err := LoadReader(&buf)
+	require.NoError(t, err)
+	fromIndented, err := LoadReader(&indented)
+	require.NoError(t, err)
+	require.Equal(t, fromIndented, fromCompact)
+}
+
+func TestWriteJSONVerbatimOthers(t *testing.T) {
+	var in bytes.Buffer
+	require.NoError(t, WriteJSON(DeclarativeConfig{Packages: []Package{{Schema: SchemaPackage, Name: "foo", DefaultChannel: "stable"}}}, &in))
+	in.WriteString("{ \"schema\":\"custom.1\",  \"package\":\"foo\", \"z\":1, \"a\":[1, 2], \"n\": 1.50 }\n")
+
+	fsys := fstest.MapFS{"catalog.json": &fstest.MapFile{Data: in.Bytes()}}
+	cfg, err := LoadFS(context.Background(), fsys, WithOthersBlobFormat(OthersBlobOriginal))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteJSONVerbatimOthers(*cfg, &out))
+	require.Equal(t, in.String(), out.String())
+
+	// WriteJSON re-encodes the same blob.
+	out.Reset()
+	require.NoError(t, WriteJSON(*cfg, &out))
+	require.NotEqual(t, in.String(), out.String())
+}
+
+func TestWriteDOT(t *testing.T) {
+	bundle := func(version string) Bundle {
+		return Bundle{
+			Schema:     SchemaBundle,
+			Package:    "foo",
+			Name:       testBundleName("foo", version),
+			Properties: []property.Property{property.MustBuildPackage("foo", version)},
+		}
+	}
+	cfg := DeclarativeConfig{
+		Channels: []Channel{
+			newTestChannel("foo", "stable",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+			),
+			newTestChannel("foo", "fast",
+				ChannelEntry{Name: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.2.0", Replaces: "foo.v0.1.0"},
+				ChannelEntry{Name: "foo.v0.3.0", Skips: []string{"foo.v0.2.0"}, SkipRange: "<0.3.0"},
+			),
+			newTestChannel("bar", "stable", ChannelEntry{Name: "bar.v0.1.0"}),
+		},
+		Bundles: []Bundle{bundle("0.1.0"), bundle("0.2.0"), bundle("0.3.0")},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDOT(cfg, "foo", &buf))
+	require.Equal(t, `digraph "foo" {
+  rankdir=LR;
+  node [shape=box];
+  "foo.v0.1.0";
+  "foo.v0.2.0";
+  "foo.v0.3.0";
+  subgraph "cluster_fast" {
+    label="fast";
+    "foo.v0.1.0" -> "foo.v0.2.0" [label="replaces", style=solid];
+    "foo.v0.2.0" -> "foo.v0.3.0" [label="skips", style=dashed];
+    "foo.v0.1.0" -> "foo.v0.3.0" [label="skipRange <0.3.0", style=dotted];
+    "foo.v0.2.0" -> "foo.v0.3.0" [label="skipRange <0.3.0", style=dotted];
+  }
+  subgraph "cluster_stable" {
+    label="stable";
+    "foo.v0.1.0" -> "foo.v0.2.0" [label="replaces", style=solid];
+  }
+}
+`, buf.String())
+
+	require.EqualError(t, WriteDOT(cfg, "baz", &buf), `package "baz" not found`)
+
+	cfg.Channels[1].Entries[2].SkipRange = "not-a-range"
+	require.ErrorContains(t, WriteDOT(cfg, "foo", &buf), `package "foo", channel "fast": entry "foo.v0.3.0" has invalid skipRange "not-a-range"`)
+}
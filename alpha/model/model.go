@@ -195,6 +195,10 @@ func (c *Channel) Validate() error {
 		}
 	}
 
+	if err := c.validateRecommended(); err != nil {
+		result.subErrors = append(result.subErrors, err)
+	}
+
 	for name, b := range c.Bundles {
 		if name != b.Name {
 			result.subErrors = append(result.subErrors, fmt.Errorf("bundle key %q does not match bundle name %q", name, b.Name))
@@ -209,6 +213,24 @@ func (c *Channel) Validate() error {
 	return result.orNil()
 }
 
+// validateRecommended checks that the channel has at most one recommended
+// entry and that it refers to a bundle in the channel.
+func (c *Channel) validateRecommended() error {
+	props, err := property.Parse(c.Properties)
+	if err != nil {
+		return err
+	}
+	if len(props.Recommended) > 1 {
+		return fmt.Errorf("must be at most one property with type %q", property.TypeRecommended)
+	}
+	for _, r := range props.Recommended {
+		if _, ok := c.Bundles[r.Name]; !ok {
+			return fmt.Errorf("recommended entry %q not found in channel", r.Name)
+		}
+	}
+	return nil
+}
+
 // validateReplacesChain checks the replaces chain of a channel.
 // Specifically the following rules must be followed:
 //  1. There must be exactly 1 channel head.
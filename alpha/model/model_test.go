@@ -210,6 +210,14 @@ func TestValidators(t *testing.T) {
 
 	var nilIcon *Icon = nil
 
+	withRecommended := func(names ...string) *Channel {
+		_, ch := makePackageChannelBundle()
+		for _, name := range names {
+			ch.Properties = append(ch.Properties, property.MustBuildRecommended(name))
+		}
+		return ch
+	}
+
 	specs := []spec{
 		{
 			name: "Model/Success/Valid",
@@ -368,6 +376,21 @@ func TestValidators(t *testing.T) {
 			v:         ch,
 			assertion: require.NoError,
 		},
+		{
+			name:      "Channel/Success/Recommended",
+			v:         withRecommended("anakin.v0.0.1"),
+			assertion: require.NoError,
+		},
+		{
+			name:      "Channel/Error/RecommendedNotFound",
+			v:         withRecommended("anakin.v0.0.3"),
+			assertion: hasError(`recommended entry "anakin.v0.0.3" not found in channel`),
+		},
+		{
+			name:      "Channel/Error/MultipleRecommended",
+			v:         withRecommended("anakin.v0.0.1", "anakin.v0.0.2"),
+			assertion: hasError(`must be at most one property with type "olm.channel.recommended"`),
+		},
 		{
 			name:      "Channel/Error/NoName",
 			v:         &Channel{},
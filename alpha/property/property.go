@@ -42,16 +42,23 @@ type Package struct {
 	Version     string `json:"version"`
 }
 
-// NOTICE: The Channel properties are for internal use only.
+// Channel is the olm.channel property, which sets the priority of the
+// channel named ChannelName. It is exposed through declcfg's
+// Channel.Priority.
 //
-//	DO NOT use it for any public-facing functionalities.
-//	This API is in alpha stage and it is subject to change.
+// NOTICE: This API is in alpha stage and it is subject to change.
 type Channel struct {
 	ChannelName string `json:"channelName"`
 	//Priority    string `json:"priority"`
 	Priority int `json:"priority"`
 }
 
+// RecommendedEntry is a channel property that names the entry tooling
+// should highlight as the recommended version of the channel.
+type RecommendedEntry struct {
+	Name string `json:"name"`
+}
+
 type PackageRequired struct {
 	PackageName  string `json:"packageName"`
 	VersionRange string `json:"versionRange"`
@@ -143,13 +150,14 @@ func (f File) GetData(root fs.FS, cwd string) ([]byte, error) {
 }
 
 type Properties struct {
-	Packages         []Package         `hash:"set"`
-	PackagesRequired []PackageRequired `hash:"set"`
-	GVKs             []GVK             `hash:"set"`
-	GVKsRequired     []GVKRequired     `hash:"set"`
-	BundleObjects    []BundleObject    `hash:"set"`
-	Channels         []Channel         `hash:"set"`
-	CSVMetadatas     []CSVMetadata     `hash:"set"`
+	Packages         []Package          `hash:"set"`
+	PackagesRequired []PackageRequired  `hash:"set"`
+	GVKs             []GVK              `hash:"set"`
+	GVKsRequired     []GVKRequired      `hash:"set"`
+	BundleObjects    []BundleObject     `hash:"set"`
+	Channels         []Channel          `hash:"set"`
+	CSVMetadatas     []CSVMetadata      `hash:"set"`
+	Recommended      []RecommendedEntry `hash:"set"`
 
 	Others []Property `hash:"set"`
 }
@@ -162,6 +170,7 @@ const (
 	TypeBundleObject    = "olm.bundle.object"
 	TypeCSVMetadata     = "olm.csv.metadata"
 	TypeChannel         = "olm.channel"
+	TypeRecommended     = "olm.channel.recommended"
 )
 
 func Parse(in []Property) (*Properties, error) {
@@ -213,6 +222,12 @@ func Parse(in []Property) (*Properties, error) {
 				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
 			}
 			out.Channels = append(out.Channels, p)
+		case TypeRecommended:
+			var p RecommendedEntry
+			if err := json.Unmarshal(prop.Value, &p); err != nil {
+				return nil, ParseError{Idx: i, Typ: prop.Type, Err: err}
+			}
+			out.Recommended = append(out.Recommended, p)
 		default:
 			var p json.RawMessage
 			if err := json.Unmarshal(prop.Value, &p); err != nil {
@@ -334,6 +349,10 @@ func MustBuildCSVMetadata(csv v1alpha1.ClusterServiceVersion) Property {
 	})
 }
 
+func MustBuildRecommended(name string) Property {
+	return MustBuild(&RecommendedEntry{Name: name})
+}
+
 // NOTICE: The Channel properties are for internal use only.
 //
 //	DO NOT use it for any public-facing functionalities.
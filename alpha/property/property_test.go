@@ -265,6 +265,13 @@ func TestParse(t *testing.T) {
 			},
 			assertion: assert.Error,
 		},
+		{
+			name: "Error/InvalidRecommended",
+			input: []Property{
+				{Type: TypeRecommended, Value: json.RawMessage(`{`)},
+			},
+			assertion: assert.Error,
+		},
 		{
 			name: "Error/InvalidOther",
 			input: []Property{
@@ -285,6 +292,7 @@ func TestParse(t *testing.T) {
 				MustBuildGVKRequired("other", "v2", "Kind4"),
 				MustBuildBundleObjectRef("testref1"),
 				MustBuildBundleObjectData([]byte("testdata2")),
+				MustBuildRecommended("package1.v0.1.0"),
 				{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 				{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
 			},
@@ -309,6 +317,9 @@ func TestParse(t *testing.T) {
 					{File: File{ref: "testref1"}},
 					{File: File{data: []byte("testdata2")}},
 				},
+				Recommended: []RecommendedEntry{
+					{"package1.v0.1.0"},
+				},
 				Others: []Property{
 					{Type: "otherType1", Value: json.RawMessage(`{"v":"otherValue1"}`)},
 					{Type: "otherType2", Value: json.RawMessage(`["otherValue2"]`)},
@@ -393,6 +404,12 @@ func TestBuild(t *testing.T) {
 			assertion:        require.NoError,
 			expectedProperty: propPtr(MustBuildBundleObjectRef("test")),
 		},
+		{
+			name:             "Success/Recommended",
+			input:            &RecommendedEntry{"name.v0.1.0"},
+			assertion:        require.NoError,
+			expectedProperty: propPtr(MustBuildRecommended("name.v0.1.0")),
+		},
 		{
 			name:             "Success/Property",
 			input:            &Property{Type: "foo", Value: json.RawMessage(`"bar"`)},
@@ -7,15 +7,15 @@ import (
 
 func init() {
 	scheme = map[reflect.Type]string{
-		reflect.TypeOf(&Package{}):         TypePackage,
-		reflect.TypeOf(&PackageRequired{}): TypePackageRequired,
-		reflect.TypeOf(&GVK{}):             TypeGVK,
-		reflect.TypeOf(&GVKRequired{}):     TypeGVKRequired,
-		reflect.TypeOf(&BundleObject{}):    TypeBundleObject,
-		reflect.TypeOf(&CSVMetadata{}):     TypeCSVMetadata,
-		// NOTICE: The Channel properties are for internal use only.
-		//   DO NOT use it for any public-facing functionalities.
-		//   This API is in alpha stage and it is subject to change.
+		reflect.TypeOf(&Package{}):          TypePackage,
+		reflect.TypeOf(&PackageRequired{}):  TypePackageRequired,
+		reflect.TypeOf(&GVK{}):              TypeGVK,
+		reflect.TypeOf(&GVKRequired{}):      TypeGVKRequired,
+		reflect.TypeOf(&BundleObject{}):     TypeBundleObject,
+		reflect.TypeOf(&CSVMetadata{}):      TypeCSVMetadata,
+		reflect.TypeOf(&RecommendedEntry{}): TypeRecommended,
+		// NOTICE: The Channel property is in alpha stage and it is subject
+		//   to change.
 		reflect.TypeOf(&Channel{}): TypeChannel,
 	}
 }
@@ -32,3 +32,14 @@ func AddToScheme(typ string, p interface{}) {
 	}
 	scheme[t] = typ
 }
+
+// RegisteredTypes returns the Go type registered for each property type,
+// including those added with AddToScheme. The returned types are not
+// pointers.
+func RegisteredTypes() map[string]reflect.Type {
+	out := make(map[string]reflect.Type, len(scheme))
+	for t, typ := range scheme {
+		out[typ] = t.Elem()
+	}
+	return out
+}
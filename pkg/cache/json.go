@@ -9,9 +9,12 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/model"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/registry"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -22,6 +25,9 @@ var _ Cache = &JSON{}
 type JSON struct {
 	baseDir string
 
+	// mu guards packageIndex, apiBundles, and the files they refer to
+	// against concurrent updates by UpdatePackage.
+	mu sync.RWMutex
 	packageIndex
 	apiBundles map[apiBundleKey]string
 }
@@ -58,6 +64,8 @@ func (q *JSON) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
 }
 
 func (q *JSON) SendBundles(_ context.Context, s registry.BundleSender) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
 	for _, pkg := range q.packageIndex {
 		channels := sets.KeySet(pkg.Channels)
 		for _, chName := range sets.List(channels) {
@@ -87,7 +95,13 @@ func (q *JSON) SendBundles(_ context.Context, s registry.BundleSender) error {
 	return nil
 }
 
-func (q *JSON) GetBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+func (q *JSON) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.getBundle(ctx, pkgName, channelName, csvName)
+}
+
+func (q *JSON) getBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
 	pkg, ok := q.packageIndex[pkgName]
 	if !ok {
 		return nil, fmt.Errorf("package %q not found", pkgName)
@@ -111,24 +125,67 @@ func (q *JSON) GetBundle(_ context.Context, pkgName, channelName, csvName string
 	return apiBundle, nil
 }
 
+func (q *JSON) ListPackages(ctx context.Context) ([]string, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.ListPackages(ctx)
+}
+
+func (q *JSON) GetPackage(ctx context.Context, name string) (*registry.PackageManifest, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetPackage(ctx, name)
+}
+
+func (q *JSON) GetChannelEntriesThatReplace(ctx context.Context, name string) ([]*registry.ChannelEntry, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetChannelEntriesThatReplace(ctx, name)
+}
+
 func (q *JSON) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
-	return q.packageIndex.GetBundleForChannel(ctx, q, pkgName, channelName)
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetBundleForChannel(ctx, lockedJSON{q}, pkgName, channelName)
 }
 
 func (q *JSON) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
-	return q.packageIndex.GetBundleThatReplaces(ctx, q, name, pkgName, channelName)
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetBundleThatReplaces(ctx, lockedJSON{q}, name, pkgName, channelName)
 }
 
 func (q *JSON) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
-	return q.packageIndex.GetChannelEntriesThatProvide(ctx, q, group, version, kind)
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetChannelEntriesThatProvide(ctx, lockedJSON{q}, group, version, kind)
 }
 
 func (q *JSON) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
-	return q.packageIndex.GetLatestChannelEntriesThatProvide(ctx, q, group, version, kind)
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetLatestChannelEntriesThatProvide(ctx, lockedJSON{q}, group, version, kind)
 }
 
 func (q *JSON) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
-	return q.packageIndex.GetBundleThatProvides(ctx, q, group, version, kind)
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.packageIndex.GetBundleThatProvides(ctx, lockedJSON{q}, group, version, kind)
+}
+
+// lockedJSON is the Cache passed to packageIndex methods, which call back
+// into it, while q.mu is held for reading. Its callbacks do not take the
+// lock again, since a recursive read lock deadlocks if a writer is waiting.
+type lockedJSON struct {
+	*JSON
+}
+
+func (q lockedJSON) GetBundle(ctx context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	return q.getBundle(ctx, pkgName, channelName, csvName)
+}
+
+func (q lockedJSON) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	return q.packageIndex.GetLatestChannelEntriesThatProvide(ctx, q, group, version, kind)
 }
 
 func NewJSON(baseDir string) *JSON {
@@ -136,9 +193,10 @@ func NewJSON(baseDir string) *JSON {
 }
 
 const (
-	jsonDigestFile = "digest"
-	jsonDir        = "cache"
-	packagesFile   = jsonDir + string(filepath.Separator) + "packages.json"
+	jsonDigestFile     = "digest"
+	jsonDir            = "cache"
+	packagesFile       = jsonDir + string(filepath.Separator) + "packages.json"
+	packageDigestsFile = "package-digests.json"
 )
 
 func (q *JSON) CheckIntegrity(fbcFsys fs.FS) error {
@@ -179,6 +237,9 @@ func (q *JSON) computeDigest(fbcFsys fs.FS) (string, error) {
 }
 
 func (q *JSON) Build(ctx context.Context, fbcFsys fs.FS) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	// ensure that generated cache is available to all future users
 	oldUmask := umask(000)
 	defer umask(oldUmask)
@@ -213,24 +274,26 @@ func (q *JSON) Build(ctx context.Context, fbcFsys fs.FS) error {
 	}
 
 	q.apiBundles = map[apiBundleKey]string{}
+	pkgDigests := map[string]string{}
 	for _, p := range fbcModel {
-		for _, ch := range p.Channels {
-			for _, b := range ch.Bundles {
-				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
-				if err != nil {
-					return err
-				}
-				jsonBundle, err := json.Marshal(apiBundle)
-				if err != nil {
-					return err
-				}
-				filename := filepath.Join(q.baseDir, jsonDir, fmt.Sprintf("%s_%s_%s.json", p.Name, ch.Name, b.Name))
-				if err := os.WriteFile(filename, jsonBundle, jsonCacheModeFile); err != nil {
-					return err
-				}
-				q.apiBundles[apiBundleKey{p.Name, ch.Name, b.Name}] = filename
+		files, err := apiBundleFiles(p)
+		if err != nil {
+			return err
+		}
+		for k, jsonBundle := range files {
+			filename := q.apiBundleFilename(k)
+			if err := os.WriteFile(filename, jsonBundle, jsonCacheModeFile); err != nil {
+				return err
 			}
+			q.apiBundles[k] = filename
 		}
+		pkgDigests[p.Name], err = packageDigest(pkgs[p.Name], files)
+		if err != nil {
+			return err
+		}
+	}
+	if err := q.writePackageDigests(pkgDigests); err != nil {
+		return err
 	}
 	digest, err := q.computeDigest(fbcFsys)
 	if err != nil {
@@ -243,6 +306,12 @@ func (q *JSON) Build(ctx context.Context, fbcFsys fs.FS) error {
 }
 
 func (q *JSON) Load() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load()
+}
+
+func (q *JSON) load() error {
 	packagesData, err := os.ReadFile(filepath.Join(q.baseDir, packagesFile))
 	if err != nil {
 		return err
@@ -254,10 +323,237 @@ func (q *JSON) Load() error {
 	for _, p := range q.packageIndex {
 		for _, ch := range p.Channels {
 			for _, b := range ch.Bundles {
-				filename := filepath.Join(q.baseDir, jsonDir, fmt.Sprintf("%s_%s_%s.json", p.Name, ch.Name, b.Name))
-				q.apiBundles[apiBundleKey{pkgName: p.Name, chName: ch.Name, name: b.Name}] = filename
+				k := apiBundleKey{pkgName: p.Name, chName: ch.Name, name: b.Name}
+				q.apiBundles[k] = q.apiBundleFilename(k)
 			}
 		}
 	}
 	return nil
 }
+
+// UpdatePackage replaces the cached entries of package pkgName with the
+// contents of pkgCfg, leaving the entries of every other package untouched.
+// pkgCfg must only contain objects belonging to pkgName, including its
+// olm.package object; if it is completely empty, the package is removed
+// from the cache. fbcFsys must be the full declarative config that already
+// includes the update, so that the cache digest keeps matching it.
+//
+// If the package's contents are unchanged (as determined by its
+// per-package digest), none of its entries are rewritten. UpdatePackage may
+// be called while the cache is serving queries; they wait until the update
+// is complete. If ctx is canceled before the cache is modified, the cache
+// is left unchanged.
+func (q *JSON) UpdatePackage(ctx context.Context, fbcFsys fs.FS, pkgName string, pkgCfg declcfg.DeclarativeConfig) error {
+	if err := checkPackageConfig(pkgName, pkgCfg); err != nil {
+		return err
+	}
+
+	var (
+		newPkg    *cPkg
+		files     = map[apiBundleKey][]byte{}
+		newDigest string
+	)
+	if len(pkgCfg.Packages) > 0 {
+		pkgModel, err := declcfg.ConvertToModel(pkgCfg)
+		if err != nil {
+			return err
+		}
+		pkgs, err := packagesFromModel(pkgModel)
+		if err != nil {
+			return err
+		}
+		p := pkgs[pkgName]
+		newPkg = &p
+		files, err = apiBundleFiles(pkgModel[pkgName])
+		if err != nil {
+			return err
+		}
+		newDigest, err = packageDigest(p, files)
+		if err != nil {
+			return err
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// ensure that generated cache is available to all future users
+	oldUmask := umask(000)
+	defer umask(oldUmask)
+
+	if q.packageIndex == nil {
+		if err := q.load(); err != nil {
+			return fmt.Errorf("load existing cache: %v", err)
+		}
+	}
+	pkgDigests, err := q.readPackageDigests()
+	if err != nil {
+		return fmt.Errorf("read package digests: %v", err)
+	}
+
+	oldDigest, cached := pkgDigests[pkgName]
+	if !cached || oldDigest != newDigest {
+		if err := q.replacePackageEntries(pkgName, newPkg, files); err != nil {
+			return err
+		}
+		if newPkg == nil {
+			delete(pkgDigests, pkgName)
+		} else {
+			pkgDigests[pkgName] = newDigest
+		}
+		if err := q.writePackageDigests(pkgDigests); err != nil {
+			return err
+		}
+	}
+
+	digest, err := q.computeDigest(fbcFsys)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(q.baseDir, jsonDigestFile), []byte(digest), jsonCacheModeFile)
+}
+
+func checkPackageConfig(pkgName string, cfg declcfg.DeclarativeConfig) error {
+	if len(cfg.Packages) > 1 {
+		return fmt.Errorf("expected at most one package, found %d", len(cfg.Packages))
+	}
+	for _, p := range cfg.Packages {
+		if p.Name != pkgName {
+			return fmt.Errorf("config contains package %q, expected only %q", p.Name, pkgName)
+		}
+	}
+	for _, c := range cfg.Channels {
+		if c.Package != pkgName {
+			return fmt.Errorf("config contains channel %q of package %q, expected only %q", c.Name, c.Package, pkgName)
+		}
+	}
+	for _, b := range cfg.Bundles {
+		if b.Package != pkgName {
+			return fmt.Errorf("config contains bundle %q of package %q, expected only %q", b.Name, b.Package, pkgName)
+		}
+	}
+	if len(cfg.Packages) == 0 && (len(cfg.Channels) > 0 || len(cfg.Bundles) > 0 || len(cfg.Others) > 0) {
+		return fmt.Errorf("config for package %q has no %q object, but is not empty", pkgName, declcfg.SchemaPackage)
+	}
+	return nil
+}
+
+// replacePackageEntries removes all bundle files of package pkgName that are
+// not in files, writes files, and stores pkg in the package index. A nil pkg
+// removes the package from the index.
+func (q *JSON) replacePackageEntries(pkgName string, pkg *cPkg, files map[apiBundleKey][]byte) error {
+	for k, filename := range q.apiBundles {
+		if k.pkgName != pkgName {
+			continue
+		}
+		if _, ok := files[k]; ok {
+			continue
+		}
+		if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		delete(q.apiBundles, k)
+	}
+	for k, jsonBundle := range files {
+		filename := q.apiBundleFilename(k)
+		if err := os.WriteFile(filename, jsonBundle, jsonCacheModeFile); err != nil {
+			return err
+		}
+		q.apiBundles[k] = filename
+	}
+
+	if pkg == nil {
+		delete(q.packageIndex, pkgName)
+	} else {
+		q.packageIndex[pkgName] = *pkg
+	}
+	packageJson, err := json.Marshal(q.packageIndex)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(q.baseDir, packagesFile), packageJson, jsonCacheModeFile)
+}
+
+func (q *JSON) apiBundleFilename(k apiBundleKey) string {
+	return filepath.Join(q.baseDir, jsonDir, fmt.Sprintf("%s_%s_%s.json", k.pkgName, k.chName, k.name))
+}
+
+func (q *JSON) readPackageDigests() (map[string]string, error) {
+	pkgDigests := map[string]string{}
+	d, err := os.ReadFile(filepath.Join(q.baseDir, packageDigestsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		// Caches built before per-package digests existed
+		// simply have every package rewritten on update.
+		return pkgDigests, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(d, &pkgDigests); err != nil {
+		return nil, err
+	}
+	return pkgDigests, nil
+}
+
+func (q *JSON) writePackageDigests(pkgDigests map[string]string) error {
+	d, err := json.Marshal(pkgDigests)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(q.baseDir, packageDigestsFile), d, jsonCacheModeFile)
+}
+
+// apiBundleFiles returns the JSON-encoded GRPC bundles of every bundle in p.
+func apiBundleFiles(p *model.Package) (map[apiBundleKey][]byte, error) {
+	files := map[apiBundleKey][]byte{}
+	for _, ch := range p.Channels {
+		for _, b := range ch.Bundles {
+			apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+			if err != nil {
+				return nil, err
+			}
+			jsonBundle, err := json.Marshal(apiBundle)
+			if err != nil {
+				return nil, err
+			}
+			files[apiBundleKey{p.Name, ch.Name, b.Name}] = jsonBundle
+		}
+	}
+	return files, nil
+}
+
+// packageDigest computes a digest covering a package's index entry and all
+// of its bundle files.
+func packageDigest(pkg cPkg, files map[apiBundleKey][]byte) (string, error) {
+	hasher := fnv.New64a()
+	pkgJson, err := json.Marshal(pkg)
+	if err != nil {
+		return "", err
+	}
+	if _, err := hasher.Write(pkgJson); err != nil {
+		return "", err
+	}
+
+	keys := make([]apiBundleKey, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].chName != keys[j].chName {
+			return keys[i].chName < keys[j].chName
+		}
+		return keys[i].name < keys[j].name
+	})
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(hasher, "%s/%s\n", k.chName, k.name); err != nil {
+			return "", err
+		}
+		if _, err := hasher.Write(files[k]); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
@@ -1,13 +1,18 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
 )
 
 func TestJSON_StableDigest(t *testing.T) {
@@ -106,3 +111,128 @@ func TestJSON_CheckIntegrity(t *testing.T) {
 		})
 	}
 }
+
+func TestJSON_UpdatePackage(t *testing.T) {
+	cacheDir := t.TempDir()
+	c := NewJSON(cacheDir)
+	require.NoError(t, c.Build(context.Background(), validFS))
+	require.NoError(t, c.Load())
+
+	digestsBefore, err := c.readPackageDigests()
+	require.NoError(t, err)
+	require.Len(t, digestsBefore, 2)
+
+	// Overwrite an etcd bundle file with a sentinel. If the update rewrites
+	// entries of packages other than cockroachdb, the sentinel is lost.
+	sentinel := []byte("sentinel")
+	etcdFile := c.apiBundleFilename(apiBundleKey{"etcd", "alpha", "etcdoperator-community.v0.6.1"})
+	require.NoError(t, os.WriteFile(etcdFile, sentinel, jsonCacheModeFile))
+
+	pkgCfg, err := declcfg.LoadFile(validFS, "cockroachdb.json")
+	require.NoError(t, err)
+	for i, ch := range pkgCfg.Channels {
+		if ch.Name == "stable-5.x" {
+			pkgCfg.Channels[i].Entries = append(pkgCfg.Channels[i].Entries, declcfg.ChannelEntry{Name: "cockroachdb.v5.0.4", Replaces: "cockroachdb.v5.0.3"})
+		}
+	}
+	pkgCfg.Bundles = append(pkgCfg.Bundles, declcfg.Bundle{
+		Schema:     declcfg.SchemaBundle,
+		Name:       "cockroachdb.v5.0.4",
+		Package:    "cockroachdb",
+		Image:      "quay.io/openshift-community-operators/cockroachdb:v5.0.4",
+		Properties: []property.Property{property.MustBuildPackage("cockroachdb", "5.0.4")},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, declcfg.WriteJSON(*pkgCfg, &buf))
+	updatedFS := fstest.MapFS{
+		"cockroachdb.json": &fstest.MapFile{Data: buf.Bytes()},
+		"etcd.json":        validFS["etcd.json"],
+	}
+
+	require.NoError(t, c.UpdatePackage(context.Background(), updatedFS, "cockroachdb", *pkgCfg))
+	require.NoError(t, c.CheckIntegrity(updatedFS))
+
+	etcdData, err := os.ReadFile(etcdFile)
+	require.NoError(t, err)
+	require.Equal(t, sentinel, etcdData)
+
+	digestsAfter, err := c.readPackageDigests()
+	require.NoError(t, err)
+	require.Equal(t, digestsBefore["etcd"], digestsAfter["etcd"])
+	require.NotEqual(t, digestsBefore["cockroachdb"], digestsAfter["cockroachdb"])
+
+	b, err := c.GetBundleForChannel(context.Background(), "cockroachdb", "stable-5.x")
+	require.NoError(t, err)
+	require.Equal(t, "cockroachdb.v5.0.4", b.CsvName)
+
+	// An update with unchanged contents must not rewrite any entries.
+	cockroachFile := c.apiBundleFilename(apiBundleKey{"cockroachdb", "stable-5.x", "cockroachdb.v5.0.4"})
+	require.NoError(t, os.WriteFile(cockroachFile, sentinel, jsonCacheModeFile))
+	require.NoError(t, c.UpdatePackage(context.Background(), updatedFS, "cockroachdb", *pkgCfg))
+	cockroachData, err := os.ReadFile(cockroachFile)
+	require.NoError(t, err)
+	require.Equal(t, sentinel, cockroachData)
+
+	// A config without the package object is rejected rather than treated
+	// as a removal.
+	withoutPackage := *pkgCfg
+	withoutPackage.Packages = nil
+	require.EqualError(t, c.UpdatePackage(context.Background(), updatedFS, "cockroachdb", withoutPackage),
+		`config for package "cockroachdb" has no "olm.package" object, but is not empty`)
+	_, err = c.GetPackage(context.Background(), "cockroachdb")
+	require.NoError(t, err)
+
+	// A canceled update leaves the cache unchanged.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, c.UpdatePackage(ctx, fstest.MapFS{"etcd.json": validFS["etcd.json"]}, "cockroachdb", declcfg.DeclarativeConfig{}), context.Canceled)
+	_, err = c.GetPackage(context.Background(), "cockroachdb")
+	require.NoError(t, err)
+
+	// Updating with an empty config removes the package.
+	require.NoError(t, c.UpdatePackage(context.Background(), fstest.MapFS{"etcd.json": validFS["etcd.json"]}, "cockroachdb", declcfg.DeclarativeConfig{}))
+	_, err = c.GetPackage(context.Background(), "cockroachdb")
+	require.Error(t, err)
+	_, err = os.Stat(cockroachFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.Error(t, c.UpdatePackage(context.Background(), updatedFS, "etcd", *pkgCfg))
+}
+
+func TestJSON_UpdatePackageConcurrentReads(t *testing.T) {
+	c := NewJSON(t.TempDir())
+	require.NoError(t, c.Build(context.Background(), validFS))
+	require.NoError(t, c.Load())
+
+	pkgCfg, err := declcfg.LoadFile(validFS, "cockroachdb.json")
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	readErrs := make(chan error, 1)
+	go func() {
+		defer close(readErrs)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := c.GetBundleForChannel(context.Background(), "etcd", "alpha"); err != nil {
+				readErrs <- err
+				return
+			}
+			if _, err := c.ListBundles(context.Background()); err != nil {
+				readErrs <- err
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, c.UpdatePackage(context.Background(), validFS, "cockroachdb", declcfg.DeclarativeConfig{}))
+		require.NoError(t, c.UpdatePackage(context.Background(), validFS, "cockroachdb", *pkgCfg))
+	}
+	close(done)
+	require.NoError(t, <-readErrs)
+}
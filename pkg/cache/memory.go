@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+var _ Cache = &Memory{}
+
+// Memory is a Cache that keeps the GRPC representation of a declarative
+// config entirely in memory. Nothing is written to disk, which makes it
+// suitable for tests and other short-lived servers.
+type Memory struct {
+	packageIndex
+	apiBundles map[apiBundleKey]*api.Bundle
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (q *Memory) loadAPIBundle(k apiBundleKey) (*api.Bundle, error) {
+	b, ok := q.apiBundles[k]
+	if !ok {
+		return nil, fmt.Errorf("package %q, channel %q, bundle %q not found", k.pkgName, k.chName, k.name)
+	}
+	// Callers are free to modify the returned bundle, so
+	// never hand out the stored copy.
+	return proto.Clone(b).(*api.Bundle), nil
+}
+
+func (q *Memory) ListBundles(ctx context.Context) ([]*api.Bundle, error) {
+	return listBundles(ctx, q)
+}
+
+func (q *Memory) SendBundles(_ context.Context, s registry.BundleSender) error {
+	for _, pkg := range q.packageIndex {
+		channels := sets.KeySet(pkg.Channels)
+		for _, chName := range sets.List(channels) {
+			ch := pkg.Channels[chName]
+
+			bundles := sets.KeySet(ch.Bundles)
+			for _, bName := range sets.List(bundles) {
+				b := ch.Bundles[bName]
+				apiBundle, err := q.loadAPIBundle(apiBundleKey{pkg.Name, ch.Name, b.Name})
+				if err != nil {
+					return fmt.Errorf("convert bundle %q: %v", b.Name, err)
+				}
+				if apiBundle.BundlePath != "" {
+					// The SQLite-based server
+					// configures its querier to
+					// omit these fields when
+					// bundle path is set.
+					apiBundle.CsvJson = ""
+					apiBundle.Object = nil
+				}
+				if err := s.Send(apiBundle); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (q *Memory) GetBundle(_ context.Context, pkgName, channelName, csvName string) (*api.Bundle, error) {
+	pkg, ok := q.packageIndex[pkgName]
+	if !ok {
+		return nil, fmt.Errorf("package %q not found", pkgName)
+	}
+	ch, ok := pkg.Channels[channelName]
+	if !ok {
+		return nil, fmt.Errorf("package %q, channel %q not found", pkgName, channelName)
+	}
+	b, ok := ch.Bundles[csvName]
+	if !ok {
+		return nil, fmt.Errorf("package %q, channel %q, bundle %q not found", pkgName, channelName, csvName)
+	}
+	apiBundle, err := q.loadAPIBundle(apiBundleKey{pkg.Name, ch.Name, b.Name})
+	if err != nil {
+		return nil, fmt.Errorf("convert bundle %q: %v", b.Name, err)
+	}
+
+	// unset Replaces and Skips (sqlite query does not populate these fields)
+	apiBundle.Replaces = ""
+	apiBundle.Skips = nil
+	return apiBundle, nil
+}
+
+func (q *Memory) GetBundleForChannel(ctx context.Context, pkgName string, channelName string) (*api.Bundle, error) {
+	return q.packageIndex.GetBundleForChannel(ctx, q, pkgName, channelName)
+}
+
+func (q *Memory) GetBundleThatReplaces(ctx context.Context, name, pkgName, channelName string) (*api.Bundle, error) {
+	return q.packageIndex.GetBundleThatReplaces(ctx, q, name, pkgName, channelName)
+}
+
+func (q *Memory) GetChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	return q.packageIndex.GetChannelEntriesThatProvide(ctx, q, group, version, kind)
+}
+
+func (q *Memory) GetLatestChannelEntriesThatProvide(ctx context.Context, group, version, kind string) ([]*registry.ChannelEntry, error) {
+	return q.packageIndex.GetLatestChannelEntriesThatProvide(ctx, q, group, version, kind)
+}
+
+func (q *Memory) GetBundleThatProvides(ctx context.Context, group, version, kind string) (*api.Bundle, error) {
+	return q.packageIndex.GetBundleThatProvides(ctx, q, group, version, kind)
+}
+
+// CheckIntegrity reports whether the cache has been populated. An in-memory
+// cache never outlives its process, so it is considered intact as soon as it
+// holds a declarative config.
+func (q *Memory) CheckIntegrity(_ fs.FS) error {
+	if q.packageIndex == nil {
+		return errors.New("cache requires rebuild: in-memory cache is empty")
+	}
+	return nil
+}
+
+func (q *Memory) Build(ctx context.Context, fbcFsys fs.FS) error {
+	fbc, err := declcfg.LoadFS(ctx, fbcFsys)
+	if err != nil {
+		return err
+	}
+	return q.LoadDeclarativeConfig(*fbc)
+}
+
+// Load is a no-op. Memory is populated by Build or LoadDeclarativeConfig.
+func (q *Memory) Load() error {
+	return nil
+}
+
+// LoadDeclarativeConfig replaces the contents of the cache with cfg. The
+// GRPC bundles are built from the CsvJSON and Objects fields of cfg's
+// bundles, so those must already be populated for the API to serve them.
+func (q *Memory) LoadDeclarativeConfig(cfg declcfg.DeclarativeConfig) error {
+	fbcModel, err := declcfg.ConvertToModel(cfg)
+	if err != nil {
+		return err
+	}
+
+	pkgs, err := packagesFromModel(fbcModel)
+	if err != nil {
+		return err
+	}
+
+	apiBundles := map[apiBundleKey]*api.Bundle{}
+	for _, p := range fbcModel {
+		for _, ch := range p.Channels {
+			for _, b := range ch.Bundles {
+				apiBundle, err := api.ConvertModelBundleToAPIBundle(*b)
+				if err != nil {
+					return err
+				}
+				apiBundles[apiBundleKey{p.Name, ch.Name, b.Name}] = apiBundle
+			}
+		}
+	}
+
+	q.packageIndex = pkgs
+	q.apiBundles = apiBundles
+	return nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	health "github.com/operator-framework/operator-registry/pkg/api/grpc_health_v1"
+	"github.com/operator-framework/operator-registry/pkg/cache"
+)
+
+// ServeDeclarativeConfig starts a registry GRPC server backed by an in-memory
+// cache of cfg, without sqlite or an on-disk cache. The server listens on a
+// random local port and its address is returned alongside it. Callers are
+// responsible for stopping the server.
+//
+// Bundles are served from their GRPC-compat fields (CsvJSON and Objects), so
+// cfg should come from declcfg.LoadFS or similar for those to be populated.
+func ServeDeclarativeConfig(cfg declcfg.DeclarativeConfig) (*grpc.Server, string, error) {
+	store := cache.NewMemory()
+	if err := store.LoadDeclarativeConfig(cfg); err != nil {
+		return nil, "", fmt.Errorf("load declarative config: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen: %v", err)
+	}
+
+	s := grpc.NewServer()
+	api.RegisterRegistryServer(s, NewRegistryServer(store))
+	health.RegisterHealthServer(s, NewHealthServer())
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	return s, lis.Addr().String(), nil
+}
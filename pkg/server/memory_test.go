@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func TestServeDeclarativeConfig(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.yaml": &fstest.MapFile{Data: []byte(`---
+schema: olm.package
+name: foo
+defaultChannel: stable
+---
+schema: olm.channel
+package: foo
+name: stable
+entries:
+  - name: foo.v0.1.0
+  - name: foo.v0.2.0
+    replaces: foo.v0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.1.0
+image: quay.io/example/foo-bundle:v0.1.0
+properties:
+  - type: olm.package
+    value:
+      packageName: foo
+      version: 0.1.0
+---
+schema: olm.bundle
+package: foo
+name: foo.v0.2.0
+image: quay.io/example/foo-bundle:v0.2.0
+properties:
+  - type: olm.package
+    value:
+      packageName: foo
+      version: 0.2.0
+`)},
+	}
+	cfg, err := declcfg.LoadFS(context.Background(), fsys)
+	require.NoError(t, err)
+
+	s, addr, err := ServeDeclarativeConfig(*cfg)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	c, conn := client(t, addr)
+	defer conn.Close()
+
+	pkg, err := c.GetPackage(context.TODO(), &api.GetPackageRequest{Name: "foo"})
+	require.NoError(t, err)
+	require.Equal(t, "stable", pkg.GetDefaultChannelName())
+	require.Len(t, pkg.GetChannels(), 1)
+	require.Equal(t, "foo.v0.2.0", pkg.GetChannels()[0].GetCsvName())
+
+	b, err := c.GetBundleForChannel(context.TODO(), &api.GetBundleInChannelRequest{PkgName: "foo", ChannelName: "stable"})
+	require.NoError(t, err)
+	require.Equal(t, "foo.v0.2.0", b.GetCsvName())
+	require.Equal(t, "quay.io/example/foo-bundle:v0.2.0", b.GetBundlePath())
+
+	_, _, err = ServeDeclarativeConfig(declcfg.DeclarativeConfig{Channels: []declcfg.Channel{{Name: "stable", Package: "missing"}}})
+	require.Error(t, err)
+}